@@ -0,0 +1,1110 @@
+package access
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/waiyan/bridge/internal/k8s"
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Bridge label and annotation keys on grant resources
+const (
+	LabelManagedBy      = "app.kubernetes.io/managed-by"
+	LabelAccessUser     = "bridge.io/access-user"
+	LabelGrantID        = "bridge.io/grant-id"
+	LabelCreatedAt      = "bridge.io/created-at"
+	AnnotationExpiresAt = "bridge.io/expires-at"
+	ManagedByBridge     = "bridge"
+
+	// LabelGrantNamespace marks Roles and bindings outside the grant's own
+	// namespace (and cluster-scoped ones) with the namespace of its ServiceAccount
+	LabelGrantNamespace = "bridge.io/grant-namespace"
+
+	// AnnotationNamespaces lists the namespaces a grant covers (comma-separated)
+	AnnotationNamespaces = "bridge.io/namespaces"
+	// AnnotationScope is ScopeNamespace or ScopeCluster
+	AnnotationScope = "bridge.io/scope"
+
+	// LabelKind tells apart the Bridge objects kept in SystemNamespace
+	LabelKind = "bridge.io/kind"
+	// LabelTemplate holds the name of an access template, on its ConfigMap and on grants made from it
+	LabelTemplate = "bridge.io/template"
+	// AnnotationUpdatedAt records when a stored object was last changed
+	AnnotationUpdatedAt = "bridge.io/updated-at"
+
+	// SystemNamespace holds state Bridge shares between everyone using it against a cluster
+	SystemNamespace = "bridge-system"
+	// KindTemplate marks access template ConfigMaps
+	KindTemplate = "access-template"
+)
+
+// Grant scopes
+const (
+	ScopeNamespace = "namespace"
+	ScopeCluster   = "cluster"
+)
+
+// Error codes reported in *Error. They double as the API error codes.
+const (
+	CodeInvalidRequest    = "INVALID_REQUEST"
+	CodeAlreadyExists     = "ALREADY_EXISTS"
+	CodeNotBridgeManaged  = "NOT_BRIDGE_MANAGED"
+	CodeNotFound          = "NOT_FOUND"
+	CodeCreateSA          = "CREATE_SA_FAILED"
+	CodeCreateRole        = "CREATE_ROLE_FAILED"
+	CodeCreateRoleBinding = "CREATE_ROLEBINDING_FAILED"
+	CodeCreateSecret      = "CREATE_SECRET_FAILED"
+	CodeTokenNotReady     = "TOKEN_NOT_READY"
+	CodeTokenRequest      = "TOKEN_REQUEST_FAILED"
+	CodeRevoke            = "PARTIAL_DELETE"
+	CodeInvalidRules      = "INVALID_RULES"
+	CodeDiscovery         = "DISCOVERY_FAILED"
+	CodeInvalidTemplate   = "INVALID_TEMPLATE"
+	CodeBuiltInTemplate   = "BUILT_IN_TEMPLATE"
+)
+
+// rollbackTimeout bounds cleanup after a failed grant; it runs even if the request was cancelled
+const rollbackTimeout = 30 * time.Second
+
+// Error is a grant failure tagged with the step that failed
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError wraps err with a code
+func newError(code string, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// ErrorCode returns the code of an *Error, or "" for other errors
+func ErrorCode(err error) string {
+	var accessErr *Error
+	if errors.As(err, &accessErr) {
+		return accessErr.Code
+	}
+	return ""
+}
+
+// GrantRequest describes the access to create
+type GrantRequest struct {
+	// ID names the grant's objects. Empty for a new grant, which gets a unique ID;
+	// set it to reconcile an existing grant.
+	ID        string
+	UserLabel string
+	Namespace string // where the ServiceAccount lives
+	Rules     []rbacv1.PolicyRule
+	Duration  time.Duration // 0 for permanent access
+	// ExpiresAt, when set, replaces now + Duration, to recreate a grant with its exact expiry
+	ExpiresAt time.Time
+
+	// Namespaces the rules apply in; defaults to Namespace. Ignored for cluster scope.
+	Namespaces []string
+	// ClusterScope grants the rules cluster-wide through a ClusterRole and ClusterRoleBinding
+	ClusterScope bool
+	// Template is the access template the rules came from, if any
+	Template string
+
+	// BreakGlass marks emergency access: at most MaxBreakGlassDuration, with a justification
+	BreakGlass bool
+	// Justification is why the grant was taken, recorded on its ServiceAccount
+	Justification string
+
+	// Subject binds the rules to an existing User or Group instead of the ServiceAccount,
+	// whose kubeconfigs then log in through Login. The ServiceAccount is still created as
+	// the grant's record and owner, but it is not bound and gets no token.
+	Subject *Subject
+	Login   *Login
+
+	// Reconcile updates an existing Bridge-managed grant with the same ID
+	// instead of failing with CodeAlreadyExists
+	Reconcile bool
+}
+
+// Grant is a created (or reconciled) grant and its credentials
+type Grant struct {
+	Name           string // the grant ID
+	Namespace      string
+	Username       string
+	ServiceAccount string
+	Role           string // the ClusterRole for cluster-scoped grants
+	RoleBinding    string // the ClusterRoleBinding for cluster-scoped grants
+	Namespaces     []string
+	ClusterScope   bool
+	Template       string
+	BreakGlass     bool
+	Justification  string
+	Subject        *Subject // the bound User or Group of identity grants
+	Login          *Login
+	Token          string
+	CACert         string // only set for permanent grants, from the token Secret
+	TokenSecret    string // the current token Secret of a permanent grant, once recorded
+	CreatedAt      string
+	ExpiresAt      time.Time
+	Extensions     []Extension
+	Rotations      []Rotation
+}
+
+// Scope returns ScopeCluster or ScopeNamespace
+func (g *Grant) Scope() string {
+	if g.ClusterScope {
+		return ScopeCluster
+	}
+	return ScopeNamespace
+}
+
+// FromServiceAccount describes the grant a Bridge ServiceAccount belongs to.
+// Grants created before multi-namespace support cover only their own namespace,
+// and grants created before grant IDs are named after their user label.
+func FromServiceAccount(sa *corev1.ServiceAccount) *Grant {
+	name := sa.Labels[LabelGrantID]
+	if name == "" {
+		name = strings.TrimSuffix(sa.Name, "-sa")
+	}
+	_, roleName, bindingName, _ := Names(name)
+
+	grant := &Grant{
+		Name:           name,
+		Namespace:      sa.Namespace,
+		Username:       sa.Labels[LabelAccessUser],
+		ServiceAccount: sa.Name,
+		Role:           roleName,
+		RoleBinding:    bindingName,
+		ClusterScope:   sa.Annotations[AnnotationScope] == ScopeCluster,
+		Template:       sa.Labels[LabelTemplate],
+		BreakGlass:     sa.Labels[LabelBreakGlass] == "true",
+		Justification:  sa.Annotations[AnnotationJustification],
+		CreatedAt:      sa.Labels[LabelCreatedAt],
+	}
+	if grant.CreatedAt == "" {
+		grant.CreatedAt = sa.CreationTimestamp.Format(time.RFC3339)
+	}
+	if grant.ClusterScope {
+		grant.Role = ClusterRoleName(sa.Namespace, name)
+		grant.RoleBinding = grant.Role
+	} else if namespaces := sa.Annotations[AnnotationNamespaces]; namespaces != "" {
+		grant.Namespaces = strings.Split(namespaces, ",")
+	} else {
+		grant.Namespaces = []string{sa.Namespace}
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, sa.Annotations[AnnotationExpiresAt]); err == nil {
+		grant.ExpiresAt = expiresAt
+	}
+	grant.Extensions = extensionsFromAnnotation(sa.Annotations[AnnotationExtensions])
+	grant.TokenSecret = sa.Annotations[AnnotationTokenSecret]
+	grant.Rotations = rotationsFromAnnotation(sa.Annotations[AnnotationRotations])
+	if grant.Subject = subjectFromAnnotation(sa.Annotations[AnnotationSubject]); grant.Subject != nil {
+		grant.Login = loginFromAnnotation(sa.Annotations[AnnotationLogin])
+	}
+	return grant
+}
+
+// ParseDuration converts a duration string (e.g., "1h", "8h", "24h", "7d") to time.Duration
+// Returns 0 for "0" or empty string (permanent access)
+func ParseDuration(durationStr string) (time.Duration, error) {
+	if durationStr == "" || durationStr == "0" {
+		return 0, nil // Permanent access
+	}
+
+	// Handle days (not natively supported by time.ParseDuration)
+	if strings.HasSuffix(durationStr, "d") {
+		daysStr := strings.TrimSuffix(durationStr, "d")
+		days, err := time.ParseDuration(daysStr + "h")
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", durationStr)
+		}
+		// Convert hours to days (multiply by 24 since we parsed as hours)
+		return days * 24, nil
+	}
+
+	return time.ParseDuration(durationStr)
+}
+
+// sanitizeName converts a string to a valid Kubernetes resource name
+func sanitizeName(name string) string {
+	// Convert to lowercase
+	name = strings.ToLower(name)
+	// Replace spaces and underscores with hyphens
+	name = strings.ReplaceAll(name, " ", "-")
+	name = strings.ReplaceAll(name, "_", "-")
+	// Remove any characters that aren't alphanumeric or hyphens
+	name = invalidNameChars.ReplaceAllString(name, "")
+	// Remove leading/trailing hyphens
+	name = strings.Trim(name, "-")
+	// Limit length to 63 characters (K8s limit)
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]")
+
+// timestamp returns the current time in RFC3339 for annotations
+func timestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// bridgeLabels returns the standard Bridge labels
+func bridgeLabels(username string) map[string]string {
+	// Format timestamp without colons as they're invalid in labels
+	// Use format: 2006-01-02T15-04-05Z (RFC3339 with dashes instead of colons)
+	timestamp := time.Now().UTC().Format("2006-01-02T15-04-05Z")
+	return map[string]string{
+		LabelManagedBy:  ManagedByBridge,
+		LabelAccessUser: sanitizeName(username), // Sanitize username for label safety
+		LabelCreatedAt:  timestamp,
+	}
+}
+
+// grantIDSuffixBytes is how many random bytes tell apart the grants of one user label
+const grantIDSuffixBytes = 3
+
+// randomSuffix returns a short random hex string for unique names
+func randomSuffix() (string, error) {
+	b := make([]byte, grantIDSuffixBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewGrantID returns a unique grant ID: the sanitized user label and a random suffix,
+// short enough to be a label value
+func NewGrantID(userLabel string) (string, error) {
+	suffix, err := randomSuffix()
+	if err != nil {
+		return "", err
+	}
+	base := sanitizeName(userLabel)
+	if base == "" {
+		base = "grant"
+	}
+	if max := 63 - 1 - 2*grantIDSuffixBytes; len(base) > max {
+		base = strings.TrimRight(base[:max], "-")
+	}
+	return base + "-" + suffix, nil
+}
+
+// UserSelector returns the label selector of every grant of a user label
+func UserSelector(userLabel string) string {
+	return fmt.Sprintf("%s=%s,%s=%s", LabelManagedBy, ManagedByBridge, LabelAccessUser, sanitizeName(userLabel))
+}
+
+// Names returns the resource names derived from a grant ID
+func Names(name string) (sa, role, binding, secret string) {
+	return name + "-sa", name + "-role", name + "-binding", name + "-token"
+}
+
+// ClusterRoleName returns the name of the ClusterRole and ClusterRoleBinding of a
+// cluster-scoped grant. It includes the namespace since cluster names are global.
+func ClusterRoleName(namespace, name string) string {
+	return "bridge:" + namespace + ":" + name
+}
+
+// targetNamespaces returns the deduplicated namespaces a namespaced request covers
+func targetNamespaces(req GrantRequest) []string {
+	if len(req.Namespaces) == 0 {
+		return []string{req.Namespace}
+	}
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, ns := range req.Namespaces {
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
+// Manager creates and revokes access grants
+type Manager struct {
+	clientsetFor func(ctx context.Context) (kubernetes.Interface, error)
+	// tokenClientset returns a client for the request's cluster that authenticates with a grant's token
+	tokenClientset func(ctx context.Context, token string) (kubernetes.Interface, error)
+	// impersonatedClientset returns a client for the request's cluster that acts as another user
+	impersonatedClientset func(ctx context.Context, impersonate rest.ImpersonationConfig) (kubernetes.Interface, error)
+	// clusterFor describes the request's cluster for generated kubeconfigs
+	clusterFor func(ctx context.Context) (*Cluster, error)
+	// execCommands are the credential plugins exec logins may run; none disables exec logins
+	execCommands map[string]bool
+}
+
+// NewManager creates a new access Manager using the request's kube context
+func NewManager(k8sService *k8s.Service) *Manager {
+	return &Manager{
+		clientsetFor: func(ctx context.Context) (kubernetes.Interface, error) {
+			return k8sService.ClientsetFor(ctx)
+		},
+		tokenClientset: func(ctx context.Context, token string) (kubernetes.Interface, error) {
+			config, err := k8sService.ConfigFor(ctx)
+			if err != nil {
+				return nil, err
+			}
+			// Keep the server, TLS and proxy settings but drop Bridge's own credentials
+			tokenConfig := rest.AnonymousClientConfig(config)
+			tokenConfig.BearerToken = token
+			return kubernetes.NewForConfig(tokenConfig)
+		},
+		impersonatedClientset: func(ctx context.Context, impersonate rest.ImpersonationConfig) (kubernetes.Interface, error) {
+			config, err := k8sService.ConfigFor(ctx)
+			if err != nil {
+				return nil, err
+			}
+			impersonated := rest.CopyConfig(config)
+			impersonated.Impersonate = impersonate
+			return kubernetes.NewForConfig(impersonated)
+		},
+		clusterFor: func(ctx context.Context) (*Cluster, error) {
+			config, err := k8sService.ConfigFor(ctx)
+			if err != nil {
+				return nil, err
+			}
+			name, raw := k8sService.GetManager().GetClusterForContext(k8s.KubeContextFrom(ctx))
+			return ClusterFromConfig(config, name, raw)
+		},
+	}
+}
+
+// transaction records how to undo each step of a grant
+type transaction struct {
+	undo []func(ctx context.Context) error
+	desc []string
+}
+
+func (t *transaction) add(desc string, undo func(ctx context.Context) error) {
+	t.desc = append(t.desc, desc)
+	t.undo = append(t.undo, undo)
+}
+
+// rollback undoes all recorded steps in reverse order
+func (t *transaction) rollback() {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		if err := t.undo[i](ctx); err != nil && !apierrors.IsNotFound(err) {
+			log.Printf("[Access] Rollback failed for %s: %v", t.desc[i], err)
+			continue
+		}
+		log.Printf("[Access] Rolled back %s", t.desc[i])
+	}
+}
+
+// ownerReference points dependents at the grant's ServiceAccount so deleting it cascades
+func ownerReference(sa *corev1.ServiceAccount) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ServiceAccount",
+		Name:       sa.Name,
+		UID:        sa.UID,
+	}
+}
+
+// checkManaged rejects reusing an object Bridge doesn't own, or any existing object unless reconciling
+func checkManaged(kind string, obj metav1.Object, reconcile bool) error {
+	ref := obj.GetName()
+	if obj.GetNamespace() != "" {
+		ref = obj.GetNamespace() + "/" + ref
+	}
+	if obj.GetLabels()[LabelManagedBy] != ManagedByBridge {
+		return newError(CodeNotBridgeManaged, "%s %s already exists and is not managed by Bridge", kind, ref)
+	}
+	if !reconcile {
+		return newError(CodeAlreadyExists, "%s %s already exists; revoke the existing grant or set reconcile to update it", kind, ref)
+	}
+	return nil
+}
+
+// Create creates a grant all-or-nothing: the ServiceAccount, a Role and RoleBinding per
+// target namespace (or a ClusterRole and ClusterRoleBinding), and for permanent grants a
+// token Secret. Identity grants bind their Subject and have no token. Dependents in the ServiceAccount's namespace are owned by it; ownerReferences
+// cannot cross namespaces, so the rest carry LabelGrantNamespace and are deleted by Revoke.
+// Rules are validated against discovery first. If any step fails, everything created
+// or changed so far is rolled back.
+func (m *Manager) Create(ctx context.Context, req GrantRequest) (*Grant, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Subject != nil {
+		if err := m.validateIdentity(req.Subject, req.Login); err != nil {
+			return nil, err
+		}
+	}
+	if req.Template == BreakGlassTemplate && !req.BreakGlass {
+		return nil, newError(CodeInvalidTemplate, "the %s template is only available through break-glass access", BreakGlassTemplate)
+	}
+	if req.BreakGlass {
+		if req.Duration <= 0 || req.Duration > MaxBreakGlassDuration {
+			return nil, newError(CodeInvalidRequest, "break-glass access lasts at most %s", MaxBreakGlassDuration)
+		}
+		if len(strings.TrimSpace(req.Justification)) < MinJustificationLength {
+			return nil, newError(CodeInvalidRequest, "break-glass access needs a justification of at least %d characters", MinJustificationLength)
+		}
+	}
+	if err := ValidateRules(clientset.Discovery(), req.Rules, req.ClusterScope); err != nil {
+		return nil, err
+	}
+
+	name := req.ID
+	if name == "" {
+		if req.Reconcile {
+			return nil, newError(CodeInvalidRequest, "the id of the grant to reconcile is required")
+		}
+		if name, err = NewGrantID(req.UserLabel); err != nil {
+			return nil, err
+		}
+	}
+	saName, roleName, bindingName, secretName := Names(name)
+	labels := bridgeLabels(req.UserLabel)
+	labels[LabelGrantID] = name
+	if req.Template != "" {
+		labels[LabelTemplate] = req.Template
+	}
+	if req.BreakGlass {
+		labels[LabelBreakGlass] = "true"
+	}
+
+	grant := &Grant{
+		Name:           name,
+		Namespace:      req.Namespace,
+		Username:       labels[LabelAccessUser],
+		ServiceAccount: saName,
+		Role:           roleName,
+		RoleBinding:    bindingName,
+		ClusterScope:   req.ClusterScope,
+		Template:       req.Template,
+		BreakGlass:     req.BreakGlass,
+		Justification:  req.Justification,
+		CreatedAt:      labels[LabelCreatedAt],
+	}
+
+	annotations := map[string]string{
+		AnnotationScope: grant.Scope(),
+	}
+	if req.Justification != "" {
+		annotations[AnnotationJustification] = req.Justification
+	}
+	if req.Subject != nil {
+		login, err := json.Marshal(req.Login)
+		if err != nil {
+			return nil, err
+		}
+		grant.Subject = req.Subject
+		grant.Login = req.Login
+		annotations[AnnotationSubject] = req.Subject.String()
+		annotations[AnnotationLogin] = string(login)
+	}
+	if req.ClusterScope {
+		grant.Role = ClusterRoleName(req.Namespace, name)
+		grant.RoleBinding = grant.Role
+	} else {
+		grant.Namespaces = targetNamespaces(req)
+		annotations[AnnotationNamespaces] = strings.Join(grant.Namespaces, ",")
+	}
+	if req.Duration > 0 {
+		grant.ExpiresAt = time.Now().Add(req.Duration)
+		if !req.ExpiresAt.IsZero() {
+			grant.ExpiresAt = req.ExpiresAt
+		}
+		annotations[AnnotationExpiresAt] = grant.ExpiresAt.Format(time.RFC3339)
+	}
+
+	// Remember the shape of a grant being reconciled so roles it no longer needs can be removed
+	var previous *Grant
+	if req.Reconcile {
+		if sa, err := clientset.CoreV1().ServiceAccounts(req.Namespace).Get(ctx, saName, metav1.GetOptions{}); err == nil {
+			previous = FromServiceAccount(sa)
+		}
+	}
+	if previous != nil && previous.BreakGlass != req.BreakGlass {
+		return nil, newError(CodeBreakGlass, "'%s' is break-glass access or is being turned into it; revoke it and create a new grant instead", name)
+	}
+	if req.Duration == 0 && req.Subject == nil {
+		// A rotated grant keeps its current token
+		if previous != nil && previous.TokenSecret != "" {
+			secretName = previous.TokenSecret
+		}
+		annotations[AnnotationTokenSecret] = secretName
+		grant.TokenSecret = secretName
+	}
+
+	tx := &transaction{}
+	ok := false
+	defer func() {
+		if !ok {
+			tx.rollback()
+		}
+	}()
+
+	// Step A: ServiceAccount (the owner of everything in its namespace)
+	sa, err := m.applyServiceAccount(ctx, clientset, tx, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        saName,
+			Namespace:   req.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}, req.Reconcile)
+	if err != nil {
+		return nil, err
+	}
+	owner := []metav1.OwnerReference{ownerReference(sa)}
+
+	subjects := []rbacv1.Subject{
+		{
+			Kind:      "ServiceAccount",
+			Name:      saName,
+			Namespace: req.Namespace,
+		},
+	}
+	if req.Subject != nil {
+		subjects = []rbacv1.Subject{req.Subject.rbacSubject()}
+	}
+	expiry := map[string]string{}
+	if expiresAt, found := annotations[AnnotationExpiresAt]; found {
+		expiry[AnnotationExpiresAt] = expiresAt
+	}
+	remoteLabels := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		remoteLabels[k] = v
+	}
+	remoteLabels[LabelGrantNamespace] = req.Namespace
+
+	if req.ClusterScope {
+		// Steps B and C: ClusterRole and ClusterRoleBinding
+		err = m.applyClusterRole(ctx, clientset, tx, &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        grant.Role,
+				Labels:      remoteLabels,
+				Annotations: expiry,
+			},
+			Rules: req.Rules,
+		}, req.Reconcile)
+		if err != nil {
+			return nil, err
+		}
+
+		err = m.applyClusterRoleBinding(ctx, clientset, tx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        grant.RoleBinding,
+				Labels:      remoteLabels,
+				Annotations: expiry,
+			},
+			Subjects: subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: "rbac.authorization.k8s.io",
+				Kind:     "ClusterRole",
+				Name:     grant.Role,
+			},
+		}, req.Reconcile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Steps B and C: a Role and RoleBinding in every target namespace
+		for _, ns := range grant.Namespaces {
+			meta := metav1.ObjectMeta{
+				Namespace:   ns,
+				Labels:      remoteLabels,
+				Annotations: expiry,
+			}
+			if ns == req.Namespace {
+				meta.Labels = labels
+				meta.OwnerReferences = owner
+			}
+
+			roleMeta := meta
+			roleMeta.Name = roleName
+			err = m.applyRole(ctx, clientset, tx, &rbacv1.Role{
+				ObjectMeta: roleMeta,
+				Rules:      req.Rules,
+			}, req.Reconcile)
+			if err != nil {
+				return nil, err
+			}
+
+			bindingMeta := meta
+			bindingMeta.Name = bindingName
+			err = m.applyRoleBinding(ctx, clientset, tx, &rbacv1.RoleBinding{
+				ObjectMeta: bindingMeta,
+				Subjects:   subjects,
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "Role",
+					Name:     roleName,
+				},
+			}, req.Reconcile)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Step D: credentials; identity grants log in on their own
+	switch {
+	case req.Subject != nil:
+	case req.Duration == 0:
+		err = m.permanentToken(ctx, clientset, tx, grant, secretName, labels, owner, req.Reconcile)
+	default:
+		err = m.ephemeralToken(ctx, clientset, grant, int64(req.Duration.Seconds()))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok = true
+	if previous != nil {
+		m.pruneRoles(ctx, clientset, previous, grant)
+	}
+	return grant, nil
+}
+
+// pruneRoles removes the roles of a reconciled grant that its new shape no longer covers.
+// It runs after the grant has been committed, so failures are only logged.
+func (m *Manager) pruneRoles(ctx context.Context, clientset kubernetes.Interface, previous, current *Grant) {
+	stale := &Grant{
+		Name:         previous.Name,
+		Namespace:    previous.Namespace,
+		ClusterScope: previous.ClusterScope && !current.ClusterScope,
+	}
+	keep := map[string]bool{}
+	for _, ns := range current.Namespaces {
+		keep[ns] = true
+	}
+	for _, ns := range previous.Namespaces {
+		if !keep[ns] {
+			stale.Namespaces = append(stale.Namespaces, ns)
+		}
+	}
+
+	if err := errors.Join(deleteRoles(ctx, clientset, stale)...); err != nil {
+		log.Printf("[Access] Failed to remove stale roles of %s/%s: %v", previous.Namespace, previous.Name, err)
+	}
+}
+
+// applyServiceAccount creates the ServiceAccount, or updates it when reconciling
+func (m *Manager) applyServiceAccount(ctx context.Context, clientset kubernetes.Interface, tx *transaction, desired *corev1.ServiceAccount, reconcile bool) (*corev1.ServiceAccount, error) {
+	client := clientset.CoreV1().ServiceAccounts(desired.Namespace)
+
+	created, err := client.Create(ctx, desired, metav1.CreateOptions{})
+	if err == nil {
+		tx.add("ServiceAccount "+desired.Name, func(ctx context.Context) error {
+			return client.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		})
+		return created, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, newError(CodeCreateSA, "failed to create ServiceAccount: %v", err)
+	}
+
+	existing, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, newError(CodeCreateSA, "failed to read existing ServiceAccount: %v", err)
+	}
+	if err := checkManaged("ServiceAccount", existing, reconcile); err != nil {
+		return nil, err
+	}
+
+	previous := existing.DeepCopy()
+	existing.Labels = desired.Labels
+	existing.Annotations = mergeAnnotations(existing.Annotations, desired.Annotations)
+	updated, err := client.Update(ctx, existing, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, newError(CodeCreateSA, "failed to update ServiceAccount: %v", err)
+	}
+	tx.add("ServiceAccount "+desired.Name+" update", func(ctx context.Context) error {
+		current, err := client.Get(ctx, previous.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Labels = previous.Labels
+		current.Annotations = previous.Annotations
+		_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+	return updated, nil
+}
+
+// applyRole creates the Role, or replaces its rules when reconciling
+func (m *Manager) applyRole(ctx context.Context, clientset kubernetes.Interface, tx *transaction, desired *rbacv1.Role, reconcile bool) error {
+	client := clientset.RbacV1().Roles(desired.Namespace)
+
+	_, err := client.Create(ctx, desired, metav1.CreateOptions{})
+	if err == nil {
+		tx.add("Role "+desired.Name, func(ctx context.Context) error {
+			return client.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		})
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return newError(CodeCreateRole, "failed to create Role: %v", err)
+	}
+
+	existing, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		return newError(CodeCreateRole, "failed to read existing Role: %v", err)
+	}
+	if err := checkManaged("Role", existing, reconcile); err != nil {
+		return err
+	}
+
+	previous := existing.DeepCopy()
+	existing.Labels = desired.Labels
+	existing.Annotations = mergeAnnotations(existing.Annotations, desired.Annotations)
+	existing.OwnerReferences = desired.OwnerReferences
+	existing.Rules = desired.Rules
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return newError(CodeCreateRole, "failed to update Role: %v", err)
+	}
+	tx.add("Role "+desired.Name+" update", func(ctx context.Context) error {
+		current, err := client.Get(ctx, previous.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Labels = previous.Labels
+		current.Annotations = previous.Annotations
+		current.OwnerReferences = previous.OwnerReferences
+		current.Rules = previous.Rules
+		_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+	return nil
+}
+
+// applyRoleBinding creates the RoleBinding, or updates its subjects when reconciling
+func (m *Manager) applyRoleBinding(ctx context.Context, clientset kubernetes.Interface, tx *transaction, desired *rbacv1.RoleBinding, reconcile bool) error {
+	client := clientset.RbacV1().RoleBindings(desired.Namespace)
+
+	_, err := client.Create(ctx, desired, metav1.CreateOptions{})
+	if err == nil {
+		tx.add("RoleBinding "+desired.Name, func(ctx context.Context) error {
+			return client.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		})
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return newError(CodeCreateRoleBinding, "failed to create RoleBinding: %v", err)
+	}
+
+	existing, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		return newError(CodeCreateRoleBinding, "failed to read existing RoleBinding: %v", err)
+	}
+	if err := checkManaged("RoleBinding", existing, reconcile); err != nil {
+		return err
+	}
+	if existing.RoleRef != desired.RoleRef {
+		// roleRef is immutable
+		return newError(CodeCreateRoleBinding, "RoleBinding %s/%s points at a different role and cannot be reconciled", desired.Namespace, desired.Name)
+	}
+
+	previous := existing.DeepCopy()
+	existing.Labels = desired.Labels
+	existing.Annotations = mergeAnnotations(existing.Annotations, desired.Annotations)
+	existing.OwnerReferences = desired.OwnerReferences
+	existing.Subjects = desired.Subjects
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return newError(CodeCreateRoleBinding, "failed to update RoleBinding: %v", err)
+	}
+	tx.add("RoleBinding "+desired.Name+" update", func(ctx context.Context) error {
+		current, err := client.Get(ctx, previous.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Labels = previous.Labels
+		current.Annotations = previous.Annotations
+		current.OwnerReferences = previous.OwnerReferences
+		current.Subjects = previous.Subjects
+		_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+	return nil
+}
+
+// applyClusterRole creates the ClusterRole, or replaces its rules when reconciling
+func (m *Manager) applyClusterRole(ctx context.Context, clientset kubernetes.Interface, tx *transaction, desired *rbacv1.ClusterRole, reconcile bool) error {
+	client := clientset.RbacV1().ClusterRoles()
+
+	_, err := client.Create(ctx, desired, metav1.CreateOptions{})
+	if err == nil {
+		tx.add("ClusterRole "+desired.Name, func(ctx context.Context) error {
+			return client.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		})
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return newError(CodeCreateRole, "failed to create ClusterRole: %v", err)
+	}
+
+	existing, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		return newError(CodeCreateRole, "failed to read existing ClusterRole: %v", err)
+	}
+	if err := checkManaged("ClusterRole", existing, reconcile); err != nil {
+		return err
+	}
+
+	previous := existing.DeepCopy()
+	existing.Labels = desired.Labels
+	existing.Annotations = mergeAnnotations(existing.Annotations, desired.Annotations)
+	existing.Rules = desired.Rules
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return newError(CodeCreateRole, "failed to update ClusterRole: %v", err)
+	}
+	tx.add("ClusterRole "+desired.Name+" update", func(ctx context.Context) error {
+		current, err := client.Get(ctx, previous.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Labels = previous.Labels
+		current.Annotations = previous.Annotations
+		current.Rules = previous.Rules
+		_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+	return nil
+}
+
+// applyClusterRoleBinding creates the ClusterRoleBinding, or updates its subjects when reconciling
+func (m *Manager) applyClusterRoleBinding(ctx context.Context, clientset kubernetes.Interface, tx *transaction, desired *rbacv1.ClusterRoleBinding, reconcile bool) error {
+	client := clientset.RbacV1().ClusterRoleBindings()
+
+	_, err := client.Create(ctx, desired, metav1.CreateOptions{})
+	if err == nil {
+		tx.add("ClusterRoleBinding "+desired.Name, func(ctx context.Context) error {
+			return client.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		})
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return newError(CodeCreateRoleBinding, "failed to create ClusterRoleBinding: %v", err)
+	}
+
+	existing, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		return newError(CodeCreateRoleBinding, "failed to read existing ClusterRoleBinding: %v", err)
+	}
+	if err := checkManaged("ClusterRoleBinding", existing, reconcile); err != nil {
+		return err
+	}
+	if existing.RoleRef != desired.RoleRef {
+		// roleRef is immutable
+		return newError(CodeCreateRoleBinding, "ClusterRoleBinding %s points at a different role and cannot be reconciled", desired.Name)
+	}
+
+	previous := existing.DeepCopy()
+	existing.Labels = desired.Labels
+	existing.Annotations = mergeAnnotations(existing.Annotations, desired.Annotations)
+	existing.Subjects = desired.Subjects
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return newError(CodeCreateRoleBinding, "failed to update ClusterRoleBinding: %v", err)
+	}
+	tx.add("ClusterRoleBinding "+desired.Name+" update", func(ctx context.Context) error {
+		current, err := client.Get(ctx, previous.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Labels = previous.Labels
+		current.Annotations = previous.Annotations
+		current.Subjects = previous.Subjects
+		_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+	return nil
+}
+
+// permanentToken creates (or, when reconciling, reuses) a long-lived token Secret and waits for it to be populated
+func (m *Manager) permanentToken(ctx context.Context, clientset kubernetes.Interface, tx *transaction, grant *Grant, secretName string, labels map[string]string, owner []metav1.OwnerReference, reconcile bool) error {
+	client := clientset.CoreV1().Secrets(grant.Namespace)
+
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: grant.Namespace,
+			Annotations: map[string]string{
+				corev1.ServiceAccountNameKey: grant.ServiceAccount,
+			},
+			Labels:          labels,
+			OwnerReferences: owner,
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+
+	_, err := client.Create(ctx, tokenSecret, metav1.CreateOptions{})
+	switch {
+	case err == nil:
+		tx.add("Secret "+secretName, func(ctx context.Context) error {
+			return client.Delete(ctx, secretName, metav1.DeleteOptions{})
+		})
+	case apierrors.IsAlreadyExists(err):
+		existing, getErr := client.Get(ctx, secretName, metav1.GetOptions{})
+		if getErr != nil {
+			return newError(CodeCreateSecret, "failed to read existing token Secret: %v", getErr)
+		}
+		if err := checkManaged("Secret", existing, reconcile); err != nil {
+			return err
+		}
+		if existing.Annotations[corev1.ServiceAccountNameKey] != grant.ServiceAccount {
+			return newError(CodeCreateSecret, "Secret %s/%s is not a token for %s", grant.Namespace, secretName, grant.ServiceAccount)
+		}
+	default:
+		return newError(CodeCreateSecret, "failed to create token Secret: %v", err)
+	}
+
+	// Wait for the token controller to populate the Secret
+	for i := 0; i < 10; i++ {
+		secret, err := client.Get(ctx, secretName, metav1.GetOptions{})
+		if err == nil {
+			if t, ok := secret.Data[corev1.ServiceAccountTokenKey]; ok && len(t) > 0 {
+				grant.Token = string(t)
+			}
+			if ca, ok := secret.Data[corev1.ServiceAccountRootCAKey]; ok && len(ca) > 0 {
+				grant.CACert = string(ca)
+			}
+			if grant.Token != "" && grant.CACert != "" {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return newError(CodeTokenNotReady, "cancelled while waiting for token: %v", ctx.Err())
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	if grant.Token == "" {
+		return newError(CodeTokenNotReady, "token was not generated in time; the ServiceAccount may not be configured correctly")
+	}
+	return nil
+}
+
+// ephemeralToken mints a TokenRequest token that expires with the grant
+func (m *Manager) ephemeralToken(ctx context.Context, clientset kubernetes.Interface, grant *Grant, expirationSeconds int64) error {
+	tokenRequest := &authv1.TokenRequest{
+		Spec: authv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+
+	tokenResponse, err := clientset.CoreV1().ServiceAccounts(grant.Namespace).CreateToken(
+		ctx, grant.ServiceAccount, tokenRequest, metav1.CreateOptions{},
+	)
+	if err != nil {
+		return newError(CodeTokenRequest, "failed to create ephemeral token: %v", err)
+	}
+	grant.Token = tokenResponse.Status.Token
+	return nil
+}
+
+// Revoke deletes a grant's resources in every namespace it covers. Grants created before
+// ownerReferences were added have no cascade, so every object is deleted explicitly.
+func (m *Manager) Revoke(ctx context.Context, namespace, name string) error {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	saName, _, _, secretName := Names(name)
+
+	sa, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return newError(CodeNotFound, "Bridge access user '%s' not found in namespace '%s'", name, namespace)
+		}
+		return err
+	}
+	if sa.Labels[LabelManagedBy] != ManagedByBridge {
+		return newError(CodeNotBridgeManaged, "ServiceAccount %s/%s is not managed by Bridge and cannot be revoked", namespace, saName)
+	}
+
+	// The current token Secret, and any replaced ones still in their grace period
+	grant := FromServiceAccount(sa)
+	secretNames := map[string]bool{secretName: true, grant.TokenSecretName(): true}
+	if secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: LabelManagedBy + "=" + ManagedByBridge + "," + LabelGrantID + "=" + grant.Name,
+	}); err == nil {
+		for _, secret := range secrets.Items {
+			secretNames[secret.Name] = true
+		}
+	}
+
+	var deleteErrors []error
+	for secret := range secretNames {
+		deleteErrors = append(deleteErrors, ignoreNotFound("Secret "+secret, clientset.CoreV1().Secrets(namespace).Delete(ctx, secret, metav1.DeleteOptions{})))
+	}
+	deleteErrors = append(deleteErrors, deleteRoles(ctx, clientset, grant)...)
+	deleteErrors = append(deleteErrors, ignoreNotFound("ServiceAccount", clientset.CoreV1().ServiceAccounts(namespace).Delete(ctx, saName, metav1.DeleteOptions{})))
+
+	if err := errors.Join(deleteErrors...); err != nil {
+		return &Error{Code: CodeRevoke, Err: fmt.Errorf("some resources could not be deleted: %w", err)}
+	}
+	return nil
+}
+
+// deleteRoles deletes a grant's bindings and roles: per namespace, and cluster-wide for cluster scope
+func deleteRoles(ctx context.Context, clientset kubernetes.Interface, grant *Grant) []error {
+	_, roleName, bindingName, _ := Names(grant.Name)
+
+	var deleteErrors []error
+	for _, ns := range grant.Namespaces {
+		deleteErrors = append(deleteErrors, ignoreNotFound("RoleBinding "+ns, clientset.RbacV1().RoleBindings(ns).Delete(ctx, bindingName, metav1.DeleteOptions{})))
+		deleteErrors = append(deleteErrors, ignoreNotFound("Role "+ns, clientset.RbacV1().Roles(ns).Delete(ctx, roleName, metav1.DeleteOptions{})))
+	}
+	if grant.ClusterScope {
+		clusterName := ClusterRoleName(grant.Namespace, grant.Name)
+		deleteErrors = append(deleteErrors, ignoreNotFound("ClusterRoleBinding", clientset.RbacV1().ClusterRoleBindings().Delete(ctx, clusterName, metav1.DeleteOptions{})))
+		deleteErrors = append(deleteErrors, ignoreNotFound("ClusterRole", clientset.RbacV1().ClusterRoles().Delete(ctx, clusterName, metav1.DeleteOptions{})))
+	}
+	return deleteErrors
+}
+
+// ignoreNotFound labels a delete error with its kind, dropping "not found"
+func ignoreNotFound(kind string, err error) error {
+	if err == nil || apierrors.IsNotFound(err) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", kind, err)
+}
+
+// mergeAnnotations overlays desired on existing. Grant annotations missing from desired are
+// dropped: a grant switched to permanent loses its expiry, one switched to cluster scope its
+// namespace list, one switched to temporary its token Secret, and so on.
+func mergeAnnotations(existing, desired map[string]string) map[string]string {
+	merged := make(map[string]string, len(existing)+len(desired))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for _, key := range []string{AnnotationExpiresAt, AnnotationNamespaces, AnnotationTokenSecret, AnnotationSubject, AnnotationLogin, AnnotationJustification} {
+		if _, ok := desired[key]; !ok {
+			delete(merged, key)
+		}
+	}
+	for k, v := range desired {
+		merged[k] = v
+	}
+	return merged
+}
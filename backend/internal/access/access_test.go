@@ -0,0 +1,251 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testResources is the API surface the fake clientset's discovery reports
+var testResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods", Namespaced: true, Verbs: []string{"get", "list", "watch", "create", "update", "patch", "delete"}},
+			{Name: "pods/log", Namespaced: true, Verbs: []string{"get"}},
+			{Name: "nodes", Namespaced: false, Verbs: []string{"get", "list", "watch"}},
+		},
+	},
+	{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Namespaced: true, Verbs: []string{"get", "list", "watch", "create", "update", "patch", "delete"}},
+		},
+	},
+}
+
+// newTestManager returns a Manager backed by a fake clientset that answers TokenRequests
+func newTestManager(objects ...runtime.Object) (*Manager, *fake.Clientset) {
+	clientset := fake.NewClientset(objects...)
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = testResources
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		return true, &authv1.TokenRequest{Status: authv1.TokenRequestStatus{Token: "test-token"}}, nil
+	})
+	return &Manager{
+		clientsetFor: func(ctx context.Context) (kubernetes.Interface, error) {
+			return clientset, nil
+		},
+	}, clientset
+}
+
+func testRequest() GrantRequest {
+	return GrantRequest{
+		ID:        "alice-dev",
+		UserLabel: "Alice Dev",
+		Namespace: "team-a",
+		Rules:     []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		Duration:  time.Hour,
+	}
+}
+
+func TestCreate(t *testing.T) {
+	managedSA := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name: "alice-dev-sa", Namespace: "team-a",
+		Labels: map[string]string{LabelManagedBy: ManagedByBridge},
+	}}
+	foreignSA := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "alice-dev-sa", Namespace: "team-a"}}
+
+	tests := []struct {
+		name      string
+		existing  []runtime.Object
+		failOn    string // resource whose create fails
+		reconcile bool
+		wantCode  string
+		wantSA    bool // ServiceAccount present afterwards
+	}{
+		{name: "creates grant", wantSA: true},
+		{name: "rolls back on rolebinding failure", failOn: "rolebindings", wantCode: CodeCreateRoleBinding},
+		{name: "rolls back on role failure", failOn: "roles", wantCode: CodeCreateRole},
+		{name: "rejects existing grant", existing: []runtime.Object{managedSA}, wantCode: CodeAlreadyExists, wantSA: true},
+		{name: "rejects foreign service account", existing: []runtime.Object{foreignSA}, reconcile: true, wantCode: CodeNotBridgeManaged, wantSA: true},
+		{name: "reconciles existing grant", existing: []runtime.Object{managedSA}, reconcile: true, wantSA: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, clientset := newTestManager(tt.existing...)
+			if tt.failOn != "" {
+				clientset.PrependReactor("create", tt.failOn, func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("boom")
+				})
+			}
+
+			req := testRequest()
+			req.Reconcile = tt.reconcile
+			grant, err := m.Create(context.Background(), req)
+			if got := ErrorCode(err); got != tt.wantCode {
+				t.Fatalf("Create() error = %v, want code %q", err, tt.wantCode)
+			}
+
+			ctx := context.Background()
+			_, saErr := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+			if tt.wantSA != (saErr == nil) {
+				t.Errorf("ServiceAccount present = %v, want %v", saErr == nil, tt.wantSA)
+			}
+
+			if tt.wantCode != "" {
+				if _, err := clientset.RbacV1().Roles("team-a").Get(ctx, "alice-dev-role", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+					t.Errorf("Role left behind after failed grant: %v", err)
+				}
+				return
+			}
+
+			if grant.Token != "test-token" {
+				t.Errorf("grant token = %q, want test-token", grant.Token)
+			}
+			role, err := clientset.RbacV1().Roles("team-a").Get(ctx, "alice-dev-role", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Role not created: %v", err)
+			}
+			if len(role.OwnerReferences) != 1 || role.OwnerReferences[0].Name != "alice-dev-sa" {
+				t.Errorf("Role ownerReferences = %v, want the grant ServiceAccount", role.OwnerReferences)
+			}
+		})
+	}
+}
+
+func TestCreateScopes(t *testing.T) {
+	tests := []struct {
+		name         string
+		namespaces   []string
+		clusterScope bool
+		reconcileTo  []string // namespaces after reconciling, if set
+		wantRoles    []string // namespaces holding a Role after Create (and reconcile)
+	}{
+		{name: "single namespace", wantRoles: []string{"team-a"}},
+		{name: "multiple namespaces", namespaces: []string{"team-a", "team-b", "team-b"}, wantRoles: []string{"team-a", "team-b"}},
+		{name: "other namespaces only", namespaces: []string{"team-b"}, wantRoles: []string{"team-b"}},
+		{name: "cluster scope", clusterScope: true},
+		{name: "reconcile drops namespace", namespaces: []string{"team-a", "team-b"}, reconcileTo: []string{"team-a"}, wantRoles: []string{"team-a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, clientset := newTestManager()
+			ctx := context.Background()
+
+			req := testRequest()
+			req.Namespaces = tt.namespaces
+			req.ClusterScope = tt.clusterScope
+			if _, err := m.Create(ctx, req); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if tt.reconcileTo != nil {
+				req.Namespaces = tt.reconcileTo
+				req.Reconcile = true
+				if _, err := m.Create(ctx, req); err != nil {
+					t.Fatalf("reconcile Create() error = %v", err)
+				}
+			}
+
+			sa, err := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("ServiceAccount not created: %v", err)
+			}
+			grant := FromServiceAccount(sa)
+			if grant.ClusterScope != tt.clusterScope {
+				t.Errorf("ClusterScope = %v, want %v", grant.ClusterScope, tt.clusterScope)
+			}
+
+			roles, err := clientset.RbacV1().Roles("").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, role := range roles.Items {
+				got = append(got, role.Namespace)
+				home := role.Namespace == "team-a"
+				if home != (len(role.OwnerReferences) == 1) {
+					t.Errorf("Role in %s ownerReferences = %v", role.Namespace, role.OwnerReferences)
+				}
+				if !home && role.Labels[LabelGrantNamespace] != "team-a" {
+					t.Errorf("Role in %s missing %s label", role.Namespace, LabelGrantNamespace)
+				}
+			}
+			if len(got) != len(tt.wantRoles) {
+				t.Errorf("Roles in %v, want %v", got, tt.wantRoles)
+			}
+
+			_, err = clientset.RbacV1().ClusterRoles().Get(ctx, ClusterRoleName("team-a", "alice-dev"), metav1.GetOptions{})
+			if tt.clusterScope != (err == nil) {
+				t.Errorf("ClusterRole present = %v, want %v", err == nil, tt.clusterScope)
+			}
+
+			if err := m.Revoke(ctx, "team-a", "alice-dev"); err != nil {
+				t.Fatalf("Revoke() error = %v", err)
+			}
+			roles, _ = clientset.RbacV1().Roles("").List(ctx, metav1.ListOptions{})
+			bindings, _ := clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+			if len(roles.Items) != 0 || len(bindings.Items) != 0 {
+				t.Errorf("Revoke left %d Roles and %d ClusterRoleBindings", len(roles.Items), len(bindings.Items))
+			}
+		})
+	}
+}
+
+func TestCreateGrantIDs(t *testing.T) {
+	m, clientset := newTestManager()
+	ctx := context.Background()
+
+	req := testRequest()
+	req.ID = ""
+	first, err := m.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	second, err := m.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("second Create() error = %v", err)
+	}
+	if first.Name == second.Name || !strings.HasPrefix(first.Name, "alice-dev-") {
+		t.Fatalf("grant IDs %q and %q, want distinct IDs prefixed with the user label", first.Name, second.Name)
+	}
+
+	sas, err := clientset.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{LabelSelector: UserSelector("Alice Dev")})
+	if err != nil || len(sas.Items) != 2 {
+		t.Fatalf("grants of the user = %d, %v; want 2", len(sas.Items), err)
+	}
+	for _, sa := range sas.Items {
+		if id := FromServiceAccount(&sa).Name; id != first.Name && id != second.Name {
+			t.Errorf("ServiceAccount %s belongs to grant %q", sa.Name, id)
+		}
+	}
+
+	req.Reconcile = true
+	if _, err := m.Create(ctx, req); ErrorCode(err) != CodeInvalidRequest {
+		t.Errorf("reconcile without an ID: error = %v, want %s", err, CodeInvalidRequest)
+	}
+
+	if err := m.Revoke(ctx, "team-a", first.Name); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := clientset.RbacV1().Roles("team-a").Get(ctx, second.Role, metav1.GetOptions{}); err != nil {
+		t.Errorf("revoking one grant removed the other's Role: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package access
+
+import "strings"
+
+// ApprovalPolicy decides whether access needs an approver, and who may approve
+type ApprovalPolicy struct {
+	// Required turns direct grants and imports into access requests
+	Required bool
+	// Approvers are the emails or subjects that may decide requests
+	Approvers []string
+	// ApproverGroups are the OIDC groups whose members may decide requests
+	ApproverGroups []string
+}
+
+// Restricted reports whether only listed approvers may decide requests
+func (p ApprovalPolicy) Restricted() bool {
+	return len(p.Approvers) > 0 || len(p.ApproverGroups) > 0
+}
+
+// CanDecide reports whether the identity with this email, subject and groups may decide
+// access requests. Without a list of approvers anyone but the requester may.
+func (p ApprovalPolicy) CanDecide(email, subject string, groups []string) bool {
+	if !p.Restricted() {
+		return true
+	}
+	for _, approver := range p.Approvers {
+		if (email != "" && strings.EqualFold(approver, email)) || strings.EqualFold(approver, subject) {
+			return true
+		}
+	}
+	for _, group := range groups {
+		for _, approverGroup := range p.ApproverGroups {
+			if strings.EqualFold(group, approverGroup) {
+				return true
+			}
+		}
+	}
+	return false
+}
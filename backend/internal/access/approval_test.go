@@ -0,0 +1,32 @@
+package access
+
+import "testing"
+
+func TestApprovalPolicyCanDecide(t *testing.T) {
+	policy := ApprovalPolicy{
+		Approvers:      []string{"lead@example.com", "local"},
+		ApproverGroups: []string{"platform-admins"},
+	}
+	tests := []struct {
+		name    string
+		policy  ApprovalPolicy
+		email   string
+		subject string
+		groups  []string
+		want    bool
+	}{
+		{name: "anyone without approvers", policy: ApprovalPolicy{}, subject: "anonymous", want: true},
+		{name: "listed email", policy: policy, email: "Lead@Example.com", subject: "1234", want: true},
+		{name: "listed subject", policy: policy, subject: "local", want: true},
+		{name: "member of an approver group", policy: policy, email: "dev@example.com", groups: []string{"devs", "Platform-Admins"}, want: true},
+		{name: "someone else", policy: policy, email: "dev@example.com", subject: "5678", groups: []string{"devs"}},
+		{name: "empty email does not match", policy: ApprovalPolicy{Approvers: []string{""}}, subject: "5678"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CanDecide(tt.email, tt.subject, tt.groups); got != tt.want {
+				t.Errorf("CanDecide(%q, %q, %v) = %v, want %v", tt.email, tt.subject, tt.groups, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+)
+
+// BreakGlassTemplate is the access template every break-glass grant is made from
+const BreakGlassTemplate = "break-glass"
+
+// Break-glass guardrails
+const (
+	// MaxBreakGlassDuration caps break-glass grants; it is also the default
+	MaxBreakGlassDuration = time.Hour
+	// BreakGlassLimit is how many break-glass grants one person can take per BreakGlassWindow
+	BreakGlassLimit = 3
+	// BreakGlassWindow is the rolling window BreakGlassLimit applies to
+	BreakGlassWindow = 24 * time.Hour
+	// MinJustificationLength is the shortest accepted break-glass justification
+	MinJustificationLength = 20
+)
+
+// Break-glass labels and annotations, on the grant's ServiceAccount
+const (
+	// LabelBreakGlass marks break-glass grants so the janitor can hold them to MaxBreakGlassDuration
+	LabelBreakGlass = "bridge.io/break-glass"
+	// AnnotationJustification records why a grant was taken
+	AnnotationJustification = "bridge.io/justification"
+)
+
+// Error codes for break-glass access
+const (
+	CodeRateLimited = "RATE_LIMITED"
+	CodeBreakGlass  = "BREAK_GLASS_GRANT"
+)
+
+// KindBreakGlassUsage marks the ConfigMap counting break-glass uses per person
+const KindBreakGlassUsage = "break-glass-usage"
+
+const (
+	// breakGlassUsageName is the ConfigMap in SystemNamespace counting break-glass uses
+	breakGlassUsageName = "bridge-break-glass-usage"
+	// breakGlassUsageKey is the ConfigMap data key holding the uses as YAML
+	breakGlassUsageKey = "usage.yaml"
+)
+
+// BreakGlassDeadline returns when a break-glass grant must be gone: its expiry, but never
+// later than MaxBreakGlassDuration after its ServiceAccount was created, so a removed or
+// edited expiry annotation doesn't keep it alive
+func BreakGlassDeadline(sa *corev1.ServiceAccount) time.Time {
+	deadline, err := time.Parse(time.RFC3339, sa.Annotations[AnnotationExpiresAt])
+	if sa.CreationTimestamp.IsZero() {
+		return deadline
+	}
+	if limit := sa.CreationTimestamp.Add(MaxBreakGlassDuration); err != nil || deadline.After(limit) {
+		return limit
+	}
+	return deadline
+}
+
+// ReserveBreakGlass counts a break-glass use by actor, failing with CodeRateLimited once
+// they have taken BreakGlassLimit in the last BreakGlassWindow. The uses are kept in a
+// ConfigMap in SystemNamespace, since revoked grants leave nothing behind to count.
+// release gives the use back, for when the grant could not be created.
+func (m *Manager) ReserveBreakGlass(ctx context.Context, actor string) (release func(), err error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = updateBreakGlassUsage(ctx, clientset, now, func(usage map[string][]time.Time) error {
+		if uses := usage[actor]; len(uses) >= BreakGlassLimit {
+			return newError(CodeRateLimited, "%s has taken break-glass access %d times in the last %s; it can be taken again at %s",
+				actor, len(uses), BreakGlassWindow, uses[len(uses)-BreakGlassLimit].Add(BreakGlassWindow).Format(time.RFC3339))
+		}
+		usage[actor] = append(usage[actor], now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	release = func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+		defer cancel()
+		err := updateBreakGlassUsage(ctx, clientset, time.Now(), func(usage map[string][]time.Time) error {
+			uses := usage[actor][:0]
+			for _, t := range usage[actor] {
+				if !t.Equal(now) {
+					uses = append(uses, t)
+				}
+			}
+			usage[actor] = uses
+			return nil
+		})
+		if err != nil {
+			log.Printf("[Access] Failed to release break-glass use of %s: %v", actor, err)
+		}
+	}
+	return release, nil
+}
+
+// updateBreakGlassUsage applies change to the recorded uses, with uses older than
+// BreakGlassWindow dropped, and retries when another request changed them first
+func updateBreakGlassUsage(ctx context.Context, clientset kubernetes.Interface, now time.Time, change func(usage map[string][]time.Time) error) error {
+	configMaps := clientset.CoreV1().ConfigMaps(SystemNamespace)
+	retriable := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}
+	return retry.OnError(retry.DefaultRetry, retriable, func() error {
+		cm, err := configMaps.Get(ctx, breakGlassUsageName, metav1.GetOptions{})
+		found := err == nil
+		if apierrors.IsNotFound(err) {
+			if err := EnsureSystemNamespace(ctx, clientset); err != nil {
+				return err
+			}
+			cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:      breakGlassUsageName,
+				Namespace: SystemNamespace,
+				Labels: map[string]string{
+					LabelManagedBy: ManagedByBridge,
+					LabelKind:      KindBreakGlassUsage,
+				},
+			}}
+		} else if err != nil {
+			return fmt.Errorf("failed to read break-glass usage: %w", err)
+		}
+
+		usage := map[string][]time.Time{}
+		if data := cm.Data[breakGlassUsageKey]; data != "" {
+			if err := yaml.Unmarshal([]byte(data), &usage); err != nil {
+				return fmt.Errorf("break-glass usage %s is malformed: %w", breakGlassUsageName, err)
+			}
+		}
+		for actor, uses := range usage {
+			var recent []time.Time
+			for _, t := range uses {
+				if now.Sub(t) < BreakGlassWindow {
+					recent = append(recent, t)
+				}
+			}
+			if len(recent) == 0 {
+				delete(usage, actor)
+				continue
+			}
+			usage[actor] = recent
+		}
+
+		if err := change(usage); err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(usage)
+		if err != nil {
+			return err
+		}
+		cm.Data = map[string]string{breakGlassUsageKey: string(data)}
+		if !found {
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		} else {
+			// The update carries the resourceVersion we read, so concurrent uses can't both pass the limit
+			_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		}
+		return err
+	})
+}
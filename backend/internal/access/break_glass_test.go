@@ -0,0 +1,148 @@
+package access
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReserveBreakGlass(t *testing.T) {
+	m, clientset := newTestManager()
+	ctx := context.Background()
+
+	var release func()
+	for i := 0; i < BreakGlassLimit; i++ {
+		var err error
+		if release, err = m.ReserveBreakGlass(ctx, "alice@example.com"); err != nil {
+			t.Fatalf("use %d: %v", i+1, err)
+		}
+	}
+	if _, err := m.ReserveBreakGlass(ctx, "alice@example.com"); ErrorCode(err) != CodeRateLimited {
+		t.Fatalf("use over the limit: error = %v, want %s", err, CodeRateLimited)
+	}
+	if _, err := m.ReserveBreakGlass(ctx, "bob@example.com"); err != nil {
+		t.Fatalf("the limit is per person, but bob was refused: %v", err)
+	}
+
+	// A use given back because the grant failed doesn't count
+	release()
+	if _, err := m.ReserveBreakGlass(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("use after release: %v", err)
+	}
+
+	// Uses older than the window are forgotten
+	cm, err := clientset.CoreV1().ConfigMaps(SystemNamespace).Get(ctx, breakGlassUsageName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-BreakGlassWindow - time.Minute).UTC().Format(time.RFC3339)
+	cm.Data[breakGlassUsageKey] = "alice@example.com: [" + strings.Repeat(old+", ", BreakGlassLimit-1) + old + "]\n"
+	if _, err := clientset.CoreV1().ConfigMaps(SystemNamespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ReserveBreakGlass(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("use after the window: %v", err)
+	}
+}
+
+func TestBreakGlassGrant(t *testing.T) {
+	breakGlass := func(req GrantRequest) GrantRequest {
+		req.Template = BreakGlassTemplate
+		req.BreakGlass = true
+		req.Justification = "database is down, need to restart the primary"
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		request  func() GrantRequest
+		wantCode string
+	}{
+		{name: "break-glass grant", request: func() GrantRequest { return breakGlass(testRequest()) }},
+		{name: "longer than the maximum", request: func() GrantRequest {
+			req := breakGlass(testRequest())
+			req.Duration = 2 * time.Hour
+			return req
+		}, wantCode: CodeInvalidRequest},
+		{name: "short justification", request: func() GrantRequest {
+			req := breakGlass(testRequest())
+			req.Justification = "urgent"
+			return req
+		}, wantCode: CodeInvalidRequest},
+		{name: "template outside break-glass", request: func() GrantRequest {
+			req := testRequest()
+			req.Template = BreakGlassTemplate
+			return req
+		}, wantCode: CodeInvalidTemplate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, clientset := newTestManager()
+			ctx := context.Background()
+
+			grant, err := m.Create(ctx, tt.request())
+			if tt.wantCode != "" {
+				if code := ErrorCode(err); code != tt.wantCode {
+					t.Fatalf("code = %q (%v), want %q", code, err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sa, err := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := FromServiceAccount(sa)
+			if !stored.BreakGlass || stored.Justification != grant.Justification {
+				t.Fatalf("stored grant = %+v, want break-glass with its justification", stored)
+			}
+
+			// Break-glass grants can't be extended, or reconciled into normal grants
+			if _, err := m.Extend(ctx, "team-a", "alice-dev", time.Hour, "test"); ErrorCode(err) != CodeBreakGlass {
+				t.Fatalf("Extend error = %v, want %s", err, CodeBreakGlass)
+			}
+			req := testRequest()
+			req.Reconcile = true
+			if _, err := m.Create(ctx, req); ErrorCode(err) != CodeBreakGlass {
+				t.Fatalf("reconcile error = %v, want %s", err, CodeBreakGlass)
+			}
+		})
+	}
+}
+
+func TestBreakGlassDeadline(t *testing.T) {
+	created := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		want      time.Time
+	}{
+		{name: "expiry within the maximum", expiresAt: "2026-01-01T12:30:00Z", want: created.Add(30 * time.Minute)},
+		{name: "expiry pushed back", expiresAt: "2026-01-02T12:00:00Z", want: created.Add(MaxBreakGlassDuration)},
+		{name: "expiry removed", want: created.Add(MaxBreakGlassDuration)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(created),
+				Annotations:       map[string]string{},
+			}}
+			if tt.expiresAt != "" {
+				sa.Annotations[AnnotationExpiresAt] = tt.expiresAt
+			}
+			if got := BreakGlassDeadline(sa); !got.Equal(tt.want) {
+				t.Fatalf("BreakGlassDeadline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AnnotationExtensions holds the JSON history of a grant's extensions
+const AnnotationExtensions = "bridge.io/extensions"
+
+// maxExtensionHistory caps how many extensions are kept in the annotation
+const maxExtensionHistory = 50
+
+// Error codes for Extend
+const (
+	CodePermanent = "PERMANENT_GRANT"
+	CodeExpired   = "GRANT_EXPIRED"
+	CodeExtend    = "EXTEND_FAILED"
+)
+
+// Extension records one extension of a grant
+type Extension struct {
+	At   time.Time `json:"at"`
+	By   string    `json:"by,omitempty"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// extensionsFromAnnotation decodes the extension history, ignoring a malformed annotation
+func extensionsFromAnnotation(value string) []Extension {
+	if value == "" {
+		return nil
+	}
+	var extensions []Extension
+	if err := json.Unmarshal([]byte(value), &extensions); err != nil {
+		return nil
+	}
+	return extensions
+}
+
+// annotationPatch builds a merge patch setting annotations; nil values remove them
+func annotationPatch(annotations map[string]*string) []byte {
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	return patch
+}
+
+// patcher applies a merge patch to one object of a grant
+type patcher struct {
+	desc     string
+	optional bool // roles of older grants may be missing
+	patch    func(ctx context.Context, data []byte) error
+}
+
+// grantPatchers returns a patcher for the ServiceAccount and every role and binding of a grant
+func grantPatchers(clientset kubernetes.Interface, grant *Grant) (sa patcher, roles []patcher) {
+	_, roleName, bindingName, _ := Names(grant.Name)
+	opts := metav1.PatchOptions{}
+
+	sa = patcher{desc: "ServiceAccount " + grant.ServiceAccount, patch: func(ctx context.Context, data []byte) error {
+		_, err := clientset.CoreV1().ServiceAccounts(grant.Namespace).Patch(ctx, grant.ServiceAccount, types.MergePatchType, data, opts)
+		return err
+	}}
+
+	for _, ns := range grant.Namespaces {
+		roles = append(roles,
+			patcher{desc: "Role " + ns + "/" + roleName, optional: true, patch: func(ctx context.Context, data []byte) error {
+				_, err := clientset.RbacV1().Roles(ns).Patch(ctx, roleName, types.MergePatchType, data, opts)
+				return err
+			}},
+			patcher{desc: "RoleBinding " + ns + "/" + bindingName, optional: true, patch: func(ctx context.Context, data []byte) error {
+				_, err := clientset.RbacV1().RoleBindings(ns).Patch(ctx, bindingName, types.MergePatchType, data, opts)
+				return err
+			}},
+		)
+	}
+	if grant.ClusterScope {
+		roles = append(roles,
+			patcher{desc: "ClusterRole " + grant.Role, optional: true, patch: func(ctx context.Context, data []byte) error {
+				_, err := clientset.RbacV1().ClusterRoles().Patch(ctx, grant.Role, types.MergePatchType, data, opts)
+				return err
+			}},
+			patcher{desc: "ClusterRoleBinding " + grant.RoleBinding, optional: true, patch: func(ctx context.Context, data []byte) error {
+				_, err := clientset.RbacV1().ClusterRoleBindings().Patch(ctx, grant.RoleBinding, types.MergePatchType, data, opts)
+				return err
+			}},
+		)
+	}
+	return sa, roles
+}
+
+// Extend pushes a grant's expiry back by d, updating the expiry annotation on all of its
+// objects and minting a fresh token that lasts until the new expiry. The extension is
+// appended to the grant's history. If any step fails, the annotations are restored.
+func (m *Manager) Extend(ctx context.Context, namespace, name string, d time.Duration, actor string) (*Grant, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sa, grant, err := extendable(ctx, clientset, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	previousExpiry := sa.Annotations[AnnotationExpiresAt]
+	previousHistory, hadHistory := sa.Annotations[AnnotationExtensions]
+
+	extension := Extension{
+		At:   time.Now().UTC(),
+		By:   actor,
+		From: grant.ExpiresAt,
+		To:   grant.ExpiresAt.Add(d).UTC(),
+	}
+	history := append(grant.Extensions, extension)
+	if len(history) > maxExtensionHistory {
+		history = history[len(history)-maxExtensionHistory:]
+	}
+	historyJSON, err := json.Marshal(history)
+	if err != nil {
+		return nil, err
+	}
+	newExpiry := extension.To.Format(time.RFC3339)
+	newHistory := string(historyJSON)
+
+	tx := &transaction{}
+	ok := false
+	defer func() {
+		if !ok {
+			tx.rollback()
+		}
+	}()
+
+	saPatcher, rolePatchers := grantPatchers(clientset, grant)
+
+	if err := saPatcher.patch(ctx, annotationPatch(map[string]*string{
+		AnnotationExpiresAt:  &newExpiry,
+		AnnotationExtensions: &newHistory,
+	})); err != nil {
+		return nil, newError(CodeExtend, "failed to update %s: %v", saPatcher.desc, err)
+	}
+	undoHistory := &previousHistory
+	if !hadHistory {
+		undoHistory = nil
+	}
+	tx.add(saPatcher.desc+" expiry", func(ctx context.Context) error {
+		return saPatcher.patch(ctx, annotationPatch(map[string]*string{
+			AnnotationExpiresAt:  &previousExpiry,
+			AnnotationExtensions: undoHistory,
+		}))
+	})
+
+	for _, p := range rolePatchers {
+		if err := p.patch(ctx, annotationPatch(map[string]*string{AnnotationExpiresAt: &newExpiry})); err != nil {
+			if p.optional && apierrors.IsNotFound(err) {
+				log.Printf("[Access] %s is missing, not extending it", p.desc)
+				continue
+			}
+			return nil, newError(CodeExtend, "failed to update %s: %v", p.desc, err)
+		}
+		tx.add(p.desc+" expiry", func(ctx context.Context) error {
+			return p.patch(ctx, annotationPatch(map[string]*string{AnnotationExpiresAt: &previousExpiry}))
+		})
+	}
+
+	grant.ExpiresAt = extension.To
+	grant.Extensions = history
+	if grant.Subject == nil {
+		if err := m.ephemeralToken(ctx, clientset, grant, int64(time.Until(extension.To).Seconds())); err != nil {
+			return nil, err
+		}
+	}
+
+	ok = true
+	return grant, nil
+}
+
+// Extendable returns the grant if it can be extended: it is managed by Bridge, not
+// break-glass, not permanent and not yet expired
+func (m *Manager) Extendable(ctx context.Context, namespace, name string) (*Grant, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, grant, err := extendable(ctx, clientset, namespace, name)
+	return grant, err
+}
+
+// extendable loads a grant's ServiceAccount and checks that the grant can be extended
+func extendable(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*corev1.ServiceAccount, *Grant, error) {
+	saName, _, _, _ := Names(name)
+	sa, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, newError(CodeNotFound, "Bridge access user '%s' not found in namespace '%s'", name, namespace)
+		}
+		return nil, nil, err
+	}
+	if sa.Labels[LabelManagedBy] != ManagedByBridge {
+		return nil, nil, newError(CodeNotBridgeManaged, "ServiceAccount %s/%s is not managed by Bridge", namespace, saName)
+	}
+
+	grant := FromServiceAccount(sa)
+	if grant.BreakGlass {
+		return nil, nil, newError(CodeBreakGlass, "'%s' is break-glass access and cannot be extended; take break-glass access again if still needed", name)
+	}
+	if grant.ExpiresAt.IsZero() {
+		return nil, nil, newError(CodePermanent, "'%s' is a permanent grant and cannot be extended", name)
+	}
+	if time.Now().After(grant.ExpiresAt) {
+		return nil, nil, newError(CodeExpired, "'%s' expired at %s; create a new grant instead", name, grant.ExpiresAt.Format(time.RFC3339))
+	}
+	return sa, grant, nil
+}
@@ -0,0 +1,100 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestExtend(t *testing.T) {
+	tests := []struct {
+		name      string
+		duration  time.Duration // of the original grant; 0 for permanent
+		expire    bool          // move the grant's expiry into the past first
+		failRoles bool
+		wantCode  string
+	}{
+		{name: "extends grant", duration: time.Hour},
+		{name: "rejects permanent grant", wantCode: CodePermanent},
+		{name: "rejects expired grant", duration: time.Hour, expire: true, wantCode: CodeExpired},
+		{name: "rolls back on role failure", duration: time.Hour, failRoles: true, wantCode: CodeExtend},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A permanent grant's token Secret is never filled by the fake clientset, so seed its ServiceAccount directly
+			var existing []runtime.Object
+			if tt.duration == 0 {
+				existing = append(existing, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+					Name: "alice-dev-sa", Namespace: "team-a",
+					Labels: map[string]string{LabelManagedBy: ManagedByBridge},
+				}})
+			}
+			m, clientset := newTestManager(existing...)
+			ctx := context.Background()
+
+			created := &Grant{Name: "alice-dev"}
+			if tt.duration > 0 {
+				req := testRequest()
+				req.Namespaces = []string{"team-a", "team-b"}
+				req.Duration = tt.duration
+				var err error
+				if created, err = m.Create(ctx, req); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+			if tt.expire {
+				past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+				sa, _ := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+				sa.Annotations[AnnotationExpiresAt] = past
+				clientset.CoreV1().ServiceAccounts("team-a").Update(ctx, sa, metav1.UpdateOptions{})
+			}
+			if tt.failRoles {
+				clientset.PrependReactor("patch", "roles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("boom")
+				})
+			}
+
+			grant, err := m.Extend(ctx, "team-a", created.Name, 2*time.Hour, "bob@example.com")
+			if got := ErrorCode(err); got != tt.wantCode {
+				t.Fatalf("Extend() error = %v, want code %q", err, tt.wantCode)
+			}
+
+			sa, err := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := FromServiceAccount(sa)
+			if tt.wantCode != "" {
+				if len(stored.Extensions) != 0 {
+					t.Errorf("extension history = %v after failed extend, want none", stored.Extensions)
+				}
+				if tt.failRoles && !stored.ExpiresAt.Equal(created.ExpiresAt.Truncate(time.Second)) {
+					t.Errorf("expiry = %v after rollback, want %v", stored.ExpiresAt, created.ExpiresAt)
+				}
+				return
+			}
+
+			want := created.ExpiresAt.Add(2 * time.Hour).Truncate(time.Second)
+			if !stored.ExpiresAt.Equal(want) || !grant.ExpiresAt.Truncate(time.Second).Equal(want) {
+				t.Errorf("expiry = %v (grant %v), want %v", stored.ExpiresAt, grant.ExpiresAt, want)
+			}
+			if len(stored.Extensions) != 1 || stored.Extensions[0].By != "bob@example.com" {
+				t.Errorf("extension history = %+v, want one extension by bob@example.com", stored.Extensions)
+			}
+			role, err := clientset.RbacV1().Roles("team-b").Get(ctx, "alice-dev-role", metav1.GetOptions{})
+			if err != nil || role.Annotations[AnnotationExpiresAt] != sa.Annotations[AnnotationExpiresAt] {
+				t.Errorf("Role in team-b expiry = %v, want %s", role.Annotations, sa.Annotations[AnnotationExpiresAt])
+			}
+			if grant.Token != "test-token" {
+				t.Errorf("grant token = %q, want test-token", grant.Token)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package access
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Grant modes
+const (
+	// ModeServiceAccount grants bind the grant's ServiceAccount and hand out its tokens
+	ModeServiceAccount = "serviceaccount"
+	// ModeIdentity grants bind an existing User or Group; kubeconfigs log in as that person
+	ModeIdentity = "identity"
+)
+
+// Subject kinds an identity grant can bind
+const (
+	SubjectUser  = "User"
+	SubjectGroup = "Group"
+)
+
+// Login types of identity grants
+const (
+	LoginOIDC = "oidc" // kubelogin (kubectl oidc-login)
+	LoginEKS  = "eks"  // aws eks get-token
+	LoginExec = "exec" // a client-go credential plugin the server allows
+)
+
+// Identity grant annotations, on the grant's ServiceAccount
+const (
+	// AnnotationSubject holds the bound subject as "<kind>:<name>"
+	AnnotationSubject = "bridge.io/subject"
+	// AnnotationLogin holds the JSON Login of the grant's kubeconfigs
+	AnnotationLogin = "bridge.io/login"
+)
+
+// CodeNoToken is reported for token operations on identity grants
+const CodeNoToken = "NO_TOKEN"
+
+// execAPIVersion is the credential plugin API the generated exec stanzas use
+const execAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+// reviewUser is the user impersonated to review a Group grant; impersonating groups requires a user
+const reviewUser = "bridge:permissions-review"
+
+// Subject is the existing user or group an identity grant binds, as the API server
+// authenticates it: an OIDC email, or the username or a group of an EKS access entry
+type Subject struct {
+	Kind string `json:"kind"` // "User" or "Group"
+	Name string `json:"name"`
+}
+
+func (s *Subject) String() string {
+	return s.Kind + ":" + s.Name
+}
+
+// rbacSubject returns the subject of the grant's bindings
+func (s *Subject) rbacSubject() rbacv1.Subject {
+	return rbacv1.Subject{
+		Kind:     s.Kind,
+		APIGroup: rbacv1.GroupName,
+		Name:     s.Name,
+	}
+}
+
+// impersonation acts as the subject. The subject's other groups are unknown, so a
+// review only sees what is bound to the subject itself.
+func (s *Subject) impersonation() rest.ImpersonationConfig {
+	if s.Kind == SubjectGroup {
+		return rest.ImpersonationConfig{UserName: reviewUser, Groups: []string{s.Name}}
+	}
+	return rest.ImpersonationConfig{UserName: s.Name}
+}
+
+// subjectFromAnnotation decodes AnnotationSubject, returning nil for ServiceAccount grants
+func subjectFromAnnotation(value string) *Subject {
+	kind, name, found := strings.Cut(value, ":")
+	if !found || name == "" {
+		return nil
+	}
+	return &Subject{Kind: kind, Name: name}
+}
+
+// Login is how the holder of an identity grant's kubeconfig authenticates
+type Login struct {
+	Type string `json:"type"` // "oidc", "eks" or "exec"
+
+	// oidc
+	IssuerURL   string   `json:"issuerURL,omitempty"`
+	ClientID    string   `json:"clientID,omitempty"`
+	ExtraScopes []string `json:"extraScopes,omitempty"` // e.g. ["email", "groups"]
+
+	// eks
+	ClusterName string `json:"clusterName,omitempty"`
+	Region      string `json:"region,omitempty"`
+	RoleARN     string `json:"roleARN,omitempty"` // IAM role to assume before getting the token
+
+	// exec
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// loginFromAnnotation decodes AnnotationLogin, ignoring a malformed annotation
+func loginFromAnnotation(value string) *Login {
+	if value == "" {
+		return nil
+	}
+	var login Login
+	if err := json.Unmarshal([]byte(value), &login); err != nil {
+		return nil
+	}
+	return &login
+}
+
+// SetExecLoginCommands sets the credential plugin commands exec logins may run. The command
+// ends up in kubeconfigs that other people run, so without any exec logins are refused.
+func (m *Manager) SetExecLoginCommands(commands []string) {
+	m.execCommands = make(map[string]bool, len(commands))
+	for _, command := range commands {
+		m.execCommands[command] = true
+	}
+}
+
+// checkExecCommand refuses exec logins whose command is not an allowed credential plugin
+func (m *Manager) checkExecCommand(login *Login) error {
+	if login.Type != LoginExec || m.execCommands[login.Command] {
+		return nil
+	}
+	if len(m.execCommands) == 0 {
+		return newError(CodeInvalidRequest, "exec logins are disabled on this server; use %s or %s", LoginOIDC, LoginEKS)
+	}
+	allowed := make([]string, 0, len(m.execCommands))
+	for command := range m.execCommands {
+		allowed = append(allowed, command)
+	}
+	sort.Strings(allowed)
+	return newError(CodeInvalidRequest, "exec login command %q is not allowed; allowed: %s", login.Command, strings.Join(allowed, ", "))
+}
+
+// validateIdentity checks the subject and login of an identity grant
+func (m *Manager) validateIdentity(subject *Subject, login *Login) error {
+	if subject.Kind != SubjectUser && subject.Kind != SubjectGroup {
+		return newError(CodeInvalidRequest, "subject kind must be %s or %s, not %q", SubjectUser, SubjectGroup, subject.Kind)
+	}
+	if subject.Name == "" {
+		return newError(CodeInvalidRequest, "subject name is required")
+	}
+	if login == nil {
+		return newError(CodeInvalidRequest, "login is required for %s grants", subject.Kind)
+	}
+	switch login.Type {
+	case LoginOIDC:
+		if login.IssuerURL == "" || login.ClientID == "" {
+			return newError(CodeInvalidRequest, "oidc login requires issuerURL and clientID")
+		}
+	case LoginEKS:
+		if login.ClusterName == "" {
+			return newError(CodeInvalidRequest, "eks login requires clusterName")
+		}
+	case LoginExec:
+		if login.Command == "" {
+			return newError(CodeInvalidRequest, "exec login requires command")
+		}
+		if err := m.checkExecCommand(login); err != nil {
+			return err
+		}
+	default:
+		return newError(CodeInvalidRequest, "login type must be %s, %s or %s, not %q", LoginOIDC, LoginEKS, LoginExec, login.Type)
+	}
+	return nil
+}
+
+// execConfig returns the credential plugin stanza of a kubeconfig user
+func (l *Login) execConfig() *clientcmdapi.ExecConfig {
+	exec := &clientcmdapi.ExecConfig{
+		APIVersion:      execAPIVersion,
+		InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+	}
+	switch l.Type {
+	case LoginOIDC:
+		exec.Command = "kubectl"
+		exec.Args = []string{"oidc-login", "get-token", "--oidc-issuer-url=" + l.IssuerURL, "--oidc-client-id=" + l.ClientID}
+		for _, scope := range l.ExtraScopes {
+			exec.Args = append(exec.Args, "--oidc-extra-scope="+scope)
+		}
+		exec.InstallHint = "This kubeconfig needs kubelogin: https://github.com/int128/kubelogin"
+	case LoginEKS:
+		exec.Command = "aws"
+		exec.Args = []string{"eks", "get-token", "--output", "json", "--cluster-name", l.ClusterName}
+		if l.Region != "" {
+			exec.Args = append(exec.Args, "--region", l.Region)
+		}
+		if l.RoleARN != "" {
+			exec.Args = append(exec.Args, "--role-arn", l.RoleARN)
+		}
+		exec.InstallHint = "This kubeconfig needs the AWS CLI: https://aws.amazon.com/cli/"
+	default:
+		exec.Command = l.Command
+		exec.Args = append([]string(nil), l.Args...)
+	}
+	return exec
+}
+
+// Mode returns ModeIdentity or ModeServiceAccount
+func (g *Grant) Mode() string {
+	if g.Subject != nil {
+		return ModeIdentity
+	}
+	return ModeServiceAccount
+}
@@ -0,0 +1,132 @@
+package access
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestCreateIdentityGrant(t *testing.T) {
+	oidc := &Login{Type: LoginOIDC, IssuerURL: "https://idp.example.com", ClientID: "bridge", ExtraScopes: []string{"email"}}
+	eks := &Login{Type: LoginEKS, ClusterName: "prod", Region: "eu-west-1", RoleARN: "arn:aws:iam::123456789012:role/dev"}
+	exec := &Login{Type: LoginExec, Command: "tsh", Args: []string{"kube", "credentials"}}
+
+	tests := []struct {
+		name      string
+		subject   *Subject
+		login     *Login
+		permanent bool
+		allowExec []string // exec login commands the server allows
+		wantCode  string
+		wantExec  string // command, then args, space-separated
+	}{
+		{
+			name:     "oidc user",
+			subject:  &Subject{Kind: SubjectUser, Name: "alice@example.com"},
+			login:    oidc,
+			wantExec: "kubectl oidc-login get-token --oidc-issuer-url=https://idp.example.com --oidc-client-id=bridge --oidc-extra-scope=email",
+		},
+		{
+			name:      "permanent eks group",
+			subject:   &Subject{Kind: SubjectGroup, Name: "dev-team"},
+			login:     eks,
+			permanent: true,
+			wantExec:  "aws eks get-token --output json --cluster-name prod --region eu-west-1 --role-arn arn:aws:iam::123456789012:role/dev",
+		},
+		{
+			name:      "allowed exec plugin",
+			subject:   &Subject{Kind: SubjectUser, Name: "alice@example.com"},
+			login:     exec,
+			allowExec: []string{"kubelogin", "tsh"},
+			wantExec:  "tsh kube credentials",
+		},
+		{name: "exec without allowed plugins", subject: &Subject{Kind: SubjectUser, Name: "alice@example.com"}, login: exec, wantCode: CodeInvalidRequest},
+		{name: "exec plugin not allowed", subject: &Subject{Kind: SubjectUser, Name: "alice@example.com"}, login: &Login{Type: LoginExec, Command: "sh", Args: []string{"-c", "id"}}, allowExec: []string{"tsh"}, wantCode: CodeInvalidRequest},
+		{name: "unknown kind", subject: &Subject{Kind: "ServiceAccount", Name: "x"}, login: oidc, wantCode: CodeInvalidRequest},
+		{name: "missing login", subject: &Subject{Kind: SubjectUser, Name: "alice@example.com"}, wantCode: CodeInvalidRequest},
+		{name: "incomplete login", subject: &Subject{Kind: SubjectUser, Name: "alice@example.com"}, login: &Login{Type: LoginEKS}, wantCode: CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, clientset := newTestManager()
+			m.SetExecLoginCommands(tt.allowExec)
+			m.clusterFor = func(ctx context.Context) (*Cluster, error) {
+				return &Cluster{Name: "prod", Server: "https://api.prod:6443", CAData: []byte("ca")}, nil
+			}
+			ctx := context.Background()
+			req := testRequest()
+			req.Subject = tt.subject
+			req.Login = tt.login
+			if tt.permanent {
+				req.Duration = 0
+			}
+
+			grant, err := m.Create(ctx, req)
+			if tt.wantCode != "" {
+				if code := ErrorCode(err); code != tt.wantCode {
+					t.Fatalf("code = %q (%v), want %q", code, err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if grant.Token != "" || grant.Mode() != ModeIdentity {
+				t.Fatalf("grant has token %q and mode %q, want no token and identity mode", grant.Token, grant.Mode())
+			}
+
+			binding, err := clientset.RbacV1().RoleBindings("team-a").Get(ctx, "alice-dev-binding", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(binding.Subjects) != 1 || binding.Subjects[0].Kind != tt.subject.Kind || binding.Subjects[0].Name != tt.subject.Name {
+				t.Fatalf("binding subjects = %+v, want only %s", binding.Subjects, tt.subject)
+			}
+			if secrets, _ := clientset.CoreV1().Secrets("team-a").List(ctx, metav1.ListOptions{}); len(secrets.Items) != 0 {
+				t.Fatalf("identity grant created %d token Secrets", len(secrets.Items))
+			}
+
+			// The grant reads back from its ServiceAccount like any other
+			sa, err := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := FromServiceAccount(sa)
+			if stored.Subject == nil || *stored.Subject != *tt.subject || stored.Login == nil || stored.Login.Type != tt.login.Type {
+				t.Fatalf("stored subject %v and login %+v, want %s and %s", stored.Subject, stored.Login, tt.subject, tt.login.Type)
+			}
+
+			out, err := m.Kubeconfig(ctx, stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config, err := clientcmd.Load([]byte(out))
+			if err != nil {
+				t.Fatalf("generated kubeconfig does not load: %v", err)
+			}
+			user := config.AuthInfos["alice-dev@prod"]
+			if user == nil || user.Token != "" || user.Exec == nil {
+				t.Fatalf("user = %+v, want an exec plugin and no token", user)
+			}
+			if got := strings.Join(append([]string{user.Exec.Command}, user.Exec.Args...), " "); got != tt.wantExec {
+				t.Fatalf("exec = %q, want %q", got, tt.wantExec)
+			}
+
+			// Kubeconfigs of stored grants are refused once their plugin is no longer allowed
+			if tt.login.Type == LoginExec {
+				m.SetExecLoginCommands(nil)
+				if _, err := m.Kubeconfig(ctx, stored); ErrorCode(err) != CodeInvalidRequest {
+					t.Fatalf("kubeconfig with a disallowed plugin: error = %v, want %s", err, CodeInvalidRequest)
+				}
+			}
+
+			if _, err := m.Rotate(ctx, "team-a", "alice-dev", 0, "test"); ErrorCode(err) != CodeNoToken {
+				t.Fatalf("Rotate error = %v, want %s", err, CodeNoToken)
+			}
+		})
+	}
+}
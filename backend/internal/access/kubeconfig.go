@@ -0,0 +1,154 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// rootCAConfigMap is published in every namespace by kube-controller-manager with the cluster CA
+const rootCAConfigMap = "kube-root-ca.crt"
+
+// Cluster is how a generated kubeconfig reaches the API server
+type Cluster struct {
+	Name                  string // the cluster's name in Bridge's kubeconfig
+	Server                string
+	CAData                []byte
+	TLSServerName         string
+	ProxyURL              string
+	InsecureSkipTLSVerify bool
+}
+
+// ClusterFromConfig describes the cluster behind a client config. name and raw are the
+// cluster's kubeconfig entry, if it has one; the CA is taken inline or from its file.
+func ClusterFromConfig(config *rest.Config, name string, raw *clientcmdapi.Cluster) (*Cluster, error) {
+	cluster := &Cluster{
+		Name:                  name,
+		Server:                config.Host,
+		CAData:                config.CAData,
+		TLSServerName:         config.ServerName,
+		InsecureSkipTLSVerify: config.Insecure,
+	}
+	if len(cluster.CAData) == 0 && config.CAFile != "" {
+		data, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate-authority %s: %w", config.CAFile, err)
+		}
+		cluster.CAData = data
+	}
+	if raw != nil {
+		cluster.ProxyURL = raw.ProxyURL
+		if cluster.TLSServerName == "" {
+			cluster.TLSServerName = raw.TLSServerName
+		}
+	}
+	if cluster.Name == "" {
+		// In-cluster config has no kubeconfig entry; the API server host is the best name there is
+		cluster.Name = "kubernetes"
+		if u, err := url.Parse(cluster.Server); err == nil && u.Hostname() != "" {
+			cluster.Name = u.Hostname()
+		}
+	}
+	return cluster, nil
+}
+
+// kubeconfigNamespaces returns the namespaces to create kubeconfig contexts for
+func kubeconfigNamespaces(grant *Grant) []string {
+	if grant.ClusterScope || len(grant.Namespaces) == 0 {
+		return []string{grant.Namespace}
+	}
+	return grant.Namespaces
+}
+
+// Kubeconfig renders a kubeconfig for a grant's token, or for identity grants an exec
+// credential plugin that logs in as the bound user, with one context per namespace; the
+// first is the current context. The cluster keeps the name, TLS and proxy settings it has
+// in Bridge's kubeconfig, and the user and contexts are named "<grant ID>@<cluster>", so
+// the result can be merged into an existing kubeconfig without replacing anything in it.
+// The CA comes from Bridge's kubeconfig, else the token Secret, else the kube-root-ca.crt
+// ConfigMap in the grant's namespace.
+func (m *Manager) Kubeconfig(ctx context.Context, grant *Grant) (string, error) {
+	authInfo := &clientcmdapi.AuthInfo{Token: grant.Token}
+	if grant.Subject != nil {
+		if grant.Login == nil {
+			return "", fmt.Errorf("%s/%s is bound to %s but has no login configured", grant.Namespace, grant.Name, grant.Subject)
+		}
+		if err := m.checkExecCommand(grant.Login); err != nil {
+			return "", err
+		}
+		authInfo = &clientcmdapi.AuthInfo{Exec: grant.Login.execConfig()}
+	}
+
+	cluster, err := m.clusterFor(ctx)
+	if err != nil {
+		return "", fmt.Errorf("config not ready: %w", err)
+	}
+
+	caData := cluster.CAData
+	if len(caData) == 0 {
+		caData = []byte(grant.CACert)
+	}
+	if len(caData) == 0 && !cluster.InsecureSkipTLSVerify {
+		caData, err = m.rootCA(ctx, grant.Namespace)
+		if err != nil {
+			log.Printf("[Access] No CA for the kubeconfig of %s/%s, relying on system roots: %v", grant.Namespace, grant.Name, err)
+		}
+	}
+
+	userName := grant.Name + "@" + cluster.Name
+	config := clientcmdapi.NewConfig()
+	config.Clusters[cluster.Name] = &clientcmdapi.Cluster{
+		Server:                   cluster.Server,
+		CertificateAuthorityData: caData,
+		TLSServerName:            cluster.TLSServerName,
+		ProxyURL:                 cluster.ProxyURL,
+		InsecureSkipTLSVerify:    cluster.InsecureSkipTLSVerify && len(caData) == 0,
+	}
+	config.AuthInfos[userName] = authInfo
+
+	// The first context is named after the user; the others are suffixed with their namespace
+	for i, namespace := range kubeconfigNamespaces(grant) {
+		contextName := userName
+		if i > 0 {
+			contextName = userName + "/" + namespace
+		} else {
+			config.CurrentContext = contextName
+		}
+		config.Contexts[contextName] = &clientcmdapi.Context{
+			Cluster:   cluster.Name,
+			AuthInfo:  userName,
+			Namespace: namespace,
+		}
+	}
+
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// rootCA reads the cluster CA from the kube-root-ca.crt ConfigMap of a namespace
+func (m *Manager) rootCA(ctx context.Context, namespace string) ([]byte, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, rootCAConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	ca := cm.Data[corev1.ServiceAccountRootCAKey]
+	if ca == "" {
+		return nil, fmt.Errorf("ConfigMap %s/%s has no %s", namespace, rootCAConfigMap, corev1.ServiceAccountRootCAKey)
+	}
+	return []byte(ca), nil
+}
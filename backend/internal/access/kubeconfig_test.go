@@ -0,0 +1,121 @@
+package access
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestClusterFromConfig(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, []byte("file-ca"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  *rest.Config
+		cluster string
+		raw     *clientcmdapi.Cluster
+		want    Cluster
+		wantErr bool
+	}{
+		{
+			name:    "inline CA",
+			config:  &rest.Config{Host: "https://10.0.0.1:6443", TLSClientConfig: rest.TLSClientConfig{CAData: []byte("inline-ca")}},
+			cluster: "kind-dev",
+			want:    Cluster{Name: "kind-dev", Server: "https://10.0.0.1:6443", CAData: []byte("inline-ca")},
+		},
+		{
+			name:    "CA file, TLS server name and proxy",
+			config:  &rest.Config{Host: "https://lb.example.com", TLSClientConfig: rest.TLSClientConfig{CAFile: caFile, ServerName: "api.internal"}},
+			cluster: "prod",
+			raw:     &clientcmdapi.Cluster{ProxyURL: "socks5://bastion:1080"},
+			want:    Cluster{Name: "prod", Server: "https://lb.example.com", CAData: []byte("file-ca"), TLSServerName: "api.internal", ProxyURL: "socks5://bastion:1080"},
+		},
+		{
+			name:    "missing CA file",
+			config:  &rest.Config{Host: "https://10.0.0.1", TLSClientConfig: rest.TLSClientConfig{CAFile: filepath.Join(t.TempDir(), "missing")}},
+			wantErr: true,
+		},
+		{
+			name:   "in-cluster config is named after the host",
+			config: &rest.Config{Host: "https://10.96.0.1:443"},
+			want:   Cluster{Name: "10.96.0.1", Server: "https://10.96.0.1:443"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClusterFromConfig(tt.config, tt.cluster, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClusterFromConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Name != tt.want.Name || got.Server != tt.want.Server || string(got.CAData) != string(tt.want.CAData) ||
+				got.TLSServerName != tt.want.TLSServerName || got.ProxyURL != tt.want.ProxyURL {
+				t.Fatalf("ClusterFromConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubeconfig(t *testing.T) {
+	rootCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: rootCAConfigMap, Namespace: "team-a"},
+		Data:       map[string]string{corev1.ServiceAccountRootCAKey: "root-ca"},
+	}
+	grant := &Grant{Name: "alice-dev-3f9a2c", Namespace: "team-a", Namespaces: []string{"team-a", "team-b"}, Token: "t"}
+
+	tests := []struct {
+		name      string
+		clusterCA string
+		secretCA  string
+		wantCA    string
+	}{
+		{name: "kubeconfig CA", clusterCA: "cluster-ca", secretCA: "secret-ca", wantCA: "cluster-ca"},
+		{name: "token Secret CA", secretCA: "secret-ca", wantCA: "secret-ca"},
+		{name: "kube-root-ca.crt", wantCA: "root-ca"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(rootCA)
+			m.clusterFor = func(ctx context.Context) (*Cluster, error) {
+				return &Cluster{Name: "prod", Server: "https://api.prod:6443", CAData: []byte(tt.clusterCA), TLSServerName: "api.internal", ProxyURL: "http://proxy:3128"}, nil
+			}
+			g := *grant
+			g.CACert = tt.secretCA
+
+			out, err := m.Kubeconfig(context.Background(), &g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config, err := clientcmd.Load([]byte(out))
+			if err != nil {
+				t.Fatalf("generated kubeconfig does not load: %v", err)
+			}
+
+			cluster := config.Clusters["prod"]
+			if cluster == nil || string(cluster.CertificateAuthorityData) != tt.wantCA || cluster.TLSServerName != "api.internal" || cluster.ProxyURL != "http://proxy:3128" {
+				t.Fatalf("cluster = %+v, want CA %q with TLS server name and proxy", cluster, tt.wantCA)
+			}
+			user := "alice-dev-3f9a2c@prod"
+			if config.AuthInfos[user] == nil || config.AuthInfos[user].Token != "t" {
+				t.Fatalf("users = %v, want %s", config.AuthInfos, user)
+			}
+			if config.CurrentContext != user || config.Contexts[user+"/team-b"] == nil || config.Contexts[user+"/team-b"].Namespace != "team-b" {
+				t.Fatalf("contexts = %v, current %q", config.Contexts, config.CurrentContext)
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ManifestAPIVersion and ManifestKind identify a bundle of exported grants
+const (
+	ManifestAPIVersion = "bridge.io/v1"
+	ManifestKind       = "AccessGrantList"
+)
+
+// Error codes for exporting and importing grants
+const (
+	CodeInvalidManifest = "INVALID_MANIFEST"
+	CodeExport          = "EXPORT_FAILED"
+)
+
+// Import actions
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+	ImportSkip      = "skip"
+	ImportError     = "error"
+)
+
+// Manifest is a bundle of grants as kept in git
+type Manifest struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Grants     []GrantManifest `json:"grants"`
+}
+
+// GrantManifest declares one grant. Temporary grants keep their absolute expiry, so
+// importing the same bundle twice changes nothing.
+type GrantManifest struct {
+	ID            string              `json:"id"`
+	Namespace     string              `json:"namespace"` // where the ServiceAccount lives
+	UserLabel     string              `json:"userLabel"`
+	Namespaces    []string            `json:"namespaces,omitempty"`
+	ClusterScope  bool                `json:"clusterScope,omitempty"`
+	Template      string              `json:"template,omitempty"` // informational; the rules are complete
+	Rules         []rbacv1.PolicyRule `json:"rules"`
+	ExpiresAt     *time.Time          `json:"expiresAt,omitempty"` // empty for permanent access
+	Subject       *Subject            `json:"subject,omitempty"`
+	Login         *Login              `json:"login,omitempty"`
+	Justification string              `json:"justification,omitempty"`
+}
+
+// ExportFilter selects the grants to export. Zero values match everything.
+type ExportFilter struct {
+	Namespace string // the namespace of the grants' ServiceAccounts
+	User      string // user label
+	Template  string
+}
+
+// Change is one field of a grant that an import changes
+type Change struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// ImportResult is what an import did, or would do, to one grant
+type ImportResult struct {
+	ID        string   `json:"id"`
+	Namespace string   `json:"namespace"`
+	Action    string   `json:"action"` // create, update, unchanged, skip or error
+	Changes   []Change `json:"changes,omitempty"`
+	Message   string   `json:"message,omitempty"`
+	RequestID string   `json:"requestId,omitempty"` // the access request made instead, when approval is required
+
+	// Grant is the created or updated grant; nil for dry runs
+	Grant *Grant `json:"-"`
+}
+
+// Export renders the grants matching filter as a manifest, sorted by namespace and ID.
+// Break-glass grants are left out: they are taken in an emergency, not declared.
+func (m *Manager) Export(ctx context.Context, filter ExportFilter) (*Manifest, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := LabelManagedBy + "=" + ManagedByBridge
+	if filter.User != "" {
+		selector = UserSelector(filter.User)
+	}
+	if filter.Template != "" {
+		selector += "," + LabelTemplate + "=" + filter.Template
+	}
+	saList, err := clientset.CoreV1().ServiceAccounts(filter.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, newError(CodeExport, "error listing ServiceAccounts: %w", err)
+	}
+
+	manifest := &Manifest{APIVersion: ManifestAPIVersion, Kind: ManifestKind, Grants: []GrantManifest{}}
+	for i := range saList.Items {
+		grant := FromServiceAccount(&saList.Items[i])
+		if grant.BreakGlass {
+			continue
+		}
+		gm, err := grantManifest(ctx, clientset, grant)
+		if err != nil {
+			return nil, newError(CodeExport, "%w", err)
+		}
+		manifest.Grants = append(manifest.Grants, *gm)
+	}
+	sort.Slice(manifest.Grants, func(i, j int) bool {
+		a, b := manifest.Grants[i], manifest.Grants[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.ID < b.ID
+	})
+	return manifest, nil
+}
+
+// grantManifest describes a live grant, with the rules of its roles
+func grantManifest(ctx context.Context, clientset kubernetes.Interface, grant *Grant) (*GrantManifest, error) {
+	rules, err := grantRules(ctx, clientset, grant)
+	if err != nil {
+		return nil, fmt.Errorf("grant %s/%s: %w", grant.Namespace, grant.Name, err)
+	}
+	gm := &GrantManifest{
+		ID:            grant.Name,
+		Namespace:     grant.Namespace,
+		UserLabel:     grant.Username,
+		ClusterScope:  grant.ClusterScope,
+		Template:      grant.Template,
+		Rules:         rules,
+		Subject:       grant.Subject,
+		Login:         grant.Login,
+		Justification: grant.Justification,
+	}
+	if !grant.ClusterScope {
+		gm.Namespaces = grant.Namespaces
+	}
+	if !grant.ExpiresAt.IsZero() {
+		expiresAt := grant.ExpiresAt.UTC()
+		gm.ExpiresAt = &expiresAt
+	}
+	return gm, nil
+}
+
+// Import makes the grants of a manifest exist as declared: missing grants are created,
+// differing ones reconciled, and matching ones left alone, so importing is idempotent.
+// Grants that aren't in the manifest are not touched. With dryRun nothing is changed and
+// the results tell what would be. One grant failing doesn't stop the others.
+func (m *Manager) Import(ctx context.Context, manifest *Manifest, dryRun bool) ([]ImportResult, error) {
+	if manifest.APIVersion != ManifestAPIVersion || manifest.Kind != ManifestKind {
+		return nil, newError(CodeInvalidManifest, "expected apiVersion %s and kind %s, got %q and %q", ManifestAPIVersion, ManifestKind, manifest.APIVersion, manifest.Kind)
+	}
+	seen := make(map[string]bool)
+	for _, gm := range manifest.Grants {
+		key := gm.Namespace + "/" + gm.ID
+		if gm.ID == "" || gm.Namespace == "" || gm.UserLabel == "" {
+			return nil, newError(CodeInvalidManifest, "every grant needs an id, namespace and userLabel")
+		}
+		if seen[key] {
+			return nil, newError(CodeInvalidManifest, "grant %s is declared twice", key)
+		}
+		seen[key] = true
+	}
+
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImportResult, 0, len(manifest.Grants))
+	for _, gm := range manifest.Grants {
+		result := m.importGrant(ctx, clientset, gm, dryRun)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// importGrant creates or reconciles one declared grant
+func (m *Manager) importGrant(ctx context.Context, clientset kubernetes.Interface, gm GrantManifest, dryRun bool) ImportResult {
+	result := ImportResult{ID: gm.ID, Namespace: gm.Namespace}
+	fail := func(err error) ImportResult {
+		result.Action = ImportError
+		result.Message = err.Error()
+		return result
+	}
+
+	if gm.ExpiresAt != nil && !gm.ExpiresAt.After(time.Now()) {
+		result.Action = ImportSkip
+		result.Message = fmt.Sprintf("expired at %s", gm.ExpiresAt.UTC().Format(time.RFC3339))
+		return result
+	}
+	if gm.Subject != nil {
+		if err := m.validateIdentity(gm.Subject, gm.Login); err != nil {
+			return fail(err)
+		}
+	}
+	if err := ValidateRules(clientset.Discovery(), gm.Rules, gm.ClusterScope); err != nil {
+		return fail(err)
+	}
+
+	saName, _, _, _ := Names(gm.ID)
+	sa, err := clientset.CoreV1().ServiceAccounts(gm.Namespace).Get(ctx, saName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		result.Action = ImportCreate
+	case err != nil:
+		return fail(err)
+	case sa.Labels[LabelManagedBy] != ManagedByBridge:
+		return fail(newError(CodeNotBridgeManaged, "ServiceAccount %s/%s already exists and is not managed by Bridge", gm.Namespace, saName))
+	default:
+		live, err := grantManifest(ctx, clientset, FromServiceAccount(sa))
+		if err != nil {
+			return fail(err)
+		}
+		if result.Changes = diffManifests(live, &gm); len(result.Changes) == 0 {
+			result.Action = ImportUnchanged
+			return result
+		}
+		result.Action = ImportUpdate
+	}
+	if dryRun {
+		return result
+	}
+
+	req := GrantRequest{
+		ID:            gm.ID,
+		UserLabel:     gm.UserLabel,
+		Namespace:     gm.Namespace,
+		Rules:         gm.Rules,
+		Namespaces:    gm.Namespaces,
+		ClusterScope:  gm.ClusterScope,
+		Template:      gm.Template,
+		Subject:       gm.Subject,
+		Login:         gm.Login,
+		Justification: gm.Justification,
+		Reconcile:     result.Action == ImportUpdate,
+	}
+	if gm.ExpiresAt != nil {
+		req.ExpiresAt = *gm.ExpiresAt
+		req.Duration = time.Until(req.ExpiresAt)
+	}
+	grant, err := m.Create(ctx, req)
+	if err != nil {
+		return fail(err)
+	}
+	result.Grant = grant
+	return result
+}
+
+// diffManifests lists the fields in which a declared grant differs from the live one
+func diffManifests(live, desired *GrantManifest) []Change {
+	var changes []Change
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, Change{Field: field, From: from, To: to})
+		}
+	}
+
+	add("userLabel", live.UserLabel, sanitizeName(desired.UserLabel))
+	add("scope", scopeOf(live.ClusterScope), scopeOf(desired.ClusterScope))
+	if !desired.ClusterScope {
+		desiredNamespaces := targetNamespaces(GrantRequest{Namespace: desired.Namespace, Namespaces: desired.Namespaces})
+		add("namespaces", strings.Join(live.Namespaces, ","), strings.Join(desiredNamespaces, ","))
+	}
+	add("template", live.Template, desired.Template)
+	add("expiresAt", formatExpiry(live.ExpiresAt), formatExpiry(desired.ExpiresAt))
+	add("subject", subjectString(live.Subject), subjectString(desired.Subject))
+	add("login", jsonString(live.Login), jsonString(desired.Login))
+	add("justification", live.Justification, desired.Justification)
+
+	// Rules are compared as sets; the change lists only the rules removed and added
+	liveRules, desiredRules := ruleSet(live.Rules), ruleSet(desired.Rules)
+	var removed, added []string
+	for rule := range liveRules {
+		if !desiredRules[rule] {
+			removed = append(removed, rule)
+		}
+	}
+	for rule := range desiredRules {
+		if !liveRules[rule] {
+			added = append(added, rule)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	add("rules", strings.Join(removed, "; "), strings.Join(added, "; "))
+	return changes
+}
+
+func scopeOf(clusterScope bool) string {
+	if clusterScope {
+		return ScopeCluster
+	}
+	return ScopeNamespace
+}
+
+// formatExpiry renders an expiry to the second, as it is stored
+func formatExpiry(t *time.Time) string {
+	if t == nil {
+		return "permanent"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func subjectString(s *Subject) string {
+	if s == nil {
+		return ""
+	}
+	return s.String()
+}
+
+// jsonString renders v compactly, or "" for nil
+func jsonString(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
+
+// ruleSet renders each rule with its lists sorted, so equal rules compare equal
+func ruleSet(rules []rbacv1.PolicyRule) map[string]bool {
+	set := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		var parts []string
+		for _, field := range []struct {
+			name   string
+			values []string
+		}{
+			{"verbs", rule.Verbs},
+			{"apiGroups", rule.APIGroups},
+			{"resources", rule.Resources},
+			{"resourceNames", rule.ResourceNames},
+			{"nonResourceURLs", rule.NonResourceURLs},
+		} {
+			if len(field.values) == 0 {
+				continue
+			}
+			values := append([]string(nil), field.values...)
+			sort.Strings(values)
+			parts = append(parts, field.name+"="+strings.Join(values, ","))
+		}
+		set[strings.Join(parts, " ")] = true
+	}
+	return set
+}
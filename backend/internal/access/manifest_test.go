@@ -0,0 +1,114 @@
+package access
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExportImport(t *testing.T) {
+	source, sourceClientset := newTestManager()
+	populateTokens(sourceClientset)
+	ctx := context.Background()
+
+	if _, err := source.Create(ctx, testRequest()); err != nil {
+		t.Fatal(err)
+	}
+	permanent := testRequest()
+	permanent.ID = "bob-ops"
+	permanent.UserLabel = "Bob Ops"
+	permanent.Duration = 0
+	if _, err := source.Create(ctx, permanent); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := source.Export(ctx, ExportFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Grants) != 2 || manifest.Grants[0].ID != "alice-dev" || manifest.Grants[1].ExpiresAt != nil {
+		t.Fatalf("exported grants = %+v, want alice-dev and a permanent bob-ops", manifest.Grants)
+	}
+	if filtered, err := source.Export(ctx, ExportFilter{User: "Bob Ops"}); err != nil || len(filtered.Grants) != 1 {
+		t.Fatalf("export filtered by user = %+v (%v), want only bob-ops", filtered, err)
+	}
+
+	// The bundle goes through YAML, as it would through git
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle Manifest
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	target, clientset := newTestManager()
+	populateTokens(clientset)
+	actions := func(dryRun bool) []string {
+		t.Helper()
+		results, err := target.Import(ctx, &bundle, dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, result := range results {
+			if result.Action == ImportError {
+				t.Fatalf("import of %s failed: %s", result.ID, result.Message)
+			}
+			got = append(got, result.Action)
+		}
+		return got
+	}
+	assertActions := func(got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("actions = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("actions = %v, want %v", got, want)
+			}
+		}
+	}
+
+	assertActions(actions(true), ImportCreate, ImportCreate)
+	if sas, _ := clientset.CoreV1().ServiceAccounts("team-a").List(ctx, metav1.ListOptions{}); len(sas.Items) != 0 {
+		t.Fatalf("dry run created %d ServiceAccounts", len(sas.Items))
+	}
+	assertActions(actions(false), ImportCreate, ImportCreate)
+	assertActions(actions(false), ImportUnchanged, ImportUnchanged)
+
+	sa, err := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FromServiceAccount(sa).ExpiresAt; !got.Equal(*bundle.Grants[0].ExpiresAt) {
+		t.Fatalf("imported expiry = %s, want %s", got, bundle.Grants[0].ExpiresAt)
+	}
+
+	// A changed rule shows up in the dry-run diff and is then reconciled
+	bundle.Grants[0].Rules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}}
+	results, err := target.Import(ctx, &bundle, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Action != ImportUpdate || len(results[0].Changes) != 1 || results[0].Changes[0].Field != "rules" {
+		t.Fatalf("dry-run result = %+v, want an update of the rules", results[0])
+	}
+	assertActions(actions(false), ImportUpdate, ImportUnchanged)
+	assertActions(actions(false), ImportUnchanged, ImportUnchanged)
+
+	// Expired grants are skipped, not recreated
+	expired := time.Now().Add(-time.Minute)
+	bundle.Grants[0].ExpiresAt = &expired
+	assertActions(actions(true), ImportSkip, ImportUnchanged)
+
+	if _, err := target.Import(ctx, &Manifest{Kind: "List"}, false); ErrorCode(err) != CodeInvalidManifest {
+		t.Fatalf("import of a foreign bundle: error = %v, want %s", err, CodeInvalidManifest)
+	}
+}
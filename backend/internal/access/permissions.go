@@ -0,0 +1,317 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	authv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CodeRulesReview is reported when the grant's permissions could not be reviewed
+const CodeRulesReview = "RULES_REVIEW_FAILED"
+
+// reviewTokenSeconds is the lifetime of the token minted to review a grant; 10 minutes is the API minimum
+const reviewTokenSeconds = 600
+
+// baselineResources are granted to every authenticated user by the system:basic-user
+// ClusterRole, so they are not reported as extra
+var baselineResources = map[string]bool{
+	"selfsubjectaccessreviews.authorization.k8s.io": true,
+	"selfsubjectrulesreviews.authorization.k8s.io":  true,
+	"selfsubjectreviews.authentication.k8s.io":      true,
+}
+
+// PermissionRow is one resource of a permission matrix
+type PermissionRow struct {
+	Resource      string   `json:"resource"` // "pods", "pods/log", "deployments.apps"; "*" for wildcards
+	ResourceNames []string `json:"resourceNames,omitempty"`
+	Verbs         []string `json:"verbs"`             // verbs the token actually has
+	Missing       []string `json:"missing,omitempty"` // requested but not granted
+	Extra         []string `json:"extra,omitempty"`   // granted but not requested
+}
+
+// NamespacePermissions is the permission matrix of a grant in one namespace
+type NamespacePermissions struct {
+	Namespace       string          `json:"namespace"`
+	Rows            []PermissionRow `json:"rows"`
+	NonResourceURLs []string        `json:"nonResourceURLs,omitempty"`
+	Matches         bool            `json:"matches"`              // nothing missing or extra
+	Incomplete      bool            `json:"incomplete,omitempty"` // the authorizer could not list every rule
+	EvaluationError string          `json:"evaluationError,omitempty"`
+}
+
+// PermissionReport compares what a grant's token can do with the rules it was created with
+type PermissionReport struct {
+	Name       string                 `json:"name"`
+	Namespace  string                 `json:"namespace"`
+	Scope      string                 `json:"scope"`
+	Requested  []rbacv1.PolicyRule    `json:"requested"`
+	Namespaces []NamespacePermissions `json:"namespaces"`
+	Matches    bool                   `json:"matches"`
+	ReviewedAt time.Time              `json:"reviewedAt"`
+}
+
+// EffectivePermissions mints a short-lived token for a grant's ServiceAccount, or impersonates
+// the User or Group of an identity grant, and runs a SelfSubjectRulesReview in each namespace
+// the grant covers (its own namespace for cluster scope). The rules reported by the API server
+// are compared with the rules of the grant's roles, which catches aggregation, extra bindings
+// and missing API groups.
+func (m *Manager) EffectivePermissions(ctx context.Context, namespace, name string) (*PermissionReport, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	saName, _, _, _ := Names(name)
+	sa, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, newError(CodeNotFound, "Bridge access user '%s' not found in namespace '%s'", name, namespace)
+		}
+		return nil, err
+	}
+	if sa.Labels[LabelManagedBy] != ManagedByBridge {
+		return nil, newError(CodeNotBridgeManaged, "ServiceAccount %s/%s is not managed by Bridge", namespace, saName)
+	}
+
+	grant := FromServiceAccount(sa)
+	if !grant.ExpiresAt.IsZero() && time.Now().After(grant.ExpiresAt) {
+		return nil, newError(CodeExpired, "'%s' expired at %s", name, grant.ExpiresAt.Format(time.RFC3339))
+	}
+
+	requested, err := grantRules(ctx, clientset, grant)
+	if err != nil {
+		return nil, newError(CodeRulesReview, "%v", err)
+	}
+	reviewNamespaces := grant.Namespaces
+	if grant.ClusterScope {
+		reviewNamespaces = []string{grant.Namespace}
+	}
+
+	grantClientset, err := m.reviewClientset(ctx, clientset, grant)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PermissionReport{
+		Name:       grant.Name,
+		Namespace:  grant.Namespace,
+		Scope:      grant.Scope(),
+		Requested:  requested,
+		Matches:    true,
+		ReviewedAt: time.Now().UTC(),
+	}
+	if report.Requested == nil {
+		report.Requested = []rbacv1.PolicyRule{}
+	}
+	for _, ns := range reviewNamespaces {
+		review, err := reviewRules(ctx, grantClientset, ns)
+		if err != nil {
+			return nil, newError(CodeRulesReview, "SelfSubjectRulesReview in namespace '%s' failed: %v", ns, err)
+		}
+		result := comparePermissions(requested, review)
+		result.Namespace = ns
+		report.Matches = report.Matches && result.Matches
+		report.Namespaces = append(report.Namespaces, result)
+	}
+	return report, nil
+}
+
+// grantRules reads the rules a grant was created with from its ClusterRole, or its Role in
+// its first namespace; every Role of a grant has the same rules
+func grantRules(ctx context.Context, clientset kubernetes.Interface, grant *Grant) ([]rbacv1.PolicyRule, error) {
+	if grant.ClusterScope {
+		role, err := clientset.RbacV1().ClusterRoles().Get(ctx, grant.Role, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ClusterRole %s: %w", grant.Role, err)
+		}
+		return role.Rules, nil
+	}
+	if len(grant.Namespaces) == 0 {
+		return nil, nil
+	}
+	_, roleName, _, _ := Names(grant.Name)
+	ns := grant.Namespaces[0]
+	role, err := clientset.RbacV1().Roles(ns).Get(ctx, roleName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Role %s/%s: %w", ns, roleName, err)
+	}
+	return role.Rules, nil
+}
+
+// reviewClientset returns a client that acts as the holder of a grant: a short-lived token of
+// its ServiceAccount, or an impersonation of its User or Group, which Bridge must be allowed
+func (m *Manager) reviewClientset(ctx context.Context, clientset kubernetes.Interface, grant *Grant) (kubernetes.Interface, error) {
+	if grant.Subject != nil {
+		impersonated, err := m.impersonatedClientset(ctx, grant.Subject.impersonation())
+		if err != nil {
+			return nil, newError(CodeRulesReview, "failed to build a client impersonating %s: %v", grant.Subject, err)
+		}
+		return impersonated, nil
+	}
+
+	expirationSeconds := int64(reviewTokenSeconds)
+	token, err := clientset.CoreV1().ServiceAccounts(grant.Namespace).CreateToken(ctx, grant.ServiceAccount, &authv1.TokenRequest{
+		Spec: authv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, newError(CodeTokenRequest, "failed to create review token: %v", err)
+	}
+	tokenClientset, err := m.tokenClientset(ctx, token.Status.Token)
+	if err != nil {
+		return nil, newError(CodeRulesReview, "failed to build a client for the grant's token: %v", err)
+	}
+	return tokenClientset, nil
+}
+
+// reviewRules asks the API server what the client's identity can do in a namespace
+func reviewRules(ctx context.Context, clientset kubernetes.Interface, namespace string) (*authorizationv1.SelfSubjectRulesReview, error) {
+	return clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}, metav1.CreateOptions{})
+}
+
+// permissionKey is a row of the matrix: a resource in a group, optionally limited to names
+type permissionKey struct {
+	group, resource, names string
+}
+
+func (k permissionKey) String() string {
+	if k.group == "" || (k.group == "*" && k.resource == "*") {
+		return k.resource
+	}
+	return k.resource + "." + k.group
+}
+
+// coveredBy reports whether a rule entry (possibly with wildcards) allows verb on k
+func (k permissionKey) coveredBy(entry permissionKey, verbs map[string]bool, verb string) bool {
+	if entry.group != "*" && entry.group != k.group {
+		return false
+	}
+	if entry.resource != "*" && entry.resource != k.resource {
+		return false
+	}
+	if entry.names != "" && entry.names != k.names {
+		return false
+	}
+	return verbs["*"] || verbs[verb]
+}
+
+// flattenRules indexes rules by group, resource and resource names
+func flattenRules(rules []rbacv1.PolicyRule) map[permissionKey]map[string]bool {
+	flat := make(map[permissionKey]map[string]bool)
+	for _, rule := range rules {
+		names := append([]string(nil), rule.ResourceNames...)
+		sort.Strings(names)
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				key := permissionKey{group: group, resource: resource, names: strings.Join(names, ",")}
+				if flat[key] == nil {
+					flat[key] = make(map[string]bool)
+				}
+				for _, verb := range rule.Verbs {
+					flat[key][verb] = true
+				}
+			}
+		}
+	}
+	return flat
+}
+
+// covered reports whether any indexed rule allows verb on key
+func covered(flat map[permissionKey]map[string]bool, key permissionKey, verb string) bool {
+	for entry, verbs := range flat {
+		if key.coveredBy(entry, verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// comparePermissions builds the permission matrix of a rules review and flags verbs
+// that were requested but not granted, and granted but not requested
+func comparePermissions(requested []rbacv1.PolicyRule, review *authorizationv1.SelfSubjectRulesReview) NamespacePermissions {
+	var effectiveRules []rbacv1.PolicyRule
+	result := NamespacePermissions{
+		Rows:            []PermissionRow{},
+		Matches:         true,
+		Incomplete:      review.Status.Incomplete,
+		EvaluationError: review.Status.EvaluationError,
+	}
+	for _, r := range review.Status.ResourceRules {
+		effectiveRules = append(effectiveRules, rbacv1.PolicyRule{
+			Verbs:         r.Verbs,
+			APIGroups:     r.APIGroups,
+			Resources:     r.Resources,
+			ResourceNames: r.ResourceNames,
+		})
+	}
+	for _, r := range review.Status.NonResourceRules {
+		for _, url := range r.NonResourceURLs {
+			for _, verb := range r.Verbs {
+				result.NonResourceURLs = append(result.NonResourceURLs, verb+" "+url)
+			}
+		}
+	}
+	sort.Strings(result.NonResourceURLs)
+
+	want := flattenRules(requested)
+	have := flattenRules(effectiveRules)
+
+	keys := make(map[permissionKey]bool)
+	for key := range want {
+		keys[key] = true
+	}
+	for key := range have {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		row := PermissionRow{Resource: key.String(), Verbs: sortedVerbs(have[key])}
+		if key.names != "" {
+			row.ResourceNames = strings.Split(key.names, ",")
+		}
+		for _, verb := range sortedVerbs(want[key]) {
+			if !covered(have, key, verb) {
+				row.Missing = append(row.Missing, verb)
+			}
+		}
+		if !baselineResources[key.String()] {
+			for _, verb := range row.Verbs {
+				if !covered(want, key, verb) {
+					row.Extra = append(row.Extra, verb)
+				}
+			}
+		}
+		if len(row.Missing) > 0 || len(row.Extra) > 0 {
+			result.Matches = false
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	sort.Slice(result.Rows, func(i, j int) bool {
+		if result.Rows[i].Resource != result.Rows[j].Resource {
+			return result.Rows[i].Resource < result.Rows[j].Resource
+		}
+		return strings.Join(result.Rows[i].ResourceNames, ",") < strings.Join(result.Rows[j].ResourceNames, ",")
+	})
+	return result
+}
+
+// sortedVerbs returns a verb set in a stable order
+func sortedVerbs(verbs map[string]bool) []string {
+	sorted := make([]string, 0, len(verbs))
+	for verb := range verbs {
+		sorted = append(sorted, verb)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
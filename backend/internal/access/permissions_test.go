@@ -0,0 +1,123 @@
+package access
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestEffectivePermissions(t *testing.T) {
+	// Every authenticated user has these; they must not show up as extra
+	basicUser := authorizationv1.ResourceRule{
+		Verbs:     []string{"create"},
+		APIGroups: []string{"authorization.k8s.io"},
+		Resources: []string{"selfsubjectaccessreviews", "selfsubjectrulesreviews"},
+	}
+
+	tests := []struct {
+		name        string
+		requested   []rbacv1.PolicyRule
+		effective   []authorizationv1.ResourceRule
+		wantMatches bool
+		wantMissing map[string][]string
+		wantExtra   map[string][]string
+	}{
+		{
+			name:        "matches",
+			requested:   []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get", "list"}}},
+			effective:   []authorizationv1.ResourceRule{{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"list", "get"}}, basicUser},
+			wantMatches: true,
+		},
+		{
+			name:      "extra verbs from another binding",
+			requested: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+			effective: []authorizationv1.ResourceRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "delete"}}, basicUser},
+			wantExtra: map[string][]string{"pods": {"delete"}},
+		},
+		{
+			name:        "missing API group",
+			requested:   []rbacv1.PolicyRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "patch"}}},
+			effective:   []authorizationv1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}}},
+			wantMissing: map[string][]string{"deployments.apps": {"patch"}},
+		},
+		{
+			name:        "wildcards cover specific verbs",
+			requested:   []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+			effective:   []authorizationv1.ResourceRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}, basicUser},
+			wantMatches: true,
+		},
+		{
+			name:      "resource names",
+			requested: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"app"}, Verbs: []string{"get"}}},
+			effective: []authorizationv1.ResourceRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}},
+			wantExtra: map[string][]string{"configmaps": {"get"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+				Name: "alice-sa", Namespace: "team-a",
+				Labels:      map[string]string{LabelManagedBy: ManagedByBridge},
+				Annotations: map[string]string{AnnotationNamespaces: "team-a,team-b"},
+			}}
+			var objects []runtime.Object
+			objects = append(objects, sa)
+			for _, ns := range []string{"team-a", "team-b"} {
+				objects = append(objects, &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "alice-role", Namespace: ns}, Rules: tt.requested})
+			}
+
+			m, clientset := newTestManager(objects...)
+			var reviewed []string
+			clientset.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+				reviewed = append(reviewed, review.Spec.Namespace)
+				review.Status.ResourceRules = tt.effective
+				return true, review, nil
+			})
+			var usedToken string
+			m.tokenClientset = func(ctx context.Context, token string) (kubernetes.Interface, error) {
+				usedToken = token
+				return clientset, nil
+			}
+
+			report, err := m.EffectivePermissions(context.Background(), "team-a", "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usedToken != "test-token" {
+				t.Fatalf("review ran with token %q, want the grant's token", usedToken)
+			}
+			if !reflect.DeepEqual(reviewed, []string{"team-a", "team-b"}) {
+				t.Fatalf("reviewed namespaces %v", reviewed)
+			}
+			if report.Matches != tt.wantMatches {
+				t.Fatalf("Matches = %v, want %v: %+v", report.Matches, tt.wantMatches, report.Namespaces[0].Rows)
+			}
+
+			missing, extra := map[string][]string{}, map[string][]string{}
+			for _, row := range report.Namespaces[0].Rows {
+				if len(row.Missing) > 0 {
+					missing[row.Resource] = row.Missing
+				}
+				if len(row.Extra) > 0 {
+					extra[row.Resource] = row.Extra
+				}
+			}
+			if len(missing) != len(tt.wantMissing) || (len(missing) > 0 && !reflect.DeepEqual(missing, tt.wantMissing)) {
+				t.Fatalf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+			if len(extra) != len(tt.wantExtra) || (len(extra) > 0 && !reflect.DeepEqual(extra, tt.wantExtra)) {
+				t.Fatalf("extra = %v, want %v", extra, tt.wantExtra)
+			}
+		})
+	}
+}
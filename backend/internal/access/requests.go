@@ -0,0 +1,313 @@
+package access
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Permissions is the resources × verbs shorthand for rules
+type Permissions struct {
+	Resources []string `json:"resources"` // e.g., ["deployments", "pods", "secrets"]
+	Verbs     []string `json:"verbs"`     // e.g., ["get", "list", "create", "update"]
+}
+
+// GrantSpec is a grant as asked for over the API, before its template and permissions are resolved
+type GrantSpec struct {
+	UserLabel    string              `json:"userLabel"`              // e.g., "frontend-dev"
+	Namespace    string              `json:"namespace"`              // Target namespace; also where the ServiceAccount lives
+	Namespaces   []string            `json:"namespaces,omitempty"`   // Grant the same permissions in several namespaces
+	ClusterScope bool                `json:"clusterScope,omitempty"` // Grant the permissions cluster-wide
+	Template     string              `json:"template,omitempty"`     // Access template to start from; rules and permissions are added to it
+	Permissions  Permissions         `json:"permissions"`            // RBAC permissions (shorthand for rules)
+	Rules        []rbacv1.PolicyRule `json:"rules,omitempty"`        // Full RBAC rules; take precedence over permissions
+	Duration     string              `json:"duration"`               // e.g., "1h", "8h", "24h", "7d", or "0" for permanent
+	Subject      *Subject            `json:"subject,omitempty"`      // Bind an existing User or Group instead of the ServiceAccount
+	Login        *Login              `json:"login,omitempty"`        // How kubeconfigs of a subject grant log in
+}
+
+// Access request statuses
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestDenied   = "denied"
+	RequestExpired  = "expired"
+)
+
+// KindRequest marks access request ConfigMaps
+const KindRequest = "access-request"
+
+// LabelRequestStatus holds an access request's status so requests can be listed by it
+const LabelRequestStatus = "bridge.io/request-status"
+
+// Error codes for access requests
+const (
+	CodeNotPending      = "REQUEST_NOT_PENDING"
+	CodeRequestConflict = "REQUEST_CHANGED"
+)
+
+const (
+	// requestKey is the ConfigMap data key holding a request's YAML
+	requestKey = "request.yaml"
+	// requestPrefix prefixes the ConfigMap name of a request
+	requestPrefix = "bridge-request-"
+
+	// RequestTTL is how long a request stays pending before the janitor expires it
+	RequestTTL = 24 * time.Hour
+	// RequestRetention is how long decided and expired requests are kept
+	RequestRetention = 30 * 24 * time.Hour
+)
+
+// AccessRequest asks for a grant; it is created only once an approver approves it
+type AccessRequest struct {
+	ID            string     `json:"id"`
+	Spec          GrantSpec  `json:"spec"`
+	Justification string     `json:"justification"`
+	Requester     string     `json:"requester"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"` // when the request expires if still pending
+	DecidedBy     string     `json:"decidedBy,omitempty"`
+	DecidedAt     *time.Time `json:"decidedAt,omitempty"`
+	Reason        string     `json:"reason,omitempty"` // approver's note or reason for denial
+	Error         string     `json:"error,omitempty"`  // why the last approval attempt failed
+
+	// Rules are resolved from the template and permissions when the request is
+	// submitted. Approvers review them and the grant gets exactly these rules,
+	// even if the template changes while the request is pending.
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+
+	// Set for grants, imports and extensions that were turned into requests because approval is required
+	GrantID   string          `json:"grantId,omitempty"`   // the grant to create, or to update with Reconcile
+	Reconcile bool            `json:"reconcile,omitempty"` // update the grant with GrantID instead of failing
+	Import    *GrantManifest  `json:"import,omitempty"`    // applied exactly as declared on approval
+	Extend    *GrantExtension `json:"extend,omitempty"`    // extends an existing grant instead of creating one
+
+	// The grant created on approval
+	GrantNamespace string `json:"grantNamespace,omitempty"`
+	GrantName      string `json:"grantName,omitempty"`
+
+	resourceVersion string
+}
+
+// GrantExtension asks to push an existing grant's expiry back
+type GrantExtension struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Duration  string `json:"duration"` // added to the grant's expiry at approval, e.g. "4h"
+}
+
+// newRequestID returns a random request ID
+func newRequestID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// requestFromConfigMap decodes a request ConfigMap
+func requestFromConfigMap(cm *corev1.ConfigMap) (*AccessRequest, error) {
+	var r AccessRequest
+	if err := yaml.Unmarshal([]byte(cm.Data[requestKey]), &r); err != nil {
+		return nil, fmt.Errorf("access request %s is malformed: %w", cm.Name, err)
+	}
+	r.resourceVersion = cm.ResourceVersion
+	return &r, nil
+}
+
+// requestConfigMap encodes a request as a ConfigMap
+func requestConfigMap(r *AccessRequest) (*corev1.ConfigMap, error) {
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            requestPrefix + r.ID,
+			Namespace:       SystemNamespace,
+			ResourceVersion: r.resourceVersion,
+			Labels: map[string]string{
+				LabelManagedBy:     ManagedByBridge,
+				LabelKind:          KindRequest,
+				LabelAccessUser:    sanitizeName(r.Spec.UserLabel),
+				LabelRequestStatus: r.Status,
+			},
+		},
+		Data: map[string]string{requestKey: string(data)},
+	}, nil
+}
+
+// SubmitRequest stores a new pending access request
+func (m *Manager) SubmitRequest(ctx context.Context, r AccessRequest) (*AccessRequest, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+	r.ID = id
+	r.Status = RequestPending
+	r.CreatedAt = time.Now().UTC()
+	r.ExpiresAt = r.CreatedAt.Add(RequestTTL)
+	r.DecidedBy, r.DecidedAt, r.Reason, r.Error = "", nil, "", ""
+	r.GrantNamespace, r.GrantName = "", ""
+
+	cm, err := requestConfigMap(&r)
+	if err != nil {
+		return nil, err
+	}
+	if err := EnsureSystemNamespace(ctx, clientset); err != nil {
+		return nil, err
+	}
+	created, err := clientset.CoreV1().ConfigMaps(SystemNamespace).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store access request: %w", err)
+	}
+	r.resourceVersion = created.ResourceVersion
+	return &r, nil
+}
+
+// ListRequests returns access requests, newest first. An empty status lists all of them.
+func (m *Manager) ListRequests(ctx context.Context, status string) ([]AccessRequest, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := fmt.Sprintf("%s=%s,%s=%s", LabelManagedBy, ManagedByBridge, LabelKind, KindRequest)
+	if status != "" {
+		selector += fmt.Sprintf(",%s=%s", LabelRequestStatus, status)
+	}
+	cms, err := clientset.CoreV1().ConfigMaps(SystemNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]AccessRequest, 0, len(cms.Items))
+	for i := range cms.Items {
+		r, err := requestFromConfigMap(&cms.Items[i])
+		if err != nil {
+			log.Printf("[Access] Skipping access request: %v", err)
+			continue
+		}
+		requests = append(requests, *r)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].CreatedAt.After(requests[j].CreatedAt) })
+	return requests, nil
+}
+
+// GetRequest returns the access request with the given ID
+func (m *Manager) GetRequest(ctx context.Context, id string) (*AccessRequest, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps(SystemNamespace).Get(ctx, requestPrefix+id, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, newError(CodeNotFound, "access request '%s' not found", id)
+		}
+		return nil, err
+	}
+	if cm.Labels[LabelKind] != KindRequest {
+		return nil, newError(CodeNotFound, "access request '%s' not found", id)
+	}
+	return requestFromConfigMap(cm)
+}
+
+// UpdateRequest saves a request read by GetRequest or ListRequests. It fails with
+// CodeRequestConflict if the request changed since, so two approvers can't both act on it.
+func (m *Manager) UpdateRequest(ctx context.Context, r *AccessRequest) error {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	cm, err := requestConfigMap(r)
+	if err != nil {
+		return err
+	}
+	updated, err := clientset.CoreV1().ConfigMaps(SystemNamespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			return newError(CodeRequestConflict, "access request '%s' was changed by someone else; reload it and try again", r.ID)
+		}
+		return err
+	}
+	r.resourceVersion = updated.ResourceVersion
+	return nil
+}
+
+// Decide moves a pending request to approved or denied. The request is claimed
+// before the grant is created, so it can only be decided once.
+func (m *Manager) Decide(ctx context.Context, r *AccessRequest, status, by, reason string) error {
+	if r.Status != RequestPending {
+		return newError(CodeNotPending, "access request '%s' is %s", r.ID, r.Status)
+	}
+	if time.Now().After(r.ExpiresAt) {
+		r.Status = RequestExpired
+		if err := m.UpdateRequest(ctx, r); err != nil {
+			return err
+		}
+		return newError(CodeNotPending, "access request '%s' expired at %s", r.ID, r.ExpiresAt.Format(time.RFC3339))
+	}
+
+	now := time.Now().UTC()
+	r.Status = status
+	r.DecidedBy = by
+	r.DecidedAt = &now
+	r.Reason = reason
+	r.Error = ""
+	return m.UpdateRequest(ctx, r)
+}
+
+// ExpireRequests marks pending requests past their expiry as expired and deletes
+// decided requests older than RequestRetention. It returns how many were expired.
+func (m *Manager) ExpireRequests(ctx context.Context) (int, error) {
+	requests, err := m.ListRequests(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	expired := 0
+	for i := range requests {
+		r := &requests[i]
+		switch {
+		case r.Status == RequestPending && now.After(r.ExpiresAt):
+			r.Status = RequestExpired
+			if err := m.UpdateRequest(ctx, r); err != nil {
+				log.Printf("[Access] Failed to expire access request %s: %v", r.ID, err)
+				continue
+			}
+			expired++
+		case r.Status != RequestPending && now.Sub(r.CreatedAt) > RequestRetention:
+			err := clientset.CoreV1().ConfigMaps(SystemNamespace).Delete(ctx, requestPrefix+r.ID, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				log.Printf("[Access] Failed to delete old access request %s: %v", r.ID, err)
+			}
+		}
+	}
+	return expired, nil
+}
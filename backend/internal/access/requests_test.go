@@ -0,0 +1,87 @@
+package access
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestLifecycle(t *testing.T) {
+	m, _ := newTestManager()
+	ctx := context.Background()
+
+	submit := func(t *testing.T) *AccessRequest {
+		r, err := m.SubmitRequest(ctx, AccessRequest{
+			Spec:          GrantSpec{UserLabel: "alice", Namespace: "team-a", Template: "read-only", Duration: "8h"},
+			Justification: "incident 42",
+			Requester:     "alice@example.com",
+		})
+		if err != nil {
+			t.Fatalf("SubmitRequest() error = %v", err)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		prepare    func(r *AccessRequest) // runs before deciding
+		decide     string
+		wantCode   string
+		wantStatus string
+	}{
+		{name: "approves pending request", decide: RequestApproved, wantStatus: RequestApproved},
+		{name: "denies pending request", decide: RequestDenied, wantStatus: RequestDenied},
+		{name: "rejects second decision", prepare: func(r *AccessRequest) {
+			if err := m.Decide(ctx, r, RequestDenied, "carol", "no"); err != nil {
+				t.Fatal(err)
+			}
+		}, decide: RequestApproved, wantCode: CodeNotPending, wantStatus: RequestDenied},
+		{name: "janitor expires stale request", prepare: func(r *AccessRequest) {
+			r.ExpiresAt = time.Now().Add(-time.Minute)
+			if err := m.UpdateRequest(ctx, r); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := m.ExpireRequests(ctx); err != nil || n != 1 {
+				t.Fatalf("ExpireRequests() = %d, %v; want 1", n, err)
+			}
+			fresh, _ := m.GetRequest(ctx, r.ID)
+			*r = *fresh
+		}, decide: RequestApproved, wantCode: CodeNotPending, wantStatus: RequestExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := submit(t)
+			if r.Status != RequestPending {
+				t.Fatalf("submitted status = %q, want pending", r.Status)
+			}
+			if tt.prepare != nil {
+				tt.prepare(r)
+			}
+
+			err := m.Decide(ctx, r, tt.decide, "bob@example.com", "")
+			if got := ErrorCode(err); got != tt.wantCode {
+				t.Fatalf("Decide() error = %v, want code %q", err, tt.wantCode)
+			}
+
+			stored, err := m.GetRequest(ctx, r.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("stored status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			listed, err := m.ListRequests(ctx, tt.wantStatus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, l := range listed {
+				found = found || l.ID == r.ID
+			}
+			if !found {
+				t.Errorf("request not listed under status %q", tt.wantStatus)
+			}
+		})
+	}
+}
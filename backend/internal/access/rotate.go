@@ -0,0 +1,281 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Token rotation annotations
+const (
+	// AnnotationTokenSecret names a permanent grant's current token Secret, on its ServiceAccount
+	AnnotationTokenSecret = "bridge.io/token-secret"
+	// AnnotationRotations holds the JSON history of a grant's token rotations, on its ServiceAccount
+	AnnotationRotations = "bridge.io/token-rotations"
+	// AnnotationRetireAt marks a replaced token Secret with when it is deleted
+	AnnotationRetireAt = "bridge.io/retire-at"
+)
+
+// Error codes for Rotate
+const (
+	CodeNotPermanent   = "NOT_PERMANENT_GRANT"
+	CodeRotate         = "ROTATE_FAILED"
+	CodeRotateConflict = "ROTATED_CONCURRENTLY"
+)
+
+// DefaultRotationGrace is how long a replaced token keeps working, so its holders can switch over
+const DefaultRotationGrace = 24 * time.Hour
+
+// maxRotationHistory caps how many rotations are kept in the annotation
+const maxRotationHistory = 20
+
+// Rotation records one token rotation of a permanent grant
+type Rotation struct {
+	At       time.Time `json:"at"`
+	By       string    `json:"by,omitempty"`
+	Secret   string    `json:"secret"`   // the new token Secret
+	Previous string    `json:"previous"` // the replaced token Secret
+	RetireAt time.Time `json:"retireAt"` // when the replaced token is deleted
+}
+
+// rotationsFromAnnotation decodes the rotation history, ignoring a malformed annotation
+func rotationsFromAnnotation(value string) []Rotation {
+	if value == "" {
+		return nil
+	}
+	var rotations []Rotation
+	if err := json.Unmarshal([]byte(value), &rotations); err != nil {
+		return nil
+	}
+	return rotations
+}
+
+// TokenSecretName returns the name of a permanent grant's current token Secret
+func (g *Grant) TokenSecretName() string {
+	if g.TokenSecret != "" {
+		return g.TokenSecret
+	}
+	_, _, _, secret := Names(g.Name)
+	return secret
+}
+
+// TokenIssuedAt returns when a permanent grant's current token was created: its last
+// rotation, or else the grant's creation
+func (g *Grant) TokenIssuedAt() time.Time {
+	if n := len(g.Rotations); n > 0 {
+		return g.Rotations[n-1].At
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15-04-05Z"} {
+		if t, err := time.Parse(layout, g.CreatedAt); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Rotate replaces a permanent grant's token: it creates a new token Secret, makes it the
+// one kubeconfigs are built from and records the rotation on the ServiceAccount. The old
+// Secret, and with it the old token, is deleted by the janitor once grace has passed, or
+// right away if grace is 0.
+func (m *Manager) Rotate(ctx context.Context, namespace, name string, grace time.Duration, actor string) (*Grant, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	saName, _, _, _ := Names(name)
+	sa, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, newError(CodeNotFound, "Bridge access user '%s' not found in namespace '%s'", name, namespace)
+		}
+		return nil, err
+	}
+	if sa.Labels[LabelManagedBy] != ManagedByBridge {
+		return nil, newError(CodeNotBridgeManaged, "ServiceAccount %s/%s is not managed by Bridge", namespace, saName)
+	}
+
+	grant := FromServiceAccount(sa)
+	if grant.Subject != nil {
+		return nil, newError(CodeNoToken, "'%s' is bound to %s, who logs in on their own; it has no token to rotate", name, grant.Subject)
+	}
+	if !grant.ExpiresAt.IsZero() {
+		return nil, newError(CodeNotPermanent, "'%s' expires at %s; its tokens are short-lived and minted for every kubeconfig", name, grant.ExpiresAt.Format(time.RFC3339))
+	}
+	return m.rotate(ctx, clientset, sa, grant, grace, actor)
+}
+
+func (m *Manager) rotate(ctx context.Context, clientset kubernetes.Interface, sa *corev1.ServiceAccount, grant *Grant, grace time.Duration, actor string) (*Grant, error) {
+	suffix, err := randomSuffix()
+	if err != nil {
+		return nil, err
+	}
+	_, _, _, baseName := Names(grant.Name)
+	previous := grant.TokenSecretName()
+	secretName := baseName + "-" + suffix
+
+	tx := &transaction{}
+	ok := false
+	defer func() {
+		if !ok {
+			tx.rollback()
+		}
+	}()
+
+	labels := map[string]string{
+		LabelManagedBy:  ManagedByBridge,
+		LabelAccessUser: grant.Username,
+		LabelGrantID:    grant.Name,
+	}
+	owner := []metav1.OwnerReference{ownerReference(sa)}
+	if err := m.permanentToken(ctx, clientset, tx, grant, secretName, labels, owner, false); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	rotation := Rotation{At: now, By: actor, Secret: secretName, Previous: previous, RetireAt: now.Add(grace)}
+
+	// Record the new token with an update carrying the resourceVersion that was read. Other
+	// changes to the ServiceAccount are retried on; if another rotation got there first, this
+	// one gives up and its Secret is rolled back, so no token is left live without a record.
+	serviceAccounts := clientset.CoreV1().ServiceAccounts(grant.Namespace)
+	current := sa
+	var history []Rotation
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if current == nil {
+			fresh, err := serviceAccounts.Get(ctx, grant.ServiceAccount, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			current = fresh
+		}
+		latest := FromServiceAccount(current)
+		if latest.TokenSecretName() != previous {
+			return newError(CodeRotateConflict, "the token of '%s' was rotated concurrently; try again", grant.Name)
+		}
+		history = append(append([]Rotation{}, latest.Rotations...), rotation)
+		if len(history) > maxRotationHistory {
+			history = history[len(history)-maxRotationHistory:]
+		}
+		historyJSON, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+
+		updated := current.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[AnnotationTokenSecret] = secretName
+		updated.Annotations[AnnotationRotations] = string(historyJSON)
+		_, err = serviceAccounts.Update(ctx, updated, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			current = nil
+		}
+		return err
+	})
+	if err != nil {
+		if ErrorCode(err) != "" {
+			return nil, err
+		}
+		return nil, newError(CodeRotate, "failed to record the new token on ServiceAccount %s: %v", grant.ServiceAccount, err)
+	}
+	ok = true
+	grant.TokenSecret = secretName
+	grant.Rotations = history
+
+	// The new token is live; failing to retire the old one only delays its deletion until revoke
+	secrets := clientset.CoreV1().Secrets(grant.Namespace)
+	if grace <= 0 {
+		if err := secrets.Delete(ctx, previous, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			log.Printf("[Access] Failed to delete replaced token Secret %s/%s: %v", grant.Namespace, previous, err)
+		}
+	} else {
+		retireAt := rotation.RetireAt.Format(time.RFC3339)
+		patch := annotationPatch(map[string]*string{AnnotationRetireAt: &retireAt})
+		if _, err := secrets.Patch(ctx, previous, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			log.Printf("[Access] Failed to schedule deletion of replaced token Secret %s/%s: %v", grant.Namespace, previous, err)
+		}
+	}
+	return grant, nil
+}
+
+// RotateDue rotates the token of every permanent ServiceAccount grant issued more than maxAge ago,
+// keeping replaced tokens valid for DefaultRotationGrace. It returns how many it rotated
+// and why the others failed.
+func (m *Manager) RotateDue(ctx context.Context, maxAge time.Duration, actor string) (int, []string, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	saList, err := clientset.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{
+		LabelSelector: LabelManagedBy + "=" + ManagedByBridge,
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("error listing ServiceAccounts: %w", err)
+	}
+
+	now := time.Now()
+	rotated := 0
+	var failures []string
+	for i := range saList.Items {
+		sa := &saList.Items[i]
+		grant := FromServiceAccount(sa)
+		if !grant.ExpiresAt.IsZero() || grant.Subject != nil {
+			continue
+		}
+		if issued := grant.TokenIssuedAt(); issued.IsZero() || now.Sub(issued) < maxAge {
+			continue
+		}
+		if _, err := m.rotate(ctx, clientset, sa, grant, DefaultRotationGrace, actor); err != nil {
+			failures = append(failures, fmt.Sprintf("%s/%s: %v", grant.Namespace, grant.Name, err))
+			continue
+		}
+		rotated++
+	}
+	return rotated, failures, nil
+}
+
+// RetireTokens deletes replaced token Secrets whose grace period has passed and returns how many
+func (m *Manager) RetireTokens(ctx context.Context) (int, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	secrets, err := clientset.CoreV1().Secrets("").List(ctx, metav1.ListOptions{
+		LabelSelector: LabelManagedBy + "=" + ManagedByBridge,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error listing token Secrets: %w", err)
+	}
+
+	now := time.Now()
+	deleted := 0
+	var errs []string
+	for _, secret := range secrets.Items {
+		retireAt, err := time.Parse(time.RFC3339, secret.Annotations[AnnotationRetireAt])
+		if err != nil || retireAt.After(now) {
+			continue
+		}
+		if err := clientset.CoreV1().Secrets(secret.Namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("%s/%s: %v", secret.Namespace, secret.Name, err))
+			continue
+		}
+		log.Printf("[Access] Deleted replaced token Secret %s/%s", secret.Namespace, secret.Name)
+		deleted++
+	}
+	if len(errs) > 0 {
+		return deleted, fmt.Errorf("failed to delete replaced tokens: %s", strings.Join(errs, "; "))
+	}
+	return deleted, nil
+}
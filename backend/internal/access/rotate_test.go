@@ -0,0 +1,184 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// populateTokens makes the fake clientset act as the token controller, issuing a new token per Secret
+func populateTokens(clientset *fake.Clientset) {
+	issued := 0
+	clientset.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		secret := action.(k8stesting.CreateAction).GetObject().(*corev1.Secret)
+		if secret.Type == corev1.SecretTypeServiceAccountToken {
+			issued++
+			secret.Data = map[string][]byte{
+				corev1.ServiceAccountTokenKey:  []byte(fmt.Sprintf("token-%d", issued)),
+				corev1.ServiceAccountRootCAKey: []byte("ca"),
+			}
+		}
+		return false, nil, nil
+	})
+}
+
+// checkResourceVersions makes the fake clientset reject updates of resource that carry a stale
+// resourceVersion, as the API server does; the fake tracker doesn't track versions itself
+func checkResourceVersions(clientset *fake.Clientset, resource string) {
+	clientset.PrependReactor("create", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj, _ := meta.Accessor(action.(k8stesting.CreateAction).GetObject())
+		obj.SetResourceVersion("1")
+		return false, nil, nil
+	})
+	clientset.PrependReactor("update", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		update := action.(k8stesting.UpdateAction)
+		obj, _ := meta.Accessor(update.GetObject())
+		stored, err := clientset.Tracker().Get(update.GetResource(), update.GetNamespace(), obj.GetName())
+		if err != nil {
+			return false, nil, nil
+		}
+		current, _ := meta.Accessor(stored)
+		if obj.GetResourceVersion() != current.GetResourceVersion() {
+			return true, nil, apierrors.NewConflict(update.GetResource().GroupResource(), obj.GetName(), fmt.Errorf("the object has been modified"))
+		}
+		version, _ := strconv.Atoi(current.GetResourceVersion())
+		obj.SetResourceVersion(strconv.Itoa(version + 1))
+		return false, nil, nil
+	})
+}
+
+func TestRotate(t *testing.T) {
+	m, clientset := newTestManager()
+	populateTokens(clientset)
+	ctx := context.Background()
+	secrets := clientset.CoreV1().Secrets("team-a")
+
+	req := testRequest()
+	req.Duration = 0
+	created, err := m.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.TokenSecret != "alice-dev-token" || created.Token != "token-1" {
+		t.Fatalf("created token %q in %q", created.Token, created.TokenSecret)
+	}
+
+	// Rotating with a grace period keeps the old token until the janitor retires it
+	rotated, err := m.Rotate(ctx, "team-a", "alice-dev", time.Hour, "bob")
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if rotated.Token != "token-2" || rotated.TokenSecret == created.TokenSecret || len(rotated.Rotations) != 1 {
+		t.Fatalf("rotated grant = %+v", rotated)
+	}
+	sa, _ := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+	if stored := FromServiceAccount(sa); stored.TokenSecretName() != rotated.TokenSecret || stored.Rotations[0].By != "bob" {
+		t.Fatalf("ServiceAccount records %q, %+v", stored.TokenSecretName(), stored.Rotations)
+	}
+	if n, err := m.RetireTokens(ctx); n != 0 || err != nil {
+		t.Fatalf("RetireTokens() during grace = %d, %v", n, err)
+	}
+	old, _ := secrets.Get(ctx, created.TokenSecret, metav1.GetOptions{})
+	old.Annotations[AnnotationRetireAt] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	secrets.Update(ctx, old, metav1.UpdateOptions{})
+	if n, err := m.RetireTokens(ctx); n != 1 || err != nil {
+		t.Fatalf("RetireTokens() after grace = %d, %v", n, err)
+	}
+	if _, err := secrets.Get(ctx, rotated.TokenSecret, metav1.GetOptions{}); err != nil {
+		t.Fatalf("current token Secret deleted: %v", err)
+	}
+
+	// Reconciling keeps the rotated token
+	req.Reconcile = true
+	if reconciled, err := m.Create(ctx, req); err != nil || reconciled.Token != "token-2" {
+		t.Fatalf("reconcile Create() = %v; want the rotated token", err)
+	}
+
+	// Without a grace period the old token is deleted right away
+	again, err := m.Rotate(ctx, "team-a", "alice-dev", 0, "")
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := secrets.Get(ctx, rotated.TokenSecret, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("replaced token Secret still present: %v", err)
+	}
+
+	// Scheduled rotation only picks up tokens older than the maximum age
+	if n, failures, err := m.RotateDue(ctx, 30*24*time.Hour, "janitor"); n != 0 || len(failures) != 0 || err != nil {
+		t.Fatalf("RotateDue() = %d, %v, %v; want nothing due", n, failures, err)
+	}
+	sa, _ = clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+	sa.Annotations[AnnotationRotations] = fmt.Sprintf(`[{"at":%q,"secret":%q}]`, time.Now().Add(-31*24*time.Hour).Format(time.RFC3339), again.TokenSecret)
+	clientset.CoreV1().ServiceAccounts("team-a").Update(ctx, sa, metav1.UpdateOptions{})
+	if n, _, err := m.RotateDue(ctx, 30*24*time.Hour, "janitor"); n != 1 || err != nil {
+		t.Fatalf("RotateDue() = %d, %v; want 1", n, err)
+	}
+
+	// Revoke removes the current token and those still in their grace period
+	if err := m.Revoke(ctx, "team-a", "alice-dev"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if left, _ := secrets.List(ctx, metav1.ListOptions{}); len(left.Items) != 0 {
+		t.Fatalf("Revoke left %d token Secrets", len(left.Items))
+	}
+
+	// Temporary grants have no token to rotate
+	req.Duration = time.Hour
+	req.Reconcile = false
+	if _, err := m.Create(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Rotate(ctx, "team-a", "alice-dev", time.Hour, ""); ErrorCode(err) != CodeNotPermanent {
+		t.Fatalf("Rotate() on a temporary grant: error = %v, want %s", err, CodeNotPermanent)
+	}
+}
+
+func TestRotateConcurrently(t *testing.T) {
+	m, clientset := newTestManager()
+	populateTokens(clientset)
+	checkResourceVersions(clientset, "serviceaccounts")
+	ctx := context.Background()
+
+	req := testRequest()
+	req.Duration = 0
+	if _, err := m.Create(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both rotations read the ServiceAccount before either records its new token
+	stale, err := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := m.Rotate(ctx, "team-a", "alice-dev", time.Hour, "bob")
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := m.rotate(ctx, clientset, stale, FromServiceAccount(stale), time.Hour, "carol"); ErrorCode(err) != CodeRotateConflict {
+		t.Fatalf("second rotation: error = %v, want %s", err, CodeRotateConflict)
+	}
+
+	// The losing rotation's Secret is gone; only the recorded token and the one it replaced are left
+	sa, _ := clientset.CoreV1().ServiceAccounts("team-a").Get(ctx, "alice-dev-sa", metav1.GetOptions{})
+	if got := FromServiceAccount(sa).TokenSecretName(); got != first.TokenSecret {
+		t.Fatalf("ServiceAccount records %q, want %q", got, first.TokenSecret)
+	}
+	secrets, _ := clientset.CoreV1().Secrets("team-a").List(ctx, metav1.ListOptions{})
+	if len(secrets.Items) != 2 {
+		var names []string
+		for _, s := range secrets.Items {
+			names = append(names, s.Name)
+		}
+		t.Fatalf("token Secrets = %v, want alice-dev-token and %s", names, first.TokenSecret)
+	}
+}
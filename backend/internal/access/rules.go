@@ -0,0 +1,251 @@
+package access
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// rbacOnlyVerbs are authorization verbs that never appear in discovery
+var rbacOnlyVerbs = map[string]bool{
+	"use":         true,
+	"bind":        true,
+	"escalate":    true,
+	"impersonate": true,
+	"approve":     true,
+	"sign":        true,
+	"attest":      true,
+}
+
+// discoveredResource is a resource as served by the cluster, merged across versions
+type discoveredResource struct {
+	namespaced bool
+	verbs      map[string]bool
+}
+
+// apiIndex maps API group -> resource (including subresources like "pods/log") -> resource
+type apiIndex struct {
+	groups map[string]map[string]*discoveredResource
+	// unverifiable holds groups whose discovery failed (e.g. an unavailable aggregated API)
+	unverifiable map[string]bool
+}
+
+// discoverAPI indexes the resources the cluster serves. Groups that fail discovery
+// are recorded as unverifiable instead of failing the whole grant.
+func discoverAPI(client discovery.DiscoveryInterface) (*apiIndex, error) {
+	index := &apiIndex{
+		groups:       map[string]map[string]*discoveredResource{},
+		unverifiable: map[string]bool{},
+	}
+
+	_, lists, err := client.ServerGroupsAndResources()
+	if err != nil {
+		var groupErr *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &groupErr) {
+			return nil, newError(CodeDiscovery, "failed to discover API resources: %v", err)
+		}
+		for gv := range groupErr.Groups {
+			index.unverifiable[gv.Group] = true
+		}
+	}
+
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		resources := index.groups[gv.Group]
+		if resources == nil {
+			resources = map[string]*discoveredResource{}
+			index.groups[gv.Group] = resources
+		}
+		for _, r := range list.APIResources {
+			res := resources[r.Name]
+			if res == nil {
+				res = &discoveredResource{namespaced: r.Namespaced, verbs: map[string]bool{}}
+				resources[r.Name] = res
+			}
+			for _, verb := range r.Verbs {
+				res.verbs[verb] = true
+			}
+		}
+	}
+	return index, nil
+}
+
+// lookup finds resource in any of groups ("*" matches every group). It reports
+// whether the lookup could not be verified because a group failed discovery.
+func (idx *apiIndex) lookup(groups []string, resource string) (found []*discoveredResource, unverifiable bool) {
+	// "deployments/*" grants every subresource; check the parent exists
+	resource = strings.TrimSuffix(resource, "/*")
+
+	for _, group := range groups {
+		if group == "*" {
+			for _, resources := range idx.groups {
+				if res, ok := resources[resource]; ok {
+					found = append(found, res)
+				}
+			}
+			if len(idx.unverifiable) > 0 {
+				unverifiable = true
+			}
+			continue
+		}
+		if idx.unverifiable[group] {
+			unverifiable = true
+		}
+		if res, ok := idx.groups[group][resource]; ok {
+			found = append(found, res)
+		}
+	}
+	return found, unverifiable
+}
+
+// ValidateRules checks rules against the API resources the cluster actually serves, so
+// typos in groups, resources and verbs are rejected before any Role is created.
+// Namespaced grants may not name cluster-scoped resources or non-resource URLs.
+func ValidateRules(client discovery.DiscoveryInterface, rules []rbacv1.PolicyRule, clusterScope bool) error {
+	if len(rules) == 0 {
+		return newError(CodeInvalidRules, "at least one rule is required")
+	}
+
+	index, err := discoverAPI(client)
+	if err != nil {
+		return err
+	}
+
+	for i, rule := range rules {
+		if err := index.validateRule(rule, clusterScope); err != nil {
+			return newError(CodeInvalidRules, "rule %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// validateRule checks a single rule
+func (idx *apiIndex) validateRule(rule rbacv1.PolicyRule, clusterScope bool) error {
+	if len(rule.Verbs) == 0 {
+		return fmt.Errorf("at least one verb is required")
+	}
+
+	if len(rule.NonResourceURLs) > 0 {
+		if !clusterScope {
+			return fmt.Errorf("nonResourceURLs can only be granted with clusterScope")
+		}
+		if len(rule.Resources) > 0 || len(rule.APIGroups) > 0 {
+			return fmt.Errorf("a rule cannot mix nonResourceURLs with apiGroups or resources")
+		}
+		return nil
+	}
+
+	if len(rule.Resources) == 0 {
+		return fmt.Errorf("at least one resource is required")
+	}
+	if len(rule.APIGroups) == 0 {
+		return fmt.Errorf(`apiGroups is required (use "" for the core group)`)
+	}
+	for _, group := range rule.APIGroups {
+		if group == "*" || idx.unverifiable[group] {
+			continue
+		}
+		if _, ok := idx.groups[group]; !ok {
+			return fmt.Errorf("unknown API group %q", group)
+		}
+	}
+
+	for _, resource := range rule.Resources {
+		if resource == "*" {
+			continue
+		}
+
+		found, unverifiable := idx.lookup(rule.APIGroups, resource)
+		if len(found) == 0 {
+			if unverifiable {
+				continue
+			}
+			return fmt.Errorf("unknown resource %q in API group(s) %s", resource, formatGroups(rule.APIGroups))
+		}
+
+		namespaced := false
+		verbs := map[string]bool{}
+		for _, res := range found {
+			namespaced = namespaced || res.namespaced
+			for verb := range res.verbs {
+				verbs[verb] = true
+			}
+		}
+		if !namespaced && !clusterScope {
+			return fmt.Errorf("%q is cluster-scoped and can only be granted with clusterScope", resource)
+		}
+
+		// Some aggregated APIs don't report verbs; only check what discovery tells us
+		if len(verbs) == 0 || strings.HasSuffix(resource, "/*") {
+			continue
+		}
+		for _, verb := range rule.Verbs {
+			if verb == "*" || rbacOnlyVerbs[verb] || verbs[verb] {
+				continue
+			}
+			return fmt.Errorf("resource %q does not support verb %q", resource, verb)
+		}
+	}
+	return nil
+}
+
+// ExpandPermissions turns a flat list of resources and verbs into rules, one per API
+// group, using discovery to find the group(s) that serve each resource.
+func ExpandPermissions(client discovery.DiscoveryInterface, resources, verbs []string) ([]rbacv1.PolicyRule, error) {
+	index, err := discoverAPI(client)
+	if err != nil {
+		return nil, err
+	}
+
+	byGroup := map[string][]string{}
+	for _, resource := range resources {
+		var groups []string
+		for group, served := range index.groups {
+			if _, ok := served[resource]; ok {
+				groups = append(groups, group)
+			}
+		}
+		if len(groups) == 0 {
+			return nil, newError(CodeInvalidRules, "unknown resource %q", resource)
+		}
+		for _, group := range groups {
+			byGroup[group] = append(byGroup[group], resource)
+		}
+	}
+
+	groups := make([]string, 0, len(byGroup))
+	for group := range byGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	rules := make([]rbacv1.PolicyRule, 0, len(groups))
+	for _, group := range groups {
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: byGroup[group],
+			Verbs:     verbs,
+		})
+	}
+	return rules, nil
+}
+
+// formatGroups renders API groups for error messages, showing the core group as "core"
+func formatGroups(groups []string) string {
+	names := make([]string, len(groups))
+	for i, group := range groups {
+		if group == "" {
+			group = "core"
+		}
+		names[i] = group
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,62 @@
+package access
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		rule         rbacv1.PolicyRule
+		clusterScope bool
+		wantErr      bool
+	}{
+		{name: "core resource", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}},
+		{name: "legacy group list", rule: rbacv1.PolicyRule{APIGroups: []string{"", "apps", "batch", "extensions"}, Resources: []string{"deployments"}, Verbs: []string{"get"}}, wantErr: true},
+		{name: "subresource", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get"}}},
+		{name: "resource names", rule: rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web"}, Verbs: []string{"get", "patch"}}},
+		{name: "wildcards", rule: rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		{name: "unknown group", rule: rbacv1.PolicyRule{APIGroups: []string{"argoproj.io"}, Resources: []string{"applications"}, Verbs: []string{"get"}}, wantErr: true},
+		{name: "typo in resource", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pod"}, Verbs: []string{"get"}}, wantErr: true},
+		{name: "resource in wrong group", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"deployments"}, Verbs: []string{"get"}}, wantErr: true},
+		{name: "unsupported verb", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"delete"}}, wantErr: true},
+		{name: "missing verbs", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}}, wantErr: true},
+		{name: "cluster resource in namespace", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}}, wantErr: true},
+		{name: "cluster resource cluster-wide", rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}}, clusterScope: true},
+		{name: "non-resource URL in namespace", rule: rbacv1.PolicyRule{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}}, wantErr: true},
+		{name: "non-resource URL cluster-wide", rule: rbacv1.PolicyRule{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}}, clusterScope: true},
+	}
+
+	client := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: testResources}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRules(client, []rbacv1.PolicyRule{tt.rule}, tt.clusterScope)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && ErrorCode(err) != CodeInvalidRules {
+				t.Errorf("error code = %q, want %q", ErrorCode(err), CodeInvalidRules)
+			}
+		})
+	}
+}
+
+func TestExpandPermissions(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: testResources}}
+
+	rules, err := ExpandPermissions(client, []string{"pods", "deployments"}, []string{"get"})
+	if err != nil {
+		t.Fatalf("ExpandPermissions() error = %v", err)
+	}
+	if len(rules) != 2 || rules[0].APIGroups[0] != "" || rules[1].APIGroups[0] != "apps" || rules[1].Resources[0] != "deployments" {
+		t.Errorf("ExpandPermissions() = %+v, want one core rule for pods and one apps rule for deployments", rules)
+	}
+
+	if _, err := ExpandPermissions(client, []string{"ingresses"}, []string{"get"}); ErrorCode(err) != CodeInvalidRules {
+		t.Errorf("ExpandPermissions(unknown) error = %v, want %s", err, CodeInvalidRules)
+	}
+}
@@ -0,0 +1,332 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// templateKey is the ConfigMap data key holding a template's YAML
+const templateKey = "template.yaml"
+
+// templatePrefix prefixes the ConfigMap name of a template
+const templatePrefix = "bridge-template-"
+
+// Template is a named, reusable set of rules for access grants
+type Template struct {
+	Name         string              `json:"name"`
+	Description  string              `json:"description,omitempty"`
+	Rules        []rbacv1.PolicyRule `json:"rules"`
+	Duration     string              `json:"duration,omitempty"` // default grant duration, e.g. "8h"
+	ClusterScope bool                `json:"clusterScope,omitempty"`
+	BuiltIn      bool                `json:"builtIn"` // shipped with Bridge and not (yet) customized in the cluster
+	UpdatedAt    string              `json:"updatedAt,omitempty"`
+}
+
+var (
+	readVerbs  = []string{"get", "list", "watch"}
+	writeVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+)
+
+// builtInTemplates are available on every cluster. Saving a template with the same
+// name stores a customized copy in the cluster, which then takes precedence.
+var builtInTemplates = []Template{
+	{
+		Name:        "read-only",
+		Description: "View workloads, networking, config and logs; no secrets",
+		Duration:    "8h",
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods", "pods/log", "services", "endpoints", "configmaps", "events", "persistentvolumeclaims"}, Verbs: readVerbs},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments", "replicasets", "statefulsets", "daemonsets"}, Verbs: readVerbs},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs", "cronjobs"}, Verbs: readVerbs},
+			{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}, Verbs: readVerbs},
+		},
+	},
+	{
+		Name:        "developer",
+		Description: "Manage workloads, services and config, exec into and port-forward to pods",
+		Duration:    "24h",
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods", "services", "configmaps", "persistentvolumeclaims"}, Verbs: writeVerbs},
+			{APIGroups: []string{""}, Resources: []string{"pods/log", "endpoints", "events"}, Verbs: readVerbs},
+			{APIGroups: []string{""}, Resources: []string{"pods/exec", "pods/portforward"}, Verbs: []string{"get", "create"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments", "replicasets", "statefulsets", "daemonsets"}, Verbs: writeVerbs},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs", "cronjobs"}, Verbs: writeVerbs},
+			{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}, Verbs: writeVerbs},
+		},
+	},
+	{
+		Name:        "on-call",
+		Description: "Read everything but secrets, restart and scale workloads, delete pods, exec",
+		Duration:    "12h",
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods", "pods/log", "services", "endpoints", "configmaps", "events", "persistentvolumeclaims"}, Verbs: readVerbs},
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"delete"}},
+			{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"get", "create"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments", "replicasets", "statefulsets", "daemonsets"}, Verbs: readVerbs},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets", "daemonsets"}, Verbs: []string{"patch"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments/scale", "statefulsets/scale"}, Verbs: []string{"get", "update", "patch"}},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs", "cronjobs"}, Verbs: readVerbs},
+			{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}, Verbs: readVerbs},
+		},
+	},
+	{
+		Name:        BreakGlassTemplate,
+		Description: "Emergency admin: everything in the namespace, including secrets. Only through break-glass access",
+		Duration:    "1h",
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+		},
+	},
+	{
+		Name:        "ci-deployer",
+		Description: "Apply workloads, services, config and secrets from a pipeline",
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"services", "configmaps", "secrets", "serviceaccounts"}, Verbs: writeVerbs},
+			{APIGroups: []string{""}, Resources: []string{"pods", "pods/log", "events"}, Verbs: readVerbs},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets", "daemonsets"}, Verbs: writeVerbs},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs", "cronjobs"}, Verbs: writeVerbs},
+			{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}, Verbs: writeVerbs},
+		},
+	},
+}
+
+// builtInTemplate returns a copy of the built-in template called name
+func builtInTemplate(name string) (*Template, bool) {
+	for _, t := range builtInTemplates {
+		if t.Name == name {
+			t.BuiltIn = true
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// templateSpec is what is stored in the ConfigMap; the name comes from the ConfigMap
+type templateSpec struct {
+	Description  string              `json:"description,omitempty"`
+	Rules        []rbacv1.PolicyRule `json:"rules"`
+	Duration     string              `json:"duration,omitempty"`
+	ClusterScope bool                `json:"clusterScope,omitempty"`
+}
+
+// templateSelector selects template ConfigMaps
+func templateSelector() string {
+	return fmt.Sprintf("%s=%s,%s=%s", LabelManagedBy, ManagedByBridge, LabelKind, KindTemplate)
+}
+
+// templateFromConfigMap decodes a template ConfigMap
+func templateFromConfigMap(cm *corev1.ConfigMap) (*Template, error) {
+	var spec templateSpec
+	if err := yaml.Unmarshal([]byte(cm.Data[templateKey]), &spec); err != nil {
+		return nil, fmt.Errorf("template %s is malformed: %w", cm.Name, err)
+	}
+
+	updatedAt := cm.Annotations[AnnotationUpdatedAt]
+	if updatedAt == "" {
+		updatedAt = cm.CreationTimestamp.UTC().Format(time.RFC3339)
+	}
+	return &Template{
+		Name:         cm.Labels[LabelTemplate],
+		Description:  spec.Description,
+		Rules:        spec.Rules,
+		Duration:     spec.Duration,
+		ClusterScope: spec.ClusterScope,
+		UpdatedAt:    updatedAt,
+	}, nil
+}
+
+// ListTemplates returns the cluster's templates merged over the built-in ones, sorted by name
+func (m *Manager) ListTemplates(ctx context.Context) ([]Template, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cms, err := clientset.CoreV1().ConfigMaps(SystemNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: templateSelector(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]Template{}
+	for _, t := range builtInTemplates {
+		t.BuiltIn = true
+		byName[t.Name] = t
+	}
+	for i := range cms.Items {
+		t, err := templateFromConfigMap(&cms.Items[i])
+		if err != nil {
+			log.Printf("[Access] Skipping access template: %v", err)
+			continue
+		}
+		if t.Name == BreakGlassTemplate {
+			continue
+		}
+		byName[t.Name] = *t
+	}
+
+	templates := make([]Template, 0, len(byName))
+	for _, t := range byName {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// GetTemplate returns the template called name, preferring the cluster's copy over a built-in one.
+// The break-glass template is always the built-in one: it is taken without an approver.
+func (m *Manager) GetTemplate(ctx context.Context, name string) (*Template, error) {
+	if name == BreakGlassTemplate {
+		t, _ := builtInTemplate(name)
+		return t, nil
+	}
+
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps(SystemNamespace).Get(ctx, templatePrefix+name, metav1.GetOptions{})
+	if err == nil && cm.Labels[LabelKind] == KindTemplate {
+		t, err := templateFromConfigMap(cm)
+		if err != nil {
+			return nil, newError(CodeInvalidTemplate, "%v", err)
+		}
+		return t, nil
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if t, ok := builtInTemplate(name); ok {
+		return t, nil
+	}
+	return nil, newError(CodeNotFound, "access template '%s' not found", name)
+}
+
+// SaveTemplate creates or replaces a template in the cluster after validating its rules
+func (m *Manager) SaveTemplate(ctx context.Context, t Template) (*Template, error) {
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Name == "" || sanitizeName(t.Name) != t.Name || len(templatePrefix+t.Name) > 253 {
+		return nil, newError(CodeInvalidTemplate, "template name must be lowercase letters, digits and hyphens")
+	}
+	if t.Name == BreakGlassTemplate {
+		return nil, newError(CodeBuiltInTemplate, "the %s template cannot be customized; it is granted without an approver", BreakGlassTemplate)
+	}
+	if t.Duration != "" {
+		if _, err := ParseDuration(t.Duration); err != nil {
+			return nil, newError(CodeInvalidTemplate, "invalid duration: %v", err)
+		}
+	}
+	if err := ValidateRules(clientset.Discovery(), t.Rules, t.ClusterScope); err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(templateSpec{
+		Description:  t.Description,
+		Rules:        t.Rules,
+		Duration:     t.Duration,
+		ClusterScope: t.ClusterScope,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := EnsureSystemNamespace(ctx, clientset); err != nil {
+		return nil, err
+	}
+
+	t.BuiltIn = false
+	t.UpdatedAt = timestamp()
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      templatePrefix + t.Name,
+			Namespace: SystemNamespace,
+			Labels: map[string]string{
+				LabelManagedBy: ManagedByBridge,
+				LabelKind:      KindTemplate,
+				LabelTemplate:  t.Name,
+			},
+			Annotations: map[string]string{
+				AnnotationUpdatedAt: t.UpdatedAt,
+			},
+		},
+		Data: map[string]string{templateKey: string(data)},
+	}
+
+	client := clientset.CoreV1().ConfigMaps(SystemNamespace)
+	existing, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = client.Create(ctx, desired, metav1.CreateOptions{})
+	case err == nil:
+		if existing.Labels[LabelManagedBy] != ManagedByBridge {
+			return nil, newError(CodeNotBridgeManaged, "ConfigMap %s/%s is not managed by Bridge", SystemNamespace, desired.Name)
+		}
+		existing.Labels = desired.Labels
+		existing.Annotations = mergeAnnotations(existing.Annotations, desired.Annotations)
+		existing.Data = desired.Data
+		_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save access template: %w", err)
+	}
+	return &t, nil
+}
+
+// DeleteTemplate deletes a template from the cluster. Deleting a customized built-in
+// template restores the built-in version; built-ins themselves cannot be deleted.
+func (m *Manager) DeleteTemplate(ctx context.Context, name string) error {
+	if name == BreakGlassTemplate {
+		return newError(CodeBuiltInTemplate, "built-in access template '%s' cannot be deleted", name)
+	}
+	clientset, err := m.clientsetFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	client := clientset.CoreV1().ConfigMaps(SystemNamespace)
+	cm, err := client.Get(ctx, templatePrefix+name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, ok := builtInTemplate(name); ok {
+			return newError(CodeBuiltInTemplate, "built-in access template '%s' cannot be deleted", name)
+		}
+		return newError(CodeNotFound, "access template '%s' not found", name)
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Labels[LabelManagedBy] != ManagedByBridge || cm.Labels[LabelKind] != KindTemplate {
+		return newError(CodeNotBridgeManaged, "ConfigMap %s/%s is not a Bridge access template", SystemNamespace, cm.Name)
+	}
+	return client.Delete(ctx, cm.Name, metav1.DeleteOptions{})
+}
+
+// EnsureSystemNamespace creates the namespace Bridge keeps shared state in
+func EnsureSystemNamespace(ctx context.Context, clientset kubernetes.Interface) error {
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   SystemNamespace,
+			Labels: map[string]string{LabelManagedBy: ManagedByBridge},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", SystemNamespace, err)
+	}
+	return nil
+}
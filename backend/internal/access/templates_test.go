@@ -0,0 +1,105 @@
+package access
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTemplates(t *testing.T) {
+	m, _ := newTestManager()
+	ctx := context.Background()
+	podRules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}
+
+	steps := []struct {
+		name        string
+		run         func() error
+		wantCode    string
+		wantBuiltIn bool // whether "read-only" resolves to the built-in afterwards
+	}{
+		{name: "built-in is available", run: func() error { return nil }, wantBuiltIn: true},
+		{name: "built-in cannot be deleted", run: func() error { return m.DeleteTemplate(ctx, "read-only") }, wantCode: CodeBuiltInTemplate, wantBuiltIn: true},
+		{name: "rejects invalid rules", run: func() error {
+			_, err := m.SaveTemplate(ctx, Template{Name: "read-only", Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pod"}, Verbs: []string{"get"}}}})
+			return err
+		}, wantCode: CodeInvalidRules, wantBuiltIn: true},
+		{name: "rejects invalid name", run: func() error {
+			_, err := m.SaveTemplate(ctx, Template{Name: "Read Only", Rules: podRules})
+			return err
+		}, wantCode: CodeInvalidTemplate, wantBuiltIn: true},
+		{name: "customizes built-in", run: func() error {
+			_, err := m.SaveTemplate(ctx, Template{Name: "read-only", Rules: podRules, Duration: "4h"})
+			return err
+		}},
+		{name: "deleting customization restores built-in", run: func() error { return m.DeleteTemplate(ctx, "read-only") }, wantBuiltIn: true},
+		{name: "unknown template", run: func() error {
+			_, err := m.GetTemplate(ctx, "nope")
+			return err
+		}, wantCode: CodeNotFound, wantBuiltIn: true},
+	}
+
+	for _, step := range steps {
+		if got := ErrorCode(step.run()); got != step.wantCode {
+			t.Fatalf("%s: error code = %q, want %q", step.name, got, step.wantCode)
+		}
+		tmpl, err := m.GetTemplate(ctx, "read-only")
+		if err != nil {
+			t.Fatalf("%s: GetTemplate() error = %v", step.name, err)
+		}
+		if tmpl.BuiltIn != step.wantBuiltIn {
+			t.Errorf("%s: BuiltIn = %v, want %v", step.name, tmpl.BuiltIn, step.wantBuiltIn)
+		}
+	}
+
+	templates, err := m.ListTemplates(ctx)
+	if err != nil {
+		t.Fatalf("ListTemplates() error = %v", err)
+	}
+	if len(templates) != len(builtInTemplates) {
+		t.Errorf("ListTemplates() returned %d templates, want %d", len(templates), len(builtInTemplates))
+	}
+}
+
+func TestBreakGlassTemplateIsFixed(t *testing.T) {
+	m, clientset := newTestManager()
+	ctx := context.Background()
+	everything := []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}}
+
+	if _, err := m.SaveTemplate(ctx, Template{Name: BreakGlassTemplate, Rules: everything, ClusterScope: true}); ErrorCode(err) != CodeBuiltInTemplate {
+		t.Fatalf("SaveTemplate(break-glass) error = %v, want %s", err, CodeBuiltInTemplate)
+	}
+	if err := m.DeleteTemplate(ctx, BreakGlassTemplate); ErrorCode(err) != CodeBuiltInTemplate {
+		t.Fatalf("DeleteTemplate(break-glass) error = %v, want %s", err, CodeBuiltInTemplate)
+	}
+
+	// A cluster-wide copy stored some other way is ignored
+	if _, err := clientset.CoreV1().ConfigMaps(SystemNamespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      templatePrefix + BreakGlassTemplate,
+			Namespace: SystemNamespace,
+			Labels:    map[string]string{LabelManagedBy: ManagedByBridge, LabelKind: KindTemplate, LabelTemplate: BreakGlassTemplate},
+		},
+		Data: map[string]string{templateKey: "rules: [{apiGroups: ['*'], resources: ['*'], verbs: ['*']}]\nclusterScope: true\n"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := m.GetTemplate(ctx, BreakGlassTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tmpl.BuiltIn || tmpl.ClusterScope {
+		t.Fatalf("GetTemplate(break-glass) = %+v, want the namespaced built-in", tmpl)
+	}
+	templates, err := m.ListTemplates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, listed := range templates {
+		if listed.Name == BreakGlassTemplate && (!listed.BuiltIn || listed.ClusterScope) {
+			t.Fatalf("ListTemplates() lists the cluster copy of break-glass: %+v", listed)
+		}
+	}
+}
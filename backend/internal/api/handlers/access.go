@@ -2,77 +2,104 @@ package handlers
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
+	"log"
 	"net/http"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/access"
+	"github.com/waiyan/bridge/internal/api/middleware"
 	"github.com/waiyan/bridge/internal/k8s"
+	"github.com/waiyan/bridge/internal/notify"
 	authv1 "k8s.io/api/authentication/v1"
-	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/yaml"
 )
 
 // Bridge label constants
 const (
-	LabelManagedBy      = "app.kubernetes.io/managed-by"
-	LabelAccessUser     = "bridge.io/access-user"
-	LabelCreatedAt      = "bridge.io/created-at"
-	AnnotationExpiresAt = "bridge.io/expires-at"
-	ManagedByBridge     = "bridge"
+	LabelManagedBy      = access.LabelManagedBy
+	LabelAccessUser     = access.LabelAccessUser
+	LabelCreatedAt      = access.LabelCreatedAt
+	AnnotationExpiresAt = access.AnnotationExpiresAt
+	ManagedByBridge     = access.ManagedByBridge
 )
 
 // AccessHandler handles RBAC and access-related HTTP requests
 type AccessHandler struct {
-	k8sService *k8s.Service
+	k8sService    *k8s.Service
+	accessManager *access.Manager
+	notifier      *notify.Notifier
+	approval      access.ApprovalPolicy
 }
 
-// NewAccessHandler creates a new AccessHandler
-func NewAccessHandler(k8sService *k8s.Service) *AccessHandler {
+// NewAccessHandler creates a new AccessHandler. execLoginCommands are the credential
+// plugins identity grants may use as exec login.
+func NewAccessHandler(k8sService *k8s.Service, approval access.ApprovalPolicy, execLoginCommands []string) *AccessHandler {
+	accessManager := access.NewManager(k8sService)
+	accessManager.SetExecLoginCommands(execLoginCommands)
 	return &AccessHandler{
-		k8sService: k8sService,
+		k8sService:    k8sService,
+		accessManager: accessManager,
+		notifier:      notify.NewNotifier(k8sService),
+		approval:      approval,
 	}
 }
 
 // Permissions defines the RBAC permissions for the generated kubeconfig
-type Permissions struct {
-	Resources []string `json:"resources"` // e.g., ["deployments", "pods", "secrets"]
-	Verbs     []string `json:"verbs"`     // e.g., ["get", "list", "create", "update"]
-}
+type Permissions = access.Permissions
 
 // CreateBridgeAccessRequest represents a request to create bridge access
 type CreateBridgeAccessRequest struct {
-	UserLabel   string      `json:"userLabel"`   // e.g., "frontend-dev"
-	Namespace   string      `json:"namespace"`   // Target namespace
-	Permissions Permissions `json:"permissions"` // RBAC permissions
-	Duration    string      `json:"duration"`    // e.g., "1h", "8h", "24h", "7d", or "0" for permanent
+	access.GrantSpec
+	ID            string `json:"id,omitempty"`            // Grant to update when reconciling; new grants get a unique ID
+	Reconcile     bool   `json:"reconcile"`               // Update the existing grant with this ID instead of failing
+	Justification string `json:"justification,omitempty"` // Why the access is needed; recorded on the grant
+
+	breakGlass bool // set by BreakGlass only
 }
 
 // CreateBridgeAccessResponse represents the response with the generated kubeconfig
 type CreateBridgeAccessResponse struct {
-	Kubeconfig     string `json:"kubeconfig"`
-	ServiceAccount string `json:"serviceAccount"`
-	Role           string `json:"role"`
-	RoleBinding    string `json:"roleBinding"`
-	ExpiresAt      string `json:"expiresAt,omitempty"` // Empty for permanent access
-	Message        string `json:"message"`
+	Name           string          `json:"name"` // The grant ID
+	Namespace      string          `json:"namespace"`
+	Kubeconfig     string          `json:"kubeconfig"`
+	ServiceAccount string          `json:"serviceAccount"`
+	Role           string          `json:"role"`
+	RoleBinding    string          `json:"roleBinding"`
+	Scope          string          `json:"scope"`                // "namespace" or "cluster"
+	Namespaces     []string        `json:"namespaces,omitempty"` // Empty for cluster scope
+	ExpiresAt      string          `json:"expiresAt,omitempty"`  // Empty for permanent access
+	Mode           string          `json:"mode"`                 // "serviceaccount" or "identity"
+	Subject        *access.Subject `json:"subject,omitempty"`    // The bound User or Group of identity grants
+	Message        string          `json:"message"`
 }
 
 // BridgeAccessUser represents a bridge-managed access user
 type BridgeAccessUser struct {
-	Name           string `json:"name"`
-	Namespace      string `json:"namespace"`
-	Username       string `json:"username"`
-	CreatedAt      string `json:"createdAt"`
-	ExpiresAt      string `json:"expiresAt,omitempty"` // Empty for permanent access
-	ServiceAccount string `json:"serviceAccount"`
-	Role           string `json:"role"`
-	RoleBinding    string `json:"roleBinding"`
+	Name           string          `json:"name"` // The grant ID
+	Namespace      string          `json:"namespace"`
+	Username       string          `json:"username"`
+	CreatedAt      string          `json:"createdAt"`
+	ExpiresAt      string          `json:"expiresAt,omitempty"` // Empty for permanent access
+	ServiceAccount string          `json:"serviceAccount"`
+	Role           string          `json:"role"`
+	RoleBinding    string          `json:"roleBinding"`
+	Scope          string          `json:"scope"`                // "namespace" or "cluster"
+	Namespaces     []string        `json:"namespaces,omitempty"` // Empty for cluster scope
+	Template       string          `json:"template,omitempty"`
+	Mode           string          `json:"mode"`                     // "serviceaccount" or "identity"
+	Subject        *access.Subject `json:"subject,omitempty"`        // The bound User or Group of identity grants
+	BreakGlass     bool            `json:"breakGlass,omitempty"`     // Emergency access taken through break-glass
+	Justification  string          `json:"justification,omitempty"`  // Why the access was taken
+	Extensions     int             `json:"extensions"`               // How many times the grant has been extended
+	LastExtendedAt string          `json:"lastExtendedAt,omitempty"` // Empty if never extended
+	TokenIssuedAt  string          `json:"tokenIssuedAt,omitempty"`  // When the current token was created; permanent access only
+	TokenAgeDays   int             `json:"tokenAgeDays,omitempty"`   // Age of the current token in whole days; permanent access only
+	Rotations      int             `json:"rotations"`                // How many times the token has been rotated
+	LastRotatedAt  string          `json:"lastRotatedAt,omitempty"`  // Empty if never rotated
 }
 
 // ListBridgeAccessResponse represents the list of bridge access users
@@ -81,58 +108,6 @@ type ListBridgeAccessResponse struct {
 	Count int                `json:"count"`
 }
 
-// sanitizeName converts a string to a valid Kubernetes resource name
-func sanitizeName(name string) string {
-	// Convert to lowercase
-	name = strings.ToLower(name)
-	// Replace spaces and underscores with hyphens
-	name = strings.ReplaceAll(name, " ", "-")
-	name = strings.ReplaceAll(name, "_", "-")
-	// Remove any characters that aren't alphanumeric or hyphens
-	reg := regexp.MustCompile("[^a-z0-9-]")
-	name = reg.ReplaceAllString(name, "")
-	// Remove leading/trailing hyphens
-	name = strings.Trim(name, "-")
-	// Limit length to 63 characters (K8s limit)
-	if len(name) > 63 {
-		name = name[:63]
-	}
-	return name
-}
-
-// getBridgeLabels returns the standard Bridge labels
-func getBridgeLabels(username string) map[string]string {
-	// Format timestamp without colons as they're invalid in labels
-	// Use format: 2006-01-02T15-04-05Z (RFC3339 with dashes instead of colons)
-	timestamp := time.Now().UTC().Format("2006-01-02T15-04-05Z")
-	return map[string]string{
-		LabelManagedBy:  ManagedByBridge,
-		LabelAccessUser: sanitizeName(username), // Sanitize username for label safety
-		LabelCreatedAt:  timestamp,
-	}
-}
-
-// parseDuration converts a duration string (e.g., "1h", "8h", "24h", "7d") to time.Duration
-// Returns 0 for "0" or empty string (permanent access)
-func parseDuration(durationStr string) (time.Duration, error) {
-	if durationStr == "" || durationStr == "0" {
-		return 0, nil // Permanent access
-	}
-
-	// Handle days (not natively supported by time.ParseDuration)
-	if strings.HasSuffix(durationStr, "d") {
-		daysStr := strings.TrimSuffix(durationStr, "d")
-		days, err := time.ParseDuration(daysStr + "h")
-		if err != nil {
-			return 0, fmt.Errorf("invalid duration: %s", durationStr)
-		}
-		// Convert hours to days (multiply by 24 since we parsed as hours)
-		return days * 24, nil
-	}
-
-	return time.ParseDuration(durationStr)
-}
-
 // CreateAccess handles POST /api/v1/bridge/access
 func (h *AccessHandler) CreateAccess(c *gin.Context) {
 	var req CreateBridgeAccessRequest
@@ -144,280 +119,221 @@ func (h *AccessHandler) CreateAccess(c *gin.Context) {
 		return
 	}
 
-	// Validate request
-	if req.UserLabel == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "INVALID_REQUEST",
-			Message: "userLabel is required",
-		})
-		return
-	}
-	if req.Namespace == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "INVALID_REQUEST",
-			Message: "namespace is required",
-		})
-		return
-	}
-	if len(req.Permissions.Resources) == 0 {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "INVALID_REQUEST",
-			Message: "at least one resource is required",
+	ctx := c.Request.Context()
+	actor := middleware.GetIdentity(c).String()
+
+	// When approval is required the grant is only created once an approver approves it
+	if h.approval.Required {
+		submitted, apiErr := h.submitRequest(ctx, access.AccessRequest{
+			Spec:          req.GrantSpec,
+			GrantID:       req.ID,
+			Reconcile:     req.Reconcile,
+			Justification: req.Justification,
+			Requester:     actor,
 		})
-		return
-	}
-	if len(req.Permissions.Verbs) == 0 {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "INVALID_REQUEST",
-			Message: "at least one verb is required",
+		if apiErr != nil {
+			c.JSON(apiErr.status, apiErr.body)
+			return
+		}
+		c.JSON(http.StatusAccepted, SubmittedAccessResponse{
+			Request: submitted,
+			Message: "Approval is required; the grant is created once an approver approves request " + submitted.ID,
 		})
 		return
 	}
 
-	// Parse duration
-	duration, err := parseDuration(req.Duration)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "INVALID_DURATION",
-			Message: fmt.Sprintf("Invalid duration: %v", err),
-		})
+	resp, apiErr := h.createAccess(ctx, req, actor)
+	if apiErr != nil {
+		c.JSON(apiErr.status, apiErr.body)
 		return
 	}
-	isPermanent := duration == 0
+	c.JSON(http.StatusOK, resp)
+}
 
-	ctx := c.Request.Context()
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
+// createAccess validates a request, resolves its template and creates the grant and its kubeconfig.
+// It is shared by CreateAccess and BreakGlass; actor is who created it.
+func (h *AccessHandler) createAccess(ctx context.Context, req CreateBridgeAccessRequest, actor string) (*CreateBridgeAccessResponse, *apiError) {
+	rules, apiErr := h.resolveAccess(ctx, &req)
+	if apiErr != nil {
+		return nil, apiErr
 	}
+	return h.grantAccess(ctx, req, rules, actor)
+}
 
-	// Sanitize the user label for use in resource names
-	baseName := sanitizeName(req.UserLabel)
-	saName := baseName + "-sa"
-	roleName := baseName + "-role"
-	bindingName := baseName + "-binding"
-	secretName := baseName + "-token"
-
-	// Get Bridge labels
-	labels := getBridgeLabels(req.UserLabel)
-
-	// Calculate expiration time (for annotations)
-	var expiresAtStr string
-	var annotations map[string]string
-	if !isPermanent {
-		expiresAt := time.Now().Add(duration)
-		expiresAtStr = expiresAt.Format(time.RFC3339)
-		annotations = map[string]string{
-			AnnotationExpiresAt: expiresAtStr,
-		}
+// resolveAccess validates a request and resolves its template and permissions into the
+// final rules. The template's duration and scope are filled into req.
+func (h *AccessHandler) resolveAccess(ctx context.Context, req *CreateBridgeAccessRequest) ([]rbacv1.PolicyRule, *apiError) {
+	// Validate request; identity grants are labelled with their subject by default
+	if req.UserLabel == "" && req.Subject != nil {
+		req.UserLabel = req.Subject.Name
 	}
-
-	// Step A: Create ServiceAccount
-	sa := &corev1.ServiceAccount{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        saName,
-			Namespace:   req.Namespace,
-			Labels:      labels,
-			Annotations: annotations,
-		},
+	if req.UserLabel == "" {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "userLabel is required",
+		}}
 	}
-
-	_, err = clientset.CoreV1().ServiceAccounts(req.Namespace).Create(ctx, sa, metav1.CreateOptions{})
-	if err != nil {
-		// Check if already exists
-		if !strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "CREATE_SA_FAILED",
-				Message: fmt.Sprintf("Failed to create ServiceAccount: %v", err),
-			})
-			return
-		}
+	if req.Namespace == "" && len(req.Namespaces) > 0 {
+		req.Namespace = req.Namespaces[0]
 	}
-
-	// Step B: Create Role
-	role := &rbacv1.Role{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        roleName,
-			Namespace:   req.Namespace,
-			Labels:      labels,
-			Annotations: annotations,
-		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{"", "apps", "batch", "extensions"},
-				Resources: req.Permissions.Resources,
-				Verbs:     req.Permissions.Verbs,
-			},
-		},
+	if req.Namespace == "" {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "namespace is required",
+		}}
 	}
-
-	_, err = clientset.RbacV1().Roles(req.Namespace).Create(ctx, role, metav1.CreateOptions{})
-	if err != nil {
-		if !strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "CREATE_ROLE_FAILED",
-				Message: fmt.Sprintf("Failed to create Role: %v", err),
-			})
-			return
-		}
+	if req.Reconcile && req.ID == "" {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "id is required to reconcile a grant",
+		}}
 	}
 
-	// Step C: Create RoleBinding
-	roleBinding := &rbacv1.RoleBinding{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        bindingName,
-			Namespace:   req.Namespace,
-			Labels:      labels,
-			Annotations: annotations,
-		},
-		Subjects: []rbacv1.Subject{
-			{
-				Kind:      "ServiceAccount",
-				Name:      saName,
-				Namespace: req.Namespace,
-			},
-		},
-		RoleRef: rbacv1.RoleRef{
-			APIGroup: "rbac.authorization.k8s.io",
-			Kind:     "Role",
-			Name:     roleName,
-		},
+	// A template supplies rules, a default duration and scope; the request adds to or overrides them
+	var template *access.Template
+	if req.Template != "" {
+		t, err := h.accessManager.GetTemplate(ctx, req.Template)
+		if err != nil {
+			return nil, accessAPIError(err)
+		}
+		template = t
+		if req.Duration == "" {
+			req.Duration = template.Duration
+		}
+		if template.ClusterScope {
+			req.ClusterScope = true
+		}
 	}
 
-	_, err = clientset.RbacV1().RoleBindings(req.Namespace).Create(ctx, roleBinding, metav1.CreateOptions{})
-	if err != nil {
-		if !strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "CREATE_ROLEBINDING_FAILED",
-				Message: fmt.Sprintf("Failed to create RoleBinding: %v", err),
-			})
-			return
+	if req.ClusterScope && len(req.Namespaces) > 0 {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "namespaces cannot be combined with clusterScope",
+		}}
+	}
+	rules := req.Rules
+	if len(rules) == 0 && (template == nil || len(req.Permissions.Resources) > 0) {
+		if len(req.Permissions.Resources) == 0 {
+			return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: "at least one resource is required",
+			}}
+		}
+		if len(req.Permissions.Verbs) == 0 {
+			return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: "at least one verb is required",
+			}}
 		}
 	}
 
-	var token, caCert string
+	if _, err := access.ParseDuration(req.Duration); err != nil {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_DURATION",
+			Message: fmt.Sprintf("Invalid duration: %v", err),
+		}}
+	}
 
-	if isPermanent {
-		// Step D (Permanent): Create token Secret for the ServiceAccount
-		tokenSecret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      secretName,
-				Namespace: req.Namespace,
-				Annotations: map[string]string{
-					"kubernetes.io/service-account.name": saName,
-				},
-				Labels: labels,
-			},
-			Type: corev1.SecretTypeServiceAccountToken,
+	if len(rules) == 0 && len(req.Permissions.Resources) > 0 {
+		// Resolve the API group of each resource in the Permissions shorthand
+		clientset, err := h.k8sService.ClientsetFor(ctx)
+		if err == nil {
+			rules, err = access.ExpandPermissions(clientset.Discovery(), req.Permissions.Resources, req.Permissions.Verbs)
 		}
-
-		_, err = clientset.CoreV1().Secrets(req.Namespace).Create(ctx, tokenSecret, metav1.CreateOptions{})
 		if err != nil {
-			if !strings.Contains(err.Error(), "already exists") {
-				c.JSON(http.StatusInternalServerError, ErrorResponse{
-					Error:   "CREATE_SECRET_FAILED",
-					Message: fmt.Sprintf("Failed to create token Secret: %v", err),
-				})
-				return
-			}
-		}
-
-		// Wait for the token to be populated
-		for i := 0; i < 10; i++ {
-			time.Sleep(500 * time.Millisecond)
-			secret, err := clientset.CoreV1().Secrets(req.Namespace).Get(ctx, secretName, metav1.GetOptions{})
-			if err != nil {
-				continue
-			}
-			if t, ok := secret.Data["token"]; ok && len(t) > 0 {
-				token = string(t)
-			}
-			if ca, ok := secret.Data["ca.crt"]; ok && len(ca) > 0 {
-				caCert = string(ca)
-			}
-			if token != "" && caCert != "" {
-				break
-			}
-		}
-
-		if token == "" {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "TOKEN_NOT_READY",
-				Message: "Token was not generated in time. The ServiceAccount may not be configured correctly.",
-			})
-			return
+			return nil, accessAPIError(err)
 		}
-	} else {
-		// Step D (Temporary): Use TokenRequest API for ephemeral token
-		expirationSeconds := int64(duration.Seconds())
+	}
+	if template != nil {
+		rules = append(append([]rbacv1.PolicyRule{}, template.Rules...), rules...)
+	}
+	return rules, nil
+}
 
-		tokenRequest := &authv1.TokenRequest{
-			Spec: authv1.TokenRequestSpec{
-				ExpirationSeconds: &expirationSeconds,
-			},
-		}
+// grantAccess creates the grant with already resolved rules and builds its kubeconfig.
+// Approving an access request uses it directly, so the grant gets exactly the rules
+// the approver saw.
+func (h *AccessHandler) grantAccess(ctx context.Context, req CreateBridgeAccessRequest, rules []rbacv1.PolicyRule, actor string) (*CreateBridgeAccessResponse, *apiError) {
+	duration, err := access.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_DURATION",
+			Message: fmt.Sprintf("Invalid duration: %v", err),
+		}}
+	}
+	isPermanent := duration == 0
 
-		tokenResponse, err := clientset.CoreV1().ServiceAccounts(req.Namespace).CreateToken(
-			ctx, saName, tokenRequest, metav1.CreateOptions{},
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "TOKEN_REQUEST_FAILED",
-				Message: fmt.Sprintf("Failed to create ephemeral token: %v", err),
-			})
-			return
-		}
-		token = tokenResponse.Status.Token
+	grant, err := h.accessManager.Create(ctx, access.GrantRequest{
+		ID:            req.ID,
+		UserLabel:     req.UserLabel,
+		Namespace:     req.Namespace,
+		Template:      req.Template,
+		Rules:         rules,
+		Namespaces:    req.Namespaces,
+		ClusterScope:  req.ClusterScope,
+		Duration:      duration,
+		Subject:       req.Subject,
+		Login:         req.Login,
+		BreakGlass:    req.breakGlass,
+		Justification: req.Justification,
+		Reconcile:     req.Reconcile,
+	})
+	if err != nil {
+		return nil, accessAPIError(err)
+	}
 
-		// Get CA cert from cluster config
-		config, getConfigErr := h.k8sService.GetConfig()
-		if getConfigErr == nil {
-			caCert = string(config.CAData)
-		}
-		if caCert == "" {
-			// Try to read from a secret in kube-system
-			secret, err := clientset.CoreV1().Secrets("kube-system").Get(ctx, "default-token", metav1.GetOptions{})
-			if err == nil {
-				if ca, ok := secret.Data["ca.crt"]; ok {
-					caCert = string(ca)
-				}
-			}
-		}
+	var expiresAtStr string
+	if !isPermanent {
+		expiresAtStr = grant.ExpiresAt.Format(time.RFC3339)
 	}
 
 	// Step E: Construct Kubeconfig
-	kubeconfig, err := h.constructKubeconfig(ctx, req.UserLabel, req.Namespace, token, caCert)
+	kubeconfig, err := h.accessManager.Kubeconfig(ctx, grant)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		// Don't leave behind a fresh grant nobody can use
+		if !req.Reconcile {
+			if revokeErr := h.accessManager.Revoke(context.Background(), grant.Namespace, grant.Name); revokeErr != nil {
+				log.Printf("[Access] Failed to roll back grant %s/%s: %v", grant.Namespace, grant.Name, revokeErr)
+			}
+		}
+		return nil, &apiError{http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBECONFIG_FAILED",
 			Message: fmt.Sprintf("Failed to construct kubeconfig: %v", err),
-		})
-		return
+		}}
 	}
 
-	message := fmt.Sprintf("Successfully created Bridge access for '%s' in namespace '%s'", req.UserLabel, req.Namespace)
+	if grant.BreakGlass {
+		h.notifier.Notify(ctx, notify.BreakGlass(grant, actor))
+	} else {
+		h.notifier.Notify(ctx, notify.GrantCreated(grant, actor))
+	}
+
+	message := fmt.Sprintf("Successfully created Bridge access '%s' for '%s' %s", grant.Name, req.UserLabel, describeScope(grant))
 	if !isPermanent {
 		message += fmt.Sprintf(" (expires in %s)", req.Duration)
 	}
 
-	c.JSON(http.StatusOK, CreateBridgeAccessResponse{
+	return &CreateBridgeAccessResponse{
+		Name:           grant.Name,
+		Namespace:      grant.Namespace,
 		Kubeconfig:     kubeconfig,
-		ServiceAccount: saName,
-		Role:           roleName,
-		RoleBinding:    bindingName,
+		ServiceAccount: grant.ServiceAccount,
+		Role:           grant.Role,
+		RoleBinding:    grant.RoleBinding,
+		Scope:          grant.Scope(),
+		Namespaces:     grant.Namespaces,
 		ExpiresAt:      expiresAtStr,
+		Mode:           grant.Mode(),
+		Subject:        grant.Subject,
 		Message:        message,
-	})
+	}, nil
 }
 
 // ListAccess handles GET /api/v1/bridge/access
+// ?user= lists only the grants of one user label
 func (h *AccessHandler) ListAccess(c *gin.Context) {
 	ctx := c.Request.Context()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
@@ -428,6 +344,9 @@ func (h *AccessHandler) ListAccess(c *gin.Context) {
 
 	// List all ServiceAccounts with Bridge label across all namespaces
 	labelSelector := fmt.Sprintf("%s=%s", LabelManagedBy, ManagedByBridge)
+	if user := c.Query("user"); user != "" {
+		labelSelector = access.UserSelector(user)
+	}
 
 	saList, err := clientset.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{
 		LabelSelector: labelSelector,
@@ -441,32 +360,48 @@ func (h *AccessHandler) ListAccess(c *gin.Context) {
 	}
 
 	users := make([]BridgeAccessUser, 0, len(saList.Items))
-	for _, sa := range saList.Items {
-		// Extract info from labels
-		username := sa.Labels[LabelAccessUser]
-		createdAt := sa.Labels[LabelCreatedAt]
-		if createdAt == "" {
-			createdAt = sa.CreationTimestamp.Format(time.RFC3339)
-		}
+	for i := range saList.Items {
+		sa := &saList.Items[i]
+		grant := access.FromServiceAccount(sa)
 
-		// Extract expiration from annotations
-		expiresAt := ""
-		if sa.Annotations != nil {
-			expiresAt = sa.Annotations[AnnotationExpiresAt]
+		var lastExtendedAt string
+		if n := len(grant.Extensions); n > 0 {
+			lastExtendedAt = grant.Extensions[n-1].At.Format(time.RFC3339)
+		}
+		var tokenIssuedAt, lastRotatedAt string
+		var tokenAgeDays int
+		if grant.ExpiresAt.IsZero() && grant.Subject == nil {
+			if issued := grant.TokenIssuedAt(); !issued.IsZero() {
+				tokenIssuedAt = issued.Format(time.RFC3339)
+				tokenAgeDays = int(time.Since(issued) / (24 * time.Hour))
+			}
+		}
+		if n := len(grant.Rotations); n > 0 {
+			lastRotatedAt = grant.Rotations[n-1].At.Format(time.RFC3339)
 		}
-
-		// Derive resource names from SA name
-		baseName := strings.TrimSuffix(sa.Name, "-sa")
 
 		users = append(users, BridgeAccessUser{
-			Name:           baseName,
-			Namespace:      sa.Namespace,
-			Username:       username,
-			CreatedAt:      createdAt,
-			ExpiresAt:      expiresAt,
-			ServiceAccount: sa.Name,
-			Role:           baseName + "-role",
-			RoleBinding:    baseName + "-binding",
+			Name:           grant.Name,
+			Namespace:      grant.Namespace,
+			Username:       grant.Username,
+			CreatedAt:      grant.CreatedAt,
+			ExpiresAt:      sa.Annotations[AnnotationExpiresAt],
+			ServiceAccount: grant.ServiceAccount,
+			Role:           grant.Role,
+			RoleBinding:    grant.RoleBinding,
+			Scope:          grant.Scope(),
+			Namespaces:     grant.Namespaces,
+			Template:       grant.Template,
+			Mode:           grant.Mode(),
+			Subject:        grant.Subject,
+			BreakGlass:     grant.BreakGlass,
+			Justification:  grant.Justification,
+			Extensions:     len(grant.Extensions),
+			LastExtendedAt: lastExtendedAt,
+			TokenIssuedAt:  tokenIssuedAt,
+			TokenAgeDays:   tokenAgeDays,
+			Rotations:      len(grant.Rotations),
+			LastRotatedAt:  lastRotatedAt,
 		})
 	}
 
@@ -489,137 +424,284 @@ func (h *AccessHandler) RevokeAccess(c *gin.Context) {
 		return
 	}
 
-	ctx := c.Request.Context()
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
+	if err := h.accessManager.Revoke(c.Request.Context(), namespace, name); err != nil {
+		respondAccessError(c, err)
+		return
+	}
+	h.notifier.Notify(c.Request.Context(), notify.GrantRevoked(namespace, name, middleware.GetIdentity(c).String()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Successfully revoked Bridge access '%s' in namespace '%s'", name, namespace),
+	})
+}
+
+// describeScope returns "in namespace 'x'", "in namespaces 'x', 'y'" or "cluster-wide"
+func describeScope(grant *access.Grant) string {
+	if grant.ClusterScope {
+		return "cluster-wide"
+	}
+	if len(grant.Namespaces) == 1 {
+		return fmt.Sprintf("in namespace '%s'", grant.Namespaces[0])
+	}
+	return fmt.Sprintf("in namespaces '%s'", strings.Join(grant.Namespaces, "', '"))
+}
+
+// ExtendAccessRequest represents a request to extend a grant
+type ExtendAccessRequest struct {
+	Duration      string `json:"duration"`                // Added to the current expiry, e.g. "4h" or "1d"
+	Justification string `json:"justification,omitempty"` // Why more time is needed; required when approval is required
+}
+
+// ExtendAccessResponse represents the response with a kubeconfig for the extended grant
+type ExtendAccessResponse struct {
+	Kubeconfig string             `json:"kubeconfig"`
+	ExpiresAt  string             `json:"expiresAt"`
+	Extensions []access.Extension `json:"extensions"`
+	Message    string             `json:"message"`
+}
+
+// ExtendAccess handles POST /api/v1/bridge/access/:namespace/:name/extend
+// Pushes the expiry back and returns a kubeconfig with a token valid until the new expiry
+func (h *AccessHandler) ExtendAccess(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	var req ExtendAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	// Derive resource names
-	saName := name + "-sa"
-	roleName := name + "-role"
-	bindingName := name + "-binding"
-	secretName := name + "-token"
+	ctx := c.Request.Context()
+	actor := middleware.GetIdentity(c).String()
 
-	// Verify the SA has Bridge label before deleting
-	sa, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
-	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "NOT_FOUND",
-			Message: fmt.Sprintf("Bridge access user '%s' not found in namespace '%s'", name, namespace),
+	// When approval is required the grant is only extended once an approver approves it
+	if h.approval.Required {
+		submitted, apiErr := h.submitExtension(ctx, namespace, name, req, actor)
+		if apiErr != nil {
+			c.JSON(apiErr.status, apiErr.body)
+			return
+		}
+		c.JSON(http.StatusAccepted, SubmittedAccessResponse{
+			Request: submitted,
+			Message: "Approval is required; the grant is extended once an approver approves request " + submitted.ID,
 		})
 		return
 	}
 
-	if sa.Labels[LabelManagedBy] != ManagedByBridge {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error:   "NOT_BRIDGE_MANAGED",
-			Message: "This ServiceAccount is not managed by Bridge and cannot be revoked",
-		})
+	resp, apiErr := h.extendAccess(ctx, namespace, name, req.Duration, actor)
+	if apiErr != nil {
+		c.JSON(apiErr.status, apiErr.body)
 		return
 	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	// Delete all resources (ignore errors for individual deletions)
-	var deleteErrors []string
+// parseExtension parses the duration an extension adds
+func parseExtension(value string) (time.Duration, *apiError) {
+	duration, err := access.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_DURATION",
+			Message: "duration must be a positive duration like 4h or 1d",
+		}}
+	}
+	return duration, nil
+}
 
-	// Delete Secret
-	if err := clientset.CoreV1().Secrets(namespace).Delete(ctx, secretName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			deleteErrors = append(deleteErrors, fmt.Sprintf("Secret: %v", err))
-		}
+// submitExtension checks that a grant can be extended and stores a request to extend it
+func (h *AccessHandler) submitExtension(ctx context.Context, namespace, name string, req ExtendAccessRequest, actor string) (*access.AccessRequest, *apiError) {
+	if _, apiErr := parseExtension(req.Duration); apiErr != nil {
+		return nil, apiErr
+	}
+	if strings.TrimSpace(req.Justification) == "" {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "justification is required",
+		}}
 	}
 
-	// Delete RoleBinding
-	if err := clientset.RbacV1().RoleBindings(namespace).Delete(ctx, bindingName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			deleteErrors = append(deleteErrors, fmt.Sprintf("RoleBinding: %v", err))
-		}
+	grant, err := h.accessManager.Extendable(ctx, namespace, name)
+	if err != nil {
+		return nil, accessAPIError(err)
+	}
+
+	submitted, err := h.accessManager.SubmitRequest(ctx, access.AccessRequest{
+		Spec: access.GrantSpec{
+			UserLabel: grant.Username,
+			Namespace: grant.Namespace,
+			Duration:  req.Duration,
+		},
+		GrantID:       grant.Name,
+		Extend:        &access.GrantExtension{Namespace: grant.Namespace, Name: grant.Name, Duration: req.Duration},
+		Justification: req.Justification,
+		Requester:     actor,
+	})
+	if err != nil {
+		return nil, accessAPIError(err)
+	}
+	return submitted, nil
+}
+
+// extendAccess extends a grant and builds a kubeconfig valid until the new expiry.
+// It is shared by ExtendAccess and the approval of extension requests; actor is who extended it.
+func (h *AccessHandler) extendAccess(ctx context.Context, namespace, name, value, actor string) (*ExtendAccessResponse, *apiError) {
+	duration, apiErr := parseExtension(value)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	grant, err := h.accessManager.Extend(ctx, namespace, name, duration, actor)
+	if err != nil {
+		return nil, accessAPIError(err)
 	}
 
-	// Delete Role
-	if err := clientset.RbacV1().Roles(namespace).Delete(ctx, roleName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			deleteErrors = append(deleteErrors, fmt.Sprintf("Role: %v", err))
+	kubeconfig, err := h.accessManager.Kubeconfig(ctx, grant)
+	if err != nil {
+		return nil, &apiError{http.StatusInternalServerError, ErrorResponse{
+			Error:   "KUBECONFIG_FAILED",
+			Message: fmt.Sprintf("Failed to construct kubeconfig: %v", err),
+		}}
+	}
+
+	expiresAt := grant.ExpiresAt.Format(time.RFC3339)
+	return &ExtendAccessResponse{
+		Kubeconfig: kubeconfig,
+		ExpiresAt:  expiresAt,
+		Extensions: grant.Extensions,
+		Message:    fmt.Sprintf("Extended Bridge access for '%s' until %s", name, expiresAt),
+	}, nil
+}
+
+// RotateAccessRequest represents a request to rotate a permanent grant's token
+type RotateAccessRequest struct {
+	GracePeriod string `json:"gracePeriod"` // How long the old token keeps working, e.g. "1h"; "0" deletes it now. Default: 24h
+}
+
+// RotateAccessResponse represents the response with a kubeconfig for the new token
+type RotateAccessResponse struct {
+	Kubeconfig     string            `json:"kubeconfig"`
+	TokenSecret    string            `json:"tokenSecret"`
+	PreviousSecret string            `json:"previousSecret"`
+	RetireAt       string            `json:"retireAt"` // When the old token is deleted
+	Rotations      []access.Rotation `json:"rotations"`
+	Message        string            `json:"message"`
+}
+
+// RotateAccess handles POST /api/v1/bridge/access/:namespace/:name/rotate
+// Replaces a permanent grant's token and returns a kubeconfig for the new one
+func (h *AccessHandler) RotateAccess(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	var req RotateAccessRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: err.Error(),
+			})
+			return
 		}
 	}
 
-	// Delete ServiceAccount
-	if err := clientset.CoreV1().ServiceAccounts(namespace).Delete(ctx, saName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			deleteErrors = append(deleteErrors, fmt.Sprintf("ServiceAccount: %v", err))
+	grace := access.DefaultRotationGrace
+	if req.GracePeriod != "" {
+		d, err := access.ParseDuration(req.GracePeriod)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_DURATION",
+				Message: "gracePeriod must be a duration like 1h or 1d, or 0 to delete the old token now",
+			})
+			return
 		}
+		grace = d
 	}
 
-	if len(deleteErrors) > 0 {
+	ctx := c.Request.Context()
+	grant, err := h.accessManager.Rotate(ctx, namespace, name, grace, middleware.GetIdentity(c).String())
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	kubeconfig, err := h.accessManager.Kubeconfig(ctx, grant)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "PARTIAL_DELETE",
-			Message: fmt.Sprintf("Some resources could not be deleted: %s", strings.Join(deleteErrors, "; ")),
+			Error:   "KUBECONFIG_FAILED",
+			Message: fmt.Sprintf("Failed to construct kubeconfig: %v", err),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Successfully revoked Bridge access for '%s' in namespace '%s'", name, namespace),
+	rotation := grant.Rotations[len(grant.Rotations)-1]
+	message := fmt.Sprintf("Rotated the token of '%s'; the old token was deleted", name)
+	if grace > 0 {
+		message = fmt.Sprintf("Rotated the token of '%s'; the old token keeps working until %s", name, rotation.RetireAt.Format(time.RFC3339))
+	}
+	c.JSON(http.StatusOK, RotateAccessResponse{
+		Kubeconfig:     kubeconfig,
+		TokenSecret:    rotation.Secret,
+		PreviousSecret: rotation.Previous,
+		RetireAt:       rotation.RetireAt.Format(time.RFC3339),
+		Rotations:      grant.Rotations,
+		Message:        message,
 	})
 }
 
-// constructKubeconfig builds a kubeconfig YAML string
-func (h *AccessHandler) constructKubeconfig(ctx context.Context, userLabel, namespace, token, caCert string) (string, error) {
-	// Get the cluster server URL from the current config
-	config, err := h.k8sService.GetConfig()
+// GetPermissions handles GET /api/v1/bridge/access/:namespace/:name/permissions
+// Reviews what the grant's own token can do in each of its namespaces and flags differences from its rules
+func (h *AccessHandler) GetPermissions(c *gin.Context) {
+	report, err := h.accessManager.EffectivePermissions(c.Request.Context(), c.Param("namespace"), c.Param("name"))
 	if err != nil {
-		return "", fmt.Errorf("config not ready: %w", err)
-	}
-	serverURL := config.Host
-
-	// Base64 encode the CA certificate (kubeconfig expects base64 encoded data)
-	caCertBase64 := base64.StdEncoding.EncodeToString([]byte(caCert))
-
-	// Build kubeconfig structure
-	kubeconfig := map[string]interface{}{
-		"apiVersion": "v1",
-		"kind":       "Config",
-		"clusters": []map[string]interface{}{
-			{
-				"name": "cluster",
-				"cluster": map[string]interface{}{
-					"server":                     serverURL,
-					"certificate-authority-data": caCertBase64,
-				},
-			},
-		},
-		"users": []map[string]interface{}{
-			{
-				"name": userLabel,
-				"user": map[string]interface{}{
-					"token": token,
-				},
-			},
-		},
-		"contexts": []map[string]interface{}{
-			{
-				"name": fmt.Sprintf("%s@cluster", userLabel),
-				"context": map[string]interface{}{
-					"cluster":   "cluster",
-					"user":      userLabel,
-					"namespace": namespace,
-				},
-			},
-		},
-		"current-context": fmt.Sprintf("%s@cluster", userLabel),
+		respondAccessError(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, report)
+}
 
-	// Convert to YAML
-	yamlBytes, err := yaml.Marshal(kubeconfig)
-	if err != nil {
-		return "", err
-	}
+// apiError is an error response that has not been written yet
+type apiError struct {
+	status int
+	body   ErrorResponse
+}
 
-	return string(yamlBytes), nil
+// respondAccessError maps an access.Manager error to an HTTP response
+func respondAccessError(c *gin.Context, err error) {
+	apiErr := accessAPIError(err)
+	c.JSON(apiErr.status, apiErr.body)
+}
+
+// accessAPIError maps an access.Manager error to an error response
+func accessAPIError(err error) *apiError {
+	code := access.ErrorCode(err)
+	status := http.StatusInternalServerError
+	switch code {
+	case "":
+		code = "CLIENT_NOT_READY"
+		status = http.StatusServiceUnavailable
+	case access.CodeAlreadyExists:
+		status = http.StatusConflict
+	case access.CodeNotBridgeManaged:
+		status = http.StatusForbidden
+	case access.CodeNotFound:
+		status = http.StatusNotFound
+	case access.CodeInvalidRequest, access.CodeInvalidRules, access.CodeInvalidTemplate, access.CodeInvalidManifest:
+		status = http.StatusBadRequest
+	case access.CodeBuiltInTemplate, access.CodePermanent, access.CodeNotPermanent, access.CodeRotateConflict, access.CodeNoToken, access.CodeBreakGlass, access.CodeNotPending, access.CodeRequestConflict:
+		status = http.StatusConflict
+	case access.CodeRateLimited:
+		status = http.StatusTooManyRequests
+	case access.CodeExpired:
+		status = http.StatusGone
+	}
+	return &apiError{status, ErrorResponse{
+		Error:   code,
+		Message: err.Error(),
+	}}
 }
 
 // Legacy endpoint - keeping for backward compatibility
@@ -630,10 +712,12 @@ func (h *AccessHandler) GenerateKubeconfig(c *gin.Context) {
 
 // GetKubeconfigResponse represents the response for GetKubeconfig
 type GetKubeconfigResponse struct {
-	Kubeconfig string `json:"kubeconfig"`
-	ExpiresAt  string `json:"expiresAt,omitempty"`
-	Username   string `json:"username"`
-	Namespace  string `json:"namespace"`
+	Kubeconfig string   `json:"kubeconfig"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"`
+	Username   string   `json:"username"`
+	Namespace  string   `json:"namespace"`
+	Scope      string   `json:"scope"`
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 // GetKubeconfig handles GET /api/v1/bridge/access/:namespace/:name/kubeconfig
@@ -651,7 +735,7 @@ func (h *AccessHandler) GetKubeconfig(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
@@ -660,9 +744,8 @@ func (h *AccessHandler) GetKubeconfig(c *gin.Context) {
 		return
 	}
 
-	// Derive resource names
-	saName := name + "-sa"
-	secretName := name + "-token"
+	// Derive resource names from the grant ID
+	saName, _, _, _ := access.Names(name)
 
 	// Verify the SA exists and has Bridge label
 	sa, err := clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
@@ -682,8 +765,10 @@ func (h *AccessHandler) GetKubeconfig(c *gin.Context) {
 		return
 	}
 
-	// Get username and expiration info
-	username := sa.Labels[LabelAccessUser]
+	// Get username, namespaces, the current token Secret and expiration info
+	grant := access.FromServiceAccount(sa)
+	secretName := grant.TokenSecretName()
+	username := grant.Username
 	expiresAt := ""
 	if sa.Annotations != nil {
 		expiresAt = sa.Annotations[AnnotationExpiresAt]
@@ -701,66 +786,60 @@ func (h *AccessHandler) GetKubeconfig(c *gin.Context) {
 		}
 	}
 
+	// Identity grants log in through their exec plugin and need no token
 	var token string
-	var caCert string
-
-	// Try to get the token from the Secret (for permanent access)
-	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
-	if err == nil {
-		// Secret exists - use the stored token
-		if t, ok := secret.Data["token"]; ok && len(t) > 0 {
-			token = string(t)
-		}
-		if ca, ok := secret.Data["ca.crt"]; ok && len(ca) > 0 {
-			caCert = string(ca)
+	if grant.Subject == nil {
+		// Try to get the token from the Secret (for permanent access)
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err == nil {
+			// Secret exists - use the stored token
+			if t, ok := secret.Data["token"]; ok && len(t) > 0 {
+				token = string(t)
+			}
+			if ca, ok := secret.Data["ca.crt"]; ok && len(ca) > 0 {
+				grant.CACert = string(ca)
+			}
 		}
-	}
 
-	// If no secret token found, generate a new ephemeral token
-	if token == "" {
-		// For temporary access, generate a new ephemeral token
-		// Use remaining time or default to 1 hour
-		var expirationSeconds int64 = 3600 // Default 1 hour
-
-		if expiresAt != "" {
-			expiryTime, err := time.Parse(time.RFC3339, expiresAt)
-			if err == nil {
-				remaining := time.Until(expiryTime).Seconds()
-				if remaining > 0 {
-					expirationSeconds = int64(remaining)
+		// If no secret token found, generate a new ephemeral token
+		if token == "" {
+			// For temporary access, generate a new ephemeral token
+			// Use remaining time or default to 1 hour
+			var expirationSeconds int64 = 3600 // Default 1 hour
+
+			if expiresAt != "" {
+				expiryTime, err := time.Parse(time.RFC3339, expiresAt)
+				if err == nil {
+					remaining := time.Until(expiryTime).Seconds()
+					if remaining > 0 {
+						expirationSeconds = int64(remaining)
+					}
 				}
 			}
-		}
-
-		tokenRequest := &authv1.TokenRequest{
-			Spec: authv1.TokenRequestSpec{
-				ExpirationSeconds: &expirationSeconds,
-			},
-		}
 
-		tokenResponse, err := clientset.CoreV1().ServiceAccounts(namespace).CreateToken(
-			ctx, saName, tokenRequest, metav1.CreateOptions{},
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "TOKEN_REQUEST_FAILED",
-				Message: fmt.Sprintf("Failed to generate ephemeral token: %v", err),
-			})
-			return
-		}
-		token = tokenResponse.Status.Token
-	}
+			tokenRequest := &authv1.TokenRequest{
+				Spec: authv1.TokenRequestSpec{
+					ExpirationSeconds: &expirationSeconds,
+				},
+			}
 
-	// Get CA cert if not already retrieved
-	if caCert == "" {
-		config, configErr := h.k8sService.GetConfig()
-		if configErr == nil {
-			caCert = string(config.CAData)
+			tokenResponse, err := clientset.CoreV1().ServiceAccounts(namespace).CreateToken(
+				ctx, saName, tokenRequest, metav1.CreateOptions{},
+			)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error:   "TOKEN_REQUEST_FAILED",
+					Message: fmt.Sprintf("Failed to generate ephemeral token: %v", err),
+				})
+				return
+			}
+			token = tokenResponse.Status.Token
 		}
 	}
 
 	// Construct the kubeconfig
-	kubeconfig, err := h.constructKubeconfig(ctx, username, namespace, token, caCert)
+	grant.Token = token
+	kubeconfig, err := h.accessManager.Kubeconfig(ctx, grant)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBECONFIG_FAILED",
@@ -774,5 +853,7 @@ func (h *AccessHandler) GetKubeconfig(c *gin.Context) {
 		ExpiresAt:  expiresAt,
 		Username:   username,
 		Namespace:  namespace,
+		Scope:      grant.Scope(),
+		Namespaces: grant.Namespaces,
 	})
 }
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/access"
+	"github.com/waiyan/bridge/internal/api/middleware"
+	"github.com/waiyan/bridge/internal/auth"
+	"github.com/waiyan/bridge/internal/notify"
+)
+
+// SubmitAccessRequest represents a request for access that needs approval
+type SubmitAccessRequest struct {
+	access.GrantSpec
+	Justification string `json:"justification"` // Why the access is needed
+}
+
+// DecideAccessRequest represents an approval or denial
+type DecideAccessRequest struct {
+	Reason string `json:"reason"` // Optional note for approvals, reason for denials
+}
+
+// ListAccessRequestsResponse represents the list of access requests
+type ListAccessRequestsResponse struct {
+	Requests []access.AccessRequest `json:"requests"`
+	Count    int                    `json:"count"`
+}
+
+// ApproveAccessResponse represents an approved request and the grant it created
+type ApproveAccessResponse struct {
+	Request *access.AccessRequest       `json:"request"`
+	Access  *CreateBridgeAccessResponse `json:"access,omitempty"`
+	Import  *access.ImportResult        `json:"import,omitempty"` // set for requests made by an import
+	Extend  *ExtendAccessResponse       `json:"extend,omitempty"` // set for requests to extend a grant
+}
+
+// SubmittedAccessResponse is returned instead of a grant when approval is required
+type SubmittedAccessResponse struct {
+	Request *access.AccessRequest `json:"request"`
+	Message string                `json:"message"`
+}
+
+// SubmitRequest handles POST /api/v1/bridge/requests
+func (h *AccessHandler) SubmitRequest(c *gin.Context) {
+	var req SubmitAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	submitted, apiErr := h.submitRequest(c.Request.Context(), access.AccessRequest{
+		Spec:          req.GrantSpec,
+		Justification: req.Justification,
+		Requester:     middleware.GetIdentity(c).String(),
+	})
+	if apiErr != nil {
+		c.JSON(apiErr.status, apiErr.body)
+		return
+	}
+
+	c.JSON(http.StatusCreated, submitted)
+}
+
+// submitRequest validates and stores an access request. It is shared by SubmitRequest and,
+// when approval is required, by CreateAccess. The template and permissions are resolved
+// into the request's rules now, so approving it grants exactly what the approver reviewed.
+func (h *AccessHandler) submitRequest(ctx context.Context, request access.AccessRequest) (*access.AccessRequest, *apiError) {
+	if strings.TrimSpace(request.Justification) == "" {
+		return nil, &apiError{http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "justification is required",
+		}}
+	}
+
+	resolved := CreateBridgeAccessRequest{GrantSpec: request.Spec, ID: request.GrantID, Reconcile: request.Reconcile}
+	rules, apiErr := h.resolveAccess(ctx, &resolved)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	request.Spec = resolved.GrantSpec
+	request.Spec.Rules, request.Spec.Permissions = nil, access.Permissions{}
+	request.Rules = rules
+
+	submitted, err := h.accessManager.SubmitRequest(ctx, request)
+	if err != nil {
+		return nil, accessAPIError(err)
+	}
+	return submitted, nil
+}
+
+// ListRequests handles GET /api/v1/bridge/requests
+// Optional filter: status (pending|approved|denied|expired)
+func (h *AccessHandler) ListRequests(c *gin.Context) {
+	requests, err := h.accessManager.ListRequests(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ListAccessRequestsResponse{
+		Requests: requests,
+		Count:    len(requests),
+	})
+}
+
+// GetRequest handles GET /api/v1/bridge/requests/:id
+func (h *AccessHandler) GetRequest(c *gin.Context) {
+	request, err := h.accessManager.GetRequest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// ApproveRequest handles POST /api/v1/bridge/requests/:id/approve
+// Creates the grant with the rules resolved at submission and returns its kubeconfig;
+// requests made by an import apply the declared grant as the import would have, and
+// requests to extend a grant extend it
+func (h *AccessHandler) ApproveRequest(c *gin.Context) {
+	var body DecideAccessRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	request, ok := h.loadForDecision(c)
+	if !ok {
+		return
+	}
+
+	// Claim the request first so a concurrent approval fails instead of granting twice
+	if err := h.accessManager.Decide(ctx, request, access.RequestApproved, middleware.GetIdentity(c).String(), body.Reason); err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	actor := middleware.GetIdentity(c).String()
+	resp := ApproveAccessResponse{Request: request}
+	var apiErr *apiError
+	switch {
+	case request.Import != nil:
+		resp.Import, apiErr = h.applyImport(ctx, request.Import, actor)
+		if apiErr == nil {
+			request.GrantNamespace, request.GrantName = resp.Import.Namespace, resp.Import.ID
+		}
+	case request.Extend != nil:
+		resp.Extend, apiErr = h.extendAccess(ctx, request.Extend.Namespace, request.Extend.Name, request.Extend.Duration, actor)
+		if apiErr == nil {
+			request.GrantNamespace, request.GrantName = request.Extend.Namespace, request.Extend.Name
+		}
+	default:
+		resp.Access, apiErr = h.grantAccess(ctx, CreateBridgeAccessRequest{
+			GrantSpec:     request.Spec,
+			ID:            request.GrantID,
+			Reconcile:     request.Reconcile,
+			Justification: request.Justification,
+		}, request.Rules, actor)
+		if apiErr == nil {
+			request.GrantNamespace, request.GrantName = resp.Access.Namespace, resp.Access.Name
+		}
+	}
+	if apiErr != nil {
+		// Put the request back so it can be approved again once the problem is fixed
+		request.Status = access.RequestPending
+		request.DecidedBy, request.DecidedAt, request.Reason = "", nil, ""
+		request.Error = apiErr.body.Message
+		if err := h.accessManager.UpdateRequest(ctx, request); err != nil {
+			log.Printf("[Access] Failed to reopen access request %s: %v", request.ID, err)
+		}
+		c.JSON(apiErr.status, apiErr.body)
+		return
+	}
+
+	if err := h.accessManager.UpdateRequest(ctx, request); err != nil {
+		log.Printf("[Access] Failed to record grant on access request %s: %v", request.ID, err)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// applyImport creates or reconciles one approved grant from an access manifest
+func (h *AccessHandler) applyImport(ctx context.Context, grant *access.GrantManifest, actor string) (*access.ImportResult, *apiError) {
+	results, err := h.accessManager.Import(ctx, &access.Manifest{
+		APIVersion: access.ManifestAPIVersion,
+		Kind:       access.ManifestKind,
+		Grants:     []access.GrantManifest{*grant},
+	}, false)
+	if err != nil {
+		return nil, accessAPIError(err)
+	}
+
+	result := results[0]
+	switch result.Action {
+	case access.ImportSkip:
+		return nil, &apiError{http.StatusGone, ErrorResponse{
+			Error:   access.CodeExpired,
+			Message: result.Message,
+		}}
+	case access.ImportError:
+		return nil, &apiError{http.StatusConflict, ErrorResponse{
+			Error:   "IMPORT_FAILED",
+			Message: result.Message,
+		}}
+	case access.ImportCreate:
+		h.notifier.Notify(ctx, notify.GrantCreated(result.Grant, actor))
+	}
+	return &result, nil
+}
+
+// DenyRequest handles POST /api/v1/bridge/requests/:id/deny
+func (h *AccessHandler) DenyRequest(c *gin.Context) {
+	var body DecideAccessRequest
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Reason) == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "reason is required",
+		})
+		return
+	}
+
+	request, ok := h.loadForDecision(c)
+	if !ok {
+		return
+	}
+
+	if err := h.accessManager.Decide(c.Request.Context(), request, access.RequestDenied, middleware.GetIdentity(c).String(), body.Reason); err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// isApprover reports whether the identity may decide access requests
+func (h *AccessHandler) isApprover(identity *auth.Identity) bool {
+	if !h.approval.Restricted() {
+		return true
+	}
+	return identity != nil && h.approval.CanDecide(identity.Email, identity.Subject, identity.Groups)
+}
+
+// loadForDecision loads the request named in the route and rejects requesters deciding their own
+// request, and anyone who is not a configured approver
+func (h *AccessHandler) loadForDecision(c *gin.Context) (*access.AccessRequest, bool) {
+	request, err := h.accessManager.GetRequest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondAccessError(c, err)
+		return nil, false
+	}
+
+	// Without authentication everyone is anonymous, so there is no one else to approve
+	identity := middleware.GetIdentity(c)
+	if identity != nil && identity.Method != string(auth.ModeNone) && strings.EqualFold(identity.String(), request.Requester) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "SELF_APPROVAL",
+			Message: "you cannot approve or deny your own access request",
+		})
+		return nil, false
+	}
+	if !h.isApprover(identity) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "NOT_APPROVER",
+			Message: "only configured approvers can approve or deny access requests",
+		})
+		return nil, false
+	}
+	return request, true
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/access"
+	"github.com/waiyan/bridge/internal/api/middleware"
+)
+
+// ListAccessTemplatesResponse represents the list of access templates
+type ListAccessTemplatesResponse struct {
+	Templates []access.Template `json:"templates"`
+	Count     int               `json:"count"`
+}
+
+// ListTemplates handles GET /api/v1/bridge/templates
+func (h *AccessHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.accessManager.ListTemplates(c.Request.Context())
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ListAccessTemplatesResponse{
+		Templates: templates,
+		Count:     len(templates),
+	})
+}
+
+// GetTemplate handles GET /api/v1/bridge/templates/:name
+func (h *AccessHandler) GetTemplate(c *gin.Context) {
+	template, err := h.accessManager.GetTemplate(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// CreateTemplate handles POST /api/v1/bridge/templates
+func (h *AccessHandler) CreateTemplate(c *gin.Context) {
+	if !h.requireTemplateWriter(c) {
+		return
+	}
+
+	var template access.Template
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.saveTemplate(c, template)
+}
+
+// UpdateTemplate handles PUT /api/v1/bridge/templates/:name
+// Updating a built-in template stores a customized copy in the cluster.
+func (h *AccessHandler) UpdateTemplate(c *gin.Context) {
+	if !h.requireTemplateWriter(c) {
+		return
+	}
+
+	var template access.Template
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+	template.Name = c.Param("name")
+
+	h.saveTemplate(c, template)
+}
+
+// requireTemplateWriter rejects template changes from anyone but the configured approvers,
+// since approvers rely on templates to know what a request grants
+func (h *AccessHandler) requireTemplateWriter(c *gin.Context) bool {
+	if h.isApprover(middleware.GetIdentity(c)) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Error:   "NOT_APPROVER",
+		Message: "only configured approvers can change access templates",
+	})
+	return false
+}
+
+// saveTemplate stores a template and writes the saved version
+func (h *AccessHandler) saveTemplate(c *gin.Context, template access.Template) {
+	saved, err := h.accessManager.SaveTemplate(c.Request.Context(), template)
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteTemplate handles DELETE /api/v1/bridge/templates/:name
+func (h *AccessHandler) DeleteTemplate(c *gin.Context) {
+	if !h.requireTemplateWriter(c) {
+		return
+	}
+
+	name := c.Param("name")
+	if err := h.accessManager.DeleteTemplate(c.Request.Context(), name); err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Access template '%s' deleted", name),
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/audit"
+)
+
+// maxAuditLimit caps how many records a single query returns
+const maxAuditLimit = 1000
+
+// AuditHandler serves the audit log
+type AuditHandler struct {
+	logger *audit.Logger
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler(logger *audit.Logger) *AuditHandler {
+	return &AuditHandler{
+		logger: logger,
+	}
+}
+
+// ListAuditResponse response for listing audit records
+type ListAuditResponse struct {
+	Records []audit.Record `json:"records"`
+	Count   int            `json:"count"`
+}
+
+// parseAuditTime accepts an RFC3339 timestamp or a duration relative to now (e.g. "24h")
+func parseAuditTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// ListAudit handles GET /api/v1/audit
+// Filters: actor, verb (exact, or a prefix like "access."), contextName, namespace,
+// result (success|failure), since/until (RFC3339 or a duration like "24h"), limit
+func (h *AuditHandler) ListAudit(c *gin.Context) {
+	// The context filter is "contextName": "context" selects the kube context of the request itself
+	filter := audit.Filter{
+		Actor:     c.Query("actor"),
+		Verb:      c.Query("verb"),
+		Context:   c.Query("contextName"),
+		Namespace: c.Query("namespace"),
+		Result:    c.Query("result"),
+		Limit:     100,
+	}
+
+	if since := c.Query("since"); since != "" {
+		t, err := parseAuditTime(since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: "since must be an RFC3339 timestamp or a duration like 24h",
+			})
+			return
+		}
+		filter.Since = t
+	}
+	if until := c.Query("until"); until != "" {
+		t, err := parseAuditTime(until)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: "until must be an RFC3339 timestamp or a duration like 1h",
+			})
+			return
+		}
+		filter.Until = t
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: "limit must be a positive integer",
+			})
+			return
+		}
+		if n > maxAuditLimit {
+			n = maxAuditLimit
+		}
+		filter.Limit = n
+	}
+
+	records, err := h.logger.Query(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "AUDIT_ERROR",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListAuditResponse{
+		Records: records,
+		Count:   len(records),
+	})
+}
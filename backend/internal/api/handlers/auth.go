@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/api/middleware"
+	"github.com/waiyan/bridge/internal/auth"
+)
+
+// AuthHandler handles login/logout for Bridge's own API
+type AuthHandler struct {
+	authenticator *auth.Authenticator
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(authenticator *auth.Authenticator) *AuthHandler {
+	return &AuthHandler{
+		authenticator: authenticator,
+	}
+}
+
+// setSessionCookie stores the session ID in an HttpOnly cookie
+func (h *AuthHandler) setSessionCookie(c *gin.Context, sessionID string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     auth.SessionCookieName,
+		Value:    sessionID,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// setOIDCStateCookie binds a pending OIDC login to this browser. SameSite=Lax
+// so the cookie is still sent on the provider's redirect back to the callback.
+func (h *AuthHandler) setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     auth.OIDCStateCookieName,
+		Value:    state,
+		Path:     "/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// TokenLogin handles GET /auth/login?token=...
+// Exchanges the local token printed at startup for a browser session cookie
+func (h *AuthHandler) TokenLogin(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" || !h.authenticator.ValidToken(token) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "INVALID_TOKEN",
+			Message: "The login token is missing or invalid",
+		})
+		return
+	}
+
+	sessionID, err := h.authenticator.NewSession(auth.Identity{Subject: "local", Method: string(auth.ModeToken)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "SESSION_FAILED",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.setSessionCookie(c, sessionID, 0)
+	c.Redirect(http.StatusFound, "/")
+}
+
+// OIDCLogin handles GET /auth/oidc/login
+// Redirects the browser to the OIDC provider
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	url, state, err := h.authenticator.OIDCLoginURL()
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "OIDC_NOT_CONFIGURED",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.setOIDCStateCookie(c, state, int(auth.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallback handles GET /auth/oidc/callback
+// Completes the authorization code flow and opens a browser session
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	var browserState string
+	if cookie, err := c.Request.Cookie(auth.OIDCStateCookieName); err == nil {
+		browserState = cookie.Value
+	}
+	h.setOIDCStateCookie(c, "", -1)
+
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "OIDC_LOGIN_FAILED",
+			Message: errParam + ": " + c.Query("error_description"),
+		})
+		return
+	}
+
+	sessionID, identity, err := h.authenticator.CompleteOIDCLogin(c.Request.Context(), browserState, c.Query("state"), c.Query("code"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "OIDC_LOGIN_FAILED",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[Auth] OIDC login: %s", identity.String())
+
+	h.setSessionCookie(c, sessionID, 0)
+	c.Redirect(http.StatusFound, "/")
+}
+
+// Logout handles POST /auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if cookie, err := c.Request.Cookie(auth.SessionCookieName); err == nil {
+		h.authenticator.EndSession(cookie.Value)
+	}
+	h.setSessionCookie(c, "", -1)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// WhoAmI handles GET /api/v1/auth/whoami
+func (h *AuthHandler) WhoAmI(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"identity":    middleware.GetIdentity(c),
+		"authEnabled": h.authenticator.Enabled(),
+		"oidcEnabled": h.authenticator.OIDCEnabled(),
+	})
+}
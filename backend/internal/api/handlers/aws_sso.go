@@ -459,6 +459,9 @@ func (h *AWSSSOHandler) MapContext(c *gin.Context) {
 		return
 	}
 
+	// Drop any cached client so the next request picks up the new identity
+	h.k8sService.GetManager().InvalidateContext(req.ContextName)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": fmt.Sprintf("Context '%s' mapped to %s/%s", req.ContextName, req.AccountId, req.RoleName),
@@ -541,6 +544,8 @@ func (h *AWSSSOHandler) DeleteContextMapping(c *gin.Context) {
 		return
 	}
 
+	h.k8sService.GetManager().InvalidateContext(contextName)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": fmt.Sprintf("Mapping for context '%s' deleted", contextName),
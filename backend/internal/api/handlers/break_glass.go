@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/access"
+	"github.com/waiyan/bridge/internal/api/middleware"
+)
+
+// BreakGlassRequest represents a request for emergency access
+type BreakGlassRequest struct {
+	Namespace     string   `json:"namespace"`
+	Namespaces    []string `json:"namespaces,omitempty"`
+	UserLabel     string   `json:"userLabel,omitempty"` // Defaults to the caller
+	Duration      string   `json:"duration,omitempty"`  // At most 1h, the default
+	Justification string   `json:"justification"`       // Why emergency access is needed
+}
+
+// BreakGlass handles POST /api/v1/bridge/break-glass
+// Grants the break-glass template right away, without an approver, but for at most an hour,
+// a few times a day per caller, and always with a notification and an audit record
+func (h *AccessHandler) BreakGlass(c *gin.Context) {
+	var req BreakGlassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Refused attempts are audited with what was asked for, too
+	req.Justification = strings.TrimSpace(req.Justification)
+	middleware.SetAuditReason(c, req.Justification)
+	middleware.SetAuditTarget(c, req.Namespace, "")
+	if len(req.Justification) < access.MinJustificationLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: fmt.Sprintf("a justification of at least %d characters is required", access.MinJustificationLength),
+		})
+		return
+	}
+	if req.Duration == "" {
+		req.Duration = access.MaxBreakGlassDuration.String()
+	}
+	duration, err := access.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 || duration > access.MaxBreakGlassDuration {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_DURATION",
+			Message: fmt.Sprintf("duration must be a positive duration of at most %s", access.MaxBreakGlassDuration),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	actor := middleware.GetIdentity(c).String()
+	if req.UserLabel == "" {
+		req.UserLabel = actor
+	}
+
+	release, err := h.accessManager.ReserveBreakGlass(ctx, actor)
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	resp, apiErr := h.createAccess(ctx, CreateBridgeAccessRequest{
+		GrantSpec: access.GrantSpec{
+			UserLabel:  req.UserLabel,
+			Namespace:  req.Namespace,
+			Namespaces: req.Namespaces,
+			Template:   access.BreakGlassTemplate,
+			Duration:   req.Duration,
+		},
+		Justification: req.Justification,
+		breakGlass:    true,
+	}, actor)
+	if apiErr != nil {
+		release()
+		c.JSON(apiErr.status, apiErr.body)
+		return
+	}
+
+	middleware.SetAuditTarget(c, resp.Namespace, resp.Name)
+	log.Printf("[Access] Break-glass access %s/%s taken by %s: %s", resp.Namespace, resp.Name, actor, req.Justification)
+	c.JSON(http.StatusOK, resp)
+}
@@ -77,16 +77,7 @@ func (h *ClusterHandler) ListHPA(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	hpaList, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(ns).List(context.Background(), metav1.ListOptions{})
+	hpaList, err := h.k8sService.CachedHorizontalPodAutoscalers(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -95,8 +86,8 @@ func (h *ClusterHandler) ListHPA(c *gin.Context) {
 		return
 	}
 
-	result := make([]HPAInfo, 0, len(hpaList.Items))
-	for _, hpa := range hpaList.Items {
+	result := make([]HPAInfo, 0, len(hpaList))
+	for _, hpa := range hpaList {
 		// Get min replicas
 		minReplicas := int32(1)
 		if hpa.Spec.MinReplicas != nil {
@@ -167,7 +158,7 @@ func (h *ClusterHandler) ListEvents(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
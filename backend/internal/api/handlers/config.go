@@ -175,7 +175,7 @@ func (h *ConfigHandler) ListSecrets(c *gin.Context) {
 }
 
 // RevealSecret handles GET /api/v1/secrets/:namespace/:name/reveal
-// Security: Explicitly reveals secret data - should be protected/audited
+// Security: Explicitly reveals secret data - every call is recorded in the audit log
 func (h *ConfigHandler) RevealSecret(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
@@ -205,7 +205,7 @@ func (h *ConfigHandler) RevealSecret(c *gin.Context) {
 // findConfigMapReferences finds all resources that reference a ConfigMap
 func (h *ConfigHandler) findConfigMapReferences(ctx context.Context, namespace, configMapName string) []ResourceReference {
 	references := []ResourceReference{}
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(ctx)
 	if err != nil {
 		return references // Return empty on client error
 	}
@@ -272,7 +272,7 @@ func (h *ConfigHandler) findConfigMapReferences(ctx context.Context, namespace,
 // findSecretReferences finds all resources that reference a Secret
 func (h *ConfigHandler) findSecretReferences(ctx context.Context, namespace, secretName string) []ResourceReference {
 	references := []ResourceReference{}
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(ctx)
 	if err != nil {
 		return references // Return empty on client error
 	}
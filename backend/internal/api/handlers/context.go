@@ -89,9 +89,10 @@ func (h *ContextHandler) SwitchContext(c *gin.Context) {
 }
 
 // GetCurrentContext handles GET /api/v1/contexts/current
+// Reports the context selected for this request (X-Bridge-Context / ?context=), or the current one
 func (h *ContextHandler) GetCurrentContext(c *gin.Context) {
 	manager := h.k8sService.GetManager()
-	currentContext, currentCluster, currentServer := manager.GetClusterInfo()
+	currentContext, currentCluster, currentServer := manager.GetClusterInfoForContext(k8s.KubeContextFrom(c.Request.Context()))
 
 	c.JSON(http.StatusOK, gin.H{
 		"context": currentContext,
@@ -30,8 +30,8 @@ func NewCRDHandler(k8sService *k8s.Service) *CRDHandler {
 }
 
 // getDiscoveryClient creates a discovery client using the current authenticated config
-func (h *CRDHandler) getDiscoveryClient() (discovery.DiscoveryInterface, error) {
-	config, err := h.k8sService.GetConfig()
+func (h *CRDHandler) getDiscoveryClient(ctx context.Context) (discovery.DiscoveryInterface, error) {
+	config, err := h.k8sService.ConfigFor(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -39,8 +39,8 @@ func (h *CRDHandler) getDiscoveryClient() (discovery.DiscoveryInterface, error)
 }
 
 // getDynamicClient creates a dynamic client using the current authenticated config
-func (h *CRDHandler) getDynamicClient() (dynamic.Interface, error) {
-	config, err := h.k8sService.GetConfig()
+func (h *CRDHandler) getDynamicClient(ctx context.Context) (dynamic.Interface, error) {
+	config, err := h.k8sService.ConfigFor(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -48,8 +48,8 @@ func (h *CRDHandler) getDynamicClient() (dynamic.Interface, error) {
 }
 
 // getAPIExtClient creates an API extensions client using the current authenticated config
-func (h *CRDHandler) getAPIExtClient() (apiextensionsclient.Interface, error) {
-	config, err := h.k8sService.GetConfig()
+func (h *CRDHandler) getAPIExtClient(ctx context.Context) (apiextensionsclient.Interface, error) {
+	config, err := h.k8sService.ConfigFor(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +118,7 @@ var coreGroups = map[string]bool{
 // ListCRDGroups handles GET /api/v1/crds
 func (h *CRDHandler) ListCRDGroups(c *gin.Context) {
 	// Get discovery client using current authenticated config
-	discoveryClient, err := h.getDiscoveryClient()
+	discoveryClient, err := h.getDiscoveryClient(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "CLIENT_ERROR",
@@ -223,7 +223,7 @@ func (h *CRDHandler) ListCustomResources(c *gin.Context) {
 	namespace := c.Query("namespace")
 
 	// Get clients using current authenticated config
-	dynamicClient, err := h.getDynamicClient()
+	dynamicClient, err := h.getDynamicClient(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "CLIENT_ERROR",
@@ -239,10 +239,10 @@ func (h *CRDHandler) ListCustomResources(c *gin.Context) {
 	}
 
 	// Check if resource is namespaced using discovery API (works for all APIs, not just CRDs)
-	isNamespaced := h.isResourceNamespaced(group, version, resource)
+	isNamespaced := h.isResourceNamespaced(c.Request.Context(), group, version, resource)
 
 	// Get printer columns from CRD definition (optional, for pretty display)
-	columns := h.getPrinterColumns(group, version, resource, isNamespaced)
+	columns := h.getPrinterColumns(c.Request.Context(), group, version, resource, isNamespaced)
 
 	// Get resources
 	var list *unstructured.UnstructuredList
@@ -280,13 +280,13 @@ func (h *CRDHandler) ListCustomResources(c *gin.Context) {
 }
 
 // isResourceNamespaced checks if a resource is namespaced using discovery API
-func (h *CRDHandler) isResourceNamespaced(group, version, resource string) bool {
+func (h *CRDHandler) isResourceNamespaced(ctx context.Context, group, version, resource string) bool {
 	gv := group + "/" + version
 	if group == "" {
 		gv = version
 	}
 
-	discoveryClient, err := h.getDiscoveryClient()
+	discoveryClient, err := h.getDiscoveryClient(ctx)
 	if err != nil {
 		return true // Default to namespaced on error
 	}
@@ -306,7 +306,7 @@ func (h *CRDHandler) isResourceNamespaced(group, version, resource string) bool
 }
 
 // getPrinterColumns fetches additionalPrinterColumns from CRD definition
-func (h *CRDHandler) getPrinterColumns(group, version, resource string, isNamespaced bool) []PrinterColumn {
+func (h *CRDHandler) getPrinterColumns(ctx context.Context, group, version, resource string, isNamespaced bool) []PrinterColumn {
 	// Default columns
 	defaultColumns := []PrinterColumn{
 		{Name: "Name", Type: "string", JSONPath: ".metadata.name"},
@@ -321,7 +321,7 @@ func (h *CRDHandler) getPrinterColumns(group, version, resource string, isNamesp
 	})
 
 	// Try to find CRD for additionalPrinterColumns
-	apiextClient, err := h.getAPIExtClient()
+	apiextClient, err := h.getAPIExtClient(ctx)
 	if err != nil {
 		return defaultColumns
 	}
@@ -9,7 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/waiyan/bridge/internal/k8s"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // DashboardHandler handles dashboard-related HTTP requests
@@ -67,7 +67,7 @@ type nodeMetricsItem struct {
 // GetStats handles GET /api/v1/dashboard/stats
 func (h *DashboardHandler) GetStats(c *gin.Context) {
 	ctx := c.Request.Context()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
@@ -84,10 +84,10 @@ func (h *DashboardHandler) GetStats(c *gin.Context) {
 	var totalCPUCapacity int64 // millicores
 	var totalMemCapacity int64 // bytes
 
-	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodeList, err := h.k8sService.CachedNodes(ctx)
 	if err == nil {
-		clusterHealth.TotalNodes = len(nodeList.Items)
-		for _, node := range nodeList.Items {
+		clusterHealth.TotalNodes = len(nodeList)
+		for _, node := range nodeList {
 			// Health check
 			isReady := false
 			for _, condition := range node.Status.Conditions {
@@ -154,22 +154,20 @@ func (h *DashboardHandler) GetStats(c *gin.Context) {
 
 	// Get namespace count
 	namespaceCount := 0
-	nsList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	nsList, err := h.k8sService.CachedNamespaces(ctx)
 	if err == nil {
-		namespaceCount = len(nsList.Items)
+		namespaceCount = len(nsList)
 	}
 
 	// Get access stats from Bridge-managed ServiceAccounts
 	accessStats := AccessStats{}
-	labelSelector := "app.kubernetes.io/managed-by=bridge"
-	saList, err := clientset.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{
-		LabelSelector: labelSelector,
-	})
+	labelSelector := labels.SelectorFromSet(labels.Set{LabelManagedBy: ManagedByBridge})
+	saList, err := h.k8sService.CachedServiceAccounts(ctx, "", labelSelector)
 	if err == nil {
 		now := time.Now()
 		twentyFourHoursLater := now.Add(24 * time.Hour)
 
-		for _, sa := range saList.Items {
+		for _, sa := range saList {
 			expiresAtStr := ""
 			if sa.Annotations != nil {
 				expiresAtStr = sa.Annotations[AnnotationExpiresAt]
@@ -16,9 +16,7 @@ import (
 )
 
 var execUpgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
+	CheckOrigin:     checkOrigin,
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
@@ -159,7 +157,7 @@ func (h *ExecHandler) Exec(c *gin.Context) {
 	}
 
 	// Create the exec request
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(c.Request.Context())
 	if err != nil {
 		h.sendError(conn, "Client not ready: "+err.Error())
 		return
@@ -180,7 +178,7 @@ func (h *ExecHandler) Exec(c *gin.Context) {
 		}, scheme.ParameterCodec)
 
 	// Create SPDY executor
-	config, err := h.k8sService.GetConfig()
+	config, err := h.k8sService.ConfigFor(c.Request.Context())
 	if err != nil {
 		h.sendError(conn, "Config not ready: "+err.Error())
 		return
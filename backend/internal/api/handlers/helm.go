@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -21,13 +22,14 @@ import (
 // This adapter allows Helm to use Bridge's authenticated config with SSO tokens
 type BridgeRESTClientGetter struct {
 	clientManager *k8s.ClientManager
+	contextName   string // kubeconfig context, empty for the current one
 	namespace     string
 }
 
 // ToRESTConfig returns the authenticated REST config from Bridge's ClientManager
 // ⚡️ MAGIC: This returns the config with the injected SSO Bearer Token
 func (b *BridgeRESTClientGetter) ToRESTConfig() (*rest.Config, error) {
-	config, err := b.clientManager.GetConfig()
+	config, err := b.clientManager.GetConfigForContext(b.contextName)
 	if err != nil {
 		return nil, err
 	}
@@ -128,10 +130,11 @@ type ReleaseHistoryResponse struct {
 	History   []HelmRevisionInfo `json:"history"`
 }
 
-func (h *HelmHandler) getActionConfig(namespace string) (*action.Configuration, error) {
+func (h *HelmHandler) getActionConfig(ctx context.Context, namespace string) (*action.Configuration, error) {
 	// ⚡️ Use Bridge's authenticated config instead of reading from ~/.kube/config
 	getter := &BridgeRESTClientGetter{
 		clientManager: h.clientManager,
+		contextName:   k8s.KubeContextFrom(ctx),
 		namespace:     namespace,
 	}
 
@@ -153,7 +156,7 @@ func (h *HelmHandler) ListReleases(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "")
 
 	// Empty namespace means all namespaces
-	actionConfig, err := h.getActionConfig(namespace)
+	actionConfig, err := h.getActionConfig(c.Request.Context(), namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "HELM_ERROR",
@@ -210,7 +213,7 @@ func (h *HelmHandler) GetRelease(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	actionConfig, err := h.getActionConfig(namespace)
+	actionConfig, err := h.getActionConfig(c.Request.Context(), namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "HELM_ERROR",
@@ -266,7 +269,7 @@ func (h *HelmHandler) GetReleaseValues(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	actionConfig, err := h.getActionConfig(namespace)
+	actionConfig, err := h.getActionConfig(c.Request.Context(), namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "HELM_ERROR",
@@ -315,7 +318,7 @@ func (h *HelmHandler) GetReleaseHistory(c *gin.Context) {
 		max = 10
 	}
 
-	actionConfig, err := h.getActionConfig(namespace)
+	actionConfig, err := h.getActionConfig(c.Request.Context(), namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "HELM_ERROR",
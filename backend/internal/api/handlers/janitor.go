@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/janitor"
+)
+
+// JanitorHandler reports on and triggers the access janitor
+type JanitorHandler struct {
+	janitor *janitor.Janitor
+}
+
+// NewJanitorHandler creates a new JanitorHandler
+func NewJanitorHandler(j *janitor.Janitor) *JanitorHandler {
+	return &JanitorHandler{
+		janitor: j,
+	}
+}
+
+// GetStatus handles GET /api/v1/janitor/status
+// Returns recent sweeps, the next scheduled one, and each context's lease holder
+func (h *JanitorHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.janitor.Status(c.Request.Context()))
+}
+
+// RunNow handles POST /api/v1/janitor/run
+// Sweeps every context immediately and returns the run
+func (h *JanitorHandler) RunNow(c *gin.Context) {
+	run, err := h.janitor.RunNow()
+	if err != nil {
+		if errors.Is(err, janitor.ErrRunning) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "JANITOR_BUSY",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "JANITOR_FAILED",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// JanitorPoliciesRequest carries janitor policies
+type JanitorPoliciesRequest struct {
+	Policies []janitor.Policy `json:"policies"`
+}
+
+// JanitorPoliciesResponse represents the janitor policies of a cluster
+type JanitorPoliciesResponse struct {
+	Policies []janitor.Policy `json:"policies"`
+}
+
+// DryRunResponse reports what the policies would delete
+type DryRunResponse struct {
+	Results []janitor.PolicyResult `json:"results"`
+}
+
+// respondPolicyError maps policy errors to HTTP responses
+func respondPolicyError(c *gin.Context, err error) {
+	if errors.Is(err, janitor.ErrInvalidPolicy) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_POLICY",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   "POLICY_ERROR",
+		Message: err.Error(),
+	})
+}
+
+// GetPolicies handles GET /api/v1/janitor/policies
+// Returns the cluster's policies, or the defaults if none are stored
+func (h *JanitorHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.janitor.Policies(c.Request.Context())
+	if err != nil {
+		respondPolicyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, JanitorPoliciesResponse{Policies: policies})
+}
+
+// UpdatePolicies handles PUT /api/v1/janitor/policies
+// Replaces the cluster's policies; TTL resources are checked against discovery
+func (h *JanitorHandler) UpdatePolicies(c *gin.Context) {
+	var req JanitorPoliciesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.Policies == nil {
+		req.Policies = []janitor.Policy{}
+	}
+
+	if err := h.janitor.SavePolicies(c.Request.Context(), req.Policies); err != nil {
+		respondPolicyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, JanitorPoliciesResponse{Policies: req.Policies})
+}
+
+// DryRunPolicies handles POST /api/v1/janitor/policies/dry-run
+// Reports what the stored policies, or the policies in the body, would delete now
+func (h *JanitorHandler) DryRunPolicies(c *gin.Context) {
+	var req JanitorPoliciesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	results, err := h.janitor.DryRun(c.Request.Context(), req.Policies)
+	if err != nil {
+		respondPolicyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DryRunResponse{Results: results})
+}
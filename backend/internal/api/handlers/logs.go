@@ -5,6 +5,8 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,85 +15,80 @@ import (
 	"github.com/waiyan/bridge/internal/k8s"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
+	CheckOrigin:     checkOrigin,
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
 
-// resolvePodsForWorkload finds pods for a given workload (Deployment, StatefulSet, DaemonSet)
-// and returns the pod list and pod names. It extracts the label selector from the workload
-// and queries for matching pods.
+// checkOrigin only allows WebSocket upgrades from the page Bridge served itself
+// (or a local dev server). Browsers attach the session cookie to cross-site
+// WebSocket handshakes, so accepting any origin would let other sites hijack it.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // Non-browser clients don't send Origin
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if u.Host == r.Host {
+		return true
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// resolveWorkloadSelector returns the pod selector of a workload (Deployment, StatefulSet,
+// DaemonSet). Any other workload type treats name as a label selector string.
 // Note: This function creates its own timeout context from context.Background() to avoid
 // issues with request context cancellation during WebSocket upgrades.
-func (h *LogsHandler) resolvePodsForWorkload(namespace, name, workloadType string) ([]corev1.Pod, []string, error) {
+func (h *LogsHandler) resolveWorkloadSelector(kubeContext, namespace, name, workloadType string) (labels.Selector, error) {
 	// Create a stable context with timeout for K8s API calls
 	// We don't use the request context because it may be cancelled during WebSocket upgrade
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(k8s.WithKubeContext(context.Background(), kubeContext), 10*time.Second)
 	defer cancel()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	var labelSelector string
-
 	switch workloadType {
 	case "deployment":
 		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
-		if err != nil {
-			return nil, nil, err
-		}
-		labelSelector = selector.String()
+		return metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
 
 	case "statefulset":
 		statefulset, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
-			return nil, nil, err
-		}
-		selector, err := metav1.LabelSelectorAsSelector(statefulset.Spec.Selector)
-		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		labelSelector = selector.String()
+		return metav1.LabelSelectorAsSelector(statefulset.Spec.Selector)
 
 	case "daemonset":
 		daemonset, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		selector, err := metav1.LabelSelectorAsSelector(daemonset.Spec.Selector)
-		if err != nil {
-			return nil, nil, err
-		}
-		labelSelector = selector.String()
+		return metav1.LabelSelectorAsSelector(daemonset.Spec.Selector)
 
 	default:
 		// Fallback: treat as a direct selector string
-		labelSelector = name
-	}
-
-	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: labelSelector,
-	})
-	if err != nil {
-		return nil, nil, err
-	}
-
-	podNames := make([]string, len(pods.Items))
-	for i, pod := range pods.Items {
-		podNames[i] = pod.Name
+		return labels.Parse(name)
 	}
-
-	return pods.Items, podNames, nil
 }
 
 // LogsHandler handles pod log streaming via WebSocket
@@ -202,10 +199,194 @@ type LogLine struct {
 	Timestamp string `json:"timestamp,omitempty"`
 }
 
+// Control messages on the aggregated log stream, sent when the set of tailed pods changes
+const (
+	LogStreamPodAdded   = "pod_added"
+	LogStreamPodRemoved = "pod_removed"
+)
+
+// LogStreamEvent tells the client that a pod's logs started or stopped being tailed
+type LogStreamEvent struct {
+	Type      string `json:"type"`
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+	// pod_added: "restarted" when attaching again after the container restarted
+	// pod_removed: "deleted" or "ended" (its log stream closed)
+	Reason string `json:"reason,omitempty"`
+}
+
+// podChange is a matching pod that was added or updated, or with a nil pod, one that was
+// deleted or no longer matches the selector
+type podChange struct {
+	name string
+	uid  types.UID
+	pod  *corev1.Pod
+}
+
+// containerRun identifies one run of a container; it changes when the container restarts
+type containerRun struct {
+	restarts int32
+	id       string
+}
+
+// runOf returns the current run of a pod's container
+func runOf(pod *corev1.Pod, container string) containerRun {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return containerRun{restarts: status.RestartCount, id: status.ContainerID}
+		}
+	}
+	return containerRun{}
+}
+
+// tailedPod is a pod the aggregated stream attached to
+type tailedPod struct {
+	name      string
+	uid       types.UID
+	container string
+	attached  containerRun // the run being tailed
+	latest    containerRun // the run the pod last reported
+	cancel    context.CancelFunc
+	ended     bool // its log stream closed; attached to again once the container restarts
+}
+
+// podChangeHandler sends the changes of the pods in namespace that match selector.
+// Informer callbacks must never block, so send must not either.
+func podChangeHandler(namespace string, selector labels.Selector, send func(podChange)) cache.ResourceEventHandlerFuncs {
+	matching := func(obj interface{}) (*corev1.Pod, bool) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		pod, ok := obj.(*corev1.Pod)
+		return pod, ok && pod.Namespace == namespace && selector.Matches(labels.Set(pod.Labels))
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := matching(obj); ok {
+				send(podChange{name: pod.Name, uid: pod.UID, pod: pod})
+			}
+		},
+		UpdateFunc: func(oldObj, obj interface{}) {
+			if pod, ok := matching(obj); ok {
+				send(podChange{name: pod.Name, uid: pod.UID, pod: pod})
+			} else if old, ok := matching(oldObj); ok {
+				// Relabelled out of the selector
+				send(podChange{name: old.Name, uid: old.UID})
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if pod, ok := matching(obj); ok {
+				send(podChange{name: pod.Name, uid: pod.UID})
+			}
+		},
+	}
+}
+
+// podTracker attaches the aggregated stream to running pods and detaches it from deleted
+// ones. It is only used by the stream's loop; tails report on ended once their logs close.
+type podTracker struct {
+	ctx    context.Context
+	tail   func(ctx context.Context, podName, container string) // streams until the logs close or ctx ends
+	ended  chan *tailedPod
+	tailed map[string]*tailedPod
+}
+
+func newPodTracker(ctx context.Context, tail func(ctx context.Context, podName, container string)) *podTracker {
+	return &podTracker{
+		ctx:    ctx,
+		tail:   tail,
+		ended:  make(chan *tailedPod),
+		tailed: make(map[string]*tailedPod),
+	}
+}
+
+// names returns the tailed pods, sorted
+func (t *podTracker) names() []string {
+	names := make([]string, 0, len(t.tailed))
+	for name := range t.tailed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apply attaches to or detaches from the pod of a change, returning the control messages to send
+func (t *podTracker) apply(change podChange) []LogStreamEvent {
+	var events []LogStreamEvent
+	current := t.tailed[change.name]
+	if current != nil && (change.pod == nil || current.uid != change.uid) {
+		// Deleted, relabelled, or replaced by a pod of the same name
+		current.cancel()
+		delete(t.tailed, change.name)
+		if !current.ended {
+			events = append(events, LogStreamEvent{Type: LogStreamPodRemoved, Pod: change.name, Reason: "deleted"})
+		}
+		current = nil
+	}
+
+	pod := change.pod
+	if pod == nil || pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil || len(pod.Spec.Containers) == 0 {
+		return events
+	}
+	if current != nil {
+		// A restarted container is attached to again once the previous run's logs have closed
+		current.latest = runOf(pod, current.container)
+		if current.ended && current.latest != current.attached {
+			return append(events, t.attach(pod, "restarted"))
+		}
+		return events
+	}
+	return append(events, t.attach(pod, ""))
+}
+
+// end handles the log stream of a pod closing. If the container restarted meanwhile the
+// new run is attached to right away; otherwise the pod waits for its next restart.
+func (t *podTracker) end(ended *tailedPod) []LogStreamEvent {
+	if t.tailed[ended.name] != ended || ended.ended {
+		return nil
+	}
+	ended.ended = true
+	events := []LogStreamEvent{{Type: LogStreamPodRemoved, Pod: ended.name, Reason: "ended"}}
+	if ended.latest != ended.attached {
+		ended.cancel()
+		events = append(events, t.attachRun(ended.name, ended.uid, ended.container, ended.latest, "restarted"))
+	}
+	return events
+}
+
+// attach tails the first container of a running pod
+func (t *podTracker) attach(pod *corev1.Pod, reason string) LogStreamEvent {
+	container := pod.Spec.Containers[0].Name
+	return t.attachRun(pod.Name, pod.UID, container, runOf(pod, container), reason)
+}
+
+func (t *podTracker) attachRun(name string, uid types.UID, container string, run containerRun, reason string) LogStreamEvent {
+	if previous := t.tailed[name]; previous != nil {
+		previous.cancel()
+	}
+	podCtx, cancel := context.WithCancel(t.ctx)
+	tailed := &tailedPod{name: name, uid: uid, container: container, attached: run, latest: run, cancel: cancel}
+	t.tailed[name] = tailed
+	go func() {
+		t.tail(podCtx, name, container)
+		if podCtx.Err() != nil {
+			return
+		}
+		select {
+		case t.ended <- tailed:
+		case <-t.ctx.Done():
+		}
+	}()
+	return LogStreamEvent{Type: LogStreamPodAdded, Pod: name, Container: container, Reason: reason}
+}
+
 // StreamAggregatedLogs handles GET /api/v1/logs/stream
 // Supports two modes:
 // 1. Workload mode: ?type=deployment&name=myapp&namespace=default
 // 2. Selector mode (legacy): ?selector=app=frontend&namespace=default
+// The selector is watched, so the stream follows rollouts: pods are attached to once they
+// are Running (pod_added) and detached once deleted (pod_removed). A pod whose logs close
+// (pod_removed, "ended") is attached to again when its container restarts.
 func (h *LogsHandler) StreamAggregatedLogs(c *gin.Context) {
 	namespace := c.Query("namespace")
 	if namespace == "" {
@@ -249,50 +430,71 @@ func (h *LogsHandler) StreamAggregatedLogs(c *gin.Context) {
 		}
 	}()
 
-	// Resolve pods based on workload type or selector
-	var pods []corev1.Pod
-	var podNames []string
-
+	// Resolve the pod selector from the workload, or take it as given
+	var podSelector labels.Selector
 	if workloadType != "" && workloadName != "" {
-		// Use workload-based resolution (uses its own stable context internally)
-		pods, podNames, err = h.resolvePodsForWorkload(namespace, workloadName, workloadType)
+		// Uses its own stable context internally
+		podSelector, err = h.resolveWorkloadSelector(k8s.KubeContextFrom(c.Request.Context()), namespace, workloadName, workloadType)
 		if err != nil {
 			log.Printf("Failed to resolve pods for %s/%s: %v", workloadType, workloadName, err)
 			h.sendError(conn, "Failed to resolve pods: "+err.Error())
 			return
 		}
 	} else {
-		// Fallback to selector-based resolution
-		// Create a stable context for the K8s API call to avoid issues with WebSocket upgrade
-		listCtx, listCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer listCancel()
-
-		clientset, err := h.k8sService.GetClientset()
+		podSelector, err = labels.Parse(selector)
 		if err != nil {
-			h.sendError(conn, "Client not ready: "+err.Error())
+			h.sendError(conn, "Invalid selector: "+err.Error())
 			return
 		}
-		podList, err := clientset.CoreV1().Pods(namespace).List(listCtx, metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			log.Printf("Failed to list pods with selector %s: %v", selector, err)
-			h.sendError(conn, "Failed to list pods: "+err.Error())
-			return
-		}
-		pods = podList.Items
-		podNames = make([]string, len(pods))
-		for i, pod := range pods {
-			podNames[i] = pod.Name
+	}
+
+	// Watch the selector through the shared pod informer
+	rc, err := h.k8sService.CacheFor(ctx)
+	if err != nil {
+		h.sendError(conn, "Client not ready: "+err.Error())
+		return
+	}
+	release := rc.Acquire()
+	defer release()
+	informer, _ := rc.Informer(func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Pods().Informer()
+	})
+
+	// Informer callbacks must never block, so changes are buffered and a stream that
+	// falls too far behind is closed (the client reconnects)
+	changes := make(chan podChange, watchBufferSize)
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+	send := func(change podChange) {
+		select {
+		case changes <- change:
+		default:
+			overflowOnce.Do(func() { close(overflow) })
 		}
 	}
+	registration, err := informer.AddEventHandler(podChangeHandler(namespace, podSelector, send))
+	if err != nil {
+		h.sendError(conn, "Failed to watch pods: "+err.Error())
+		return
+	}
+	defer informer.RemoveEventHandler(registration)
 
-	log.Printf("Found %d pods", len(pods))
+	// Create a channel to aggregate all log lines
+	logChan := make(chan LogLine, 100)
+	tracker := newPodTracker(ctx, func(podCtx context.Context, podName, container string) {
+		h.streamPodLogs(podCtx, namespace, podName, container, logChan)
+	})
 
-	if len(pods) == 0 {
-		h.sendError(conn, "No pods found")
+	// The initial pods were buffered while the handler synced; they go in the init message
+	if !cache.WaitForCacheSync(ctx.Done(), registration.HasSynced) {
 		return
 	}
+	for n := len(changes); n > 0; n-- {
+		tracker.apply(<-changes)
+	}
+	podNames := tracker.names()
+
+	log.Printf("Found %d running pods", len(podNames))
 
 	// Send initial message about which pods we're tailing
 	initMsg := map[string]interface{}{
@@ -305,47 +507,37 @@ func (h *LogsHandler) StreamAggregatedLogs(c *gin.Context) {
 		return
 	}
 
-	// Create a channel to aggregate all log lines
-	logChan := make(chan LogLine, 100)
-	var wg sync.WaitGroup
-
-	// Launch a goroutine for each pod to stream its logs
-	for _, pod := range pods {
-		// Skip non-running pods
-		if pod.Status.Phase != corev1.PodRunning {
-			continue
-		}
-
-		// Get first container name
-		if len(pod.Spec.Containers) == 0 {
-			continue
-		}
-		containerName := pod.Spec.Containers[0].Name
+	ticker := time.NewTicker(watchPingInterval)
+	defer ticker.Stop()
 
-		wg.Add(1)
-		go func(podName, containerName string) {
-			defer wg.Done()
-			h.streamPodLogs(ctx, namespace, podName, containerName, logChan)
-		}(pod.Name, containerName)
-	}
-
-	// Close logChan when all goroutines complete
-	go func() {
-		wg.Wait()
-		close(logChan)
-	}()
-
-	// Fan-in: Read from the aggregated channel and send to WebSocket
+	// Fan-in: this loop is the only writer to the WebSocket
 	for {
+		var events []LogStreamEvent
 		select {
 		case <-ctx.Done():
 			return
-		case logLine, ok := <-logChan:
-			if !ok {
-				// Channel closed, all streams ended
+		case <-rc.StopCh():
+			h.sendError(conn, "pod watch was reset, reconnect to resume")
+			return
+		case <-overflow:
+			h.sendError(conn, "client fell too far behind, reconnect to resume")
+			return
+		case change := <-changes:
+			events = tracker.apply(change)
+		case ended := <-tracker.ended:
+			events = tracker.end(ended)
+		case logLine := <-logChan:
+			if err := conn.WriteJSON(logLine); err != nil {
+				log.Printf("Failed to write to WebSocket: %v", err)
 				return
 			}
-			if err := conn.WriteJSON(logLine); err != nil {
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+		for _, event := range events {
+			if err := conn.WriteJSON(event); err != nil {
 				log.Printf("Failed to write to WebSocket: %v", err)
 				return
 			}
@@ -395,11 +587,15 @@ func (h *LogsHandler) streamPodLogs(ctx context.Context, namespace, podName, con
 				}
 			}
 
-			logChan <- LogLine{
+			select {
+			case logChan <- LogLine{
 				Pod:       podName,
 				Container: containerName,
 				Message:   message,
 				Timestamp: timestamp,
+			}:
+			case <-ctx.Done():
+				return
 			}
 		}
 	}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeTails stands in for pod log streams; each runs until the test closes it
+type fakeTails struct {
+	mu    sync.Mutex
+	close map[string]chan struct{}
+}
+
+func (f *fakeTails) tail(ctx context.Context, podName, container string) {
+	done := make(chan struct{})
+	f.mu.Lock()
+	f.close[podName] = done
+	f.mu.Unlock()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// end closes the current log stream of a pod, as when its container exits
+func (f *fakeTails) end(t *testing.T, podName string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		done, ok := f.close[podName]
+		delete(f.close, podName)
+		f.mu.Unlock()
+		if ok {
+			close(done)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no log stream of %s to end", podName)
+}
+
+func TestAggregatedLogsFollowPods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientset := fake.NewClientset()
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	informer := factory.Core().V1().Pods().Informer()
+	changes := make(chan podChange, watchBufferSize)
+	selector := labels.SelectorFromSet(labels.Set{"app": "web"})
+	if _, err := informer.AddEventHandler(podChangeHandler("team-a", selector, func(change podChange) { changes <- change })); err != nil {
+		t.Fatal(err)
+	}
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		t.Fatal("pod informer did not sync")
+	}
+
+	tails := &fakeTails{close: make(map[string]chan struct{})}
+	tracker := newPodTracker(ctx, tails.tail)
+
+	// expect runs the stream loop until the wanted control messages were sent
+	expect := func(step string, want ...LogStreamEvent) {
+		t.Helper()
+		var got []LogStreamEvent
+		timeout := time.After(5 * time.Second)
+		for len(got) < len(want) {
+			select {
+			case change := <-changes:
+				got = append(got, tracker.apply(change)...)
+			case ended := <-tracker.ended:
+				got = append(got, tracker.end(ended)...)
+			case <-timeout:
+				t.Fatalf("%s: got %+v, want %+v", step, got, want)
+			}
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %+v, want %+v", step, got, want)
+			}
+		}
+	}
+	// quiet checks that nothing is sent for a change
+	quiet := func(step string) {
+		t.Helper()
+		select {
+		case change := <-changes:
+			if events := tracker.apply(change); len(events) != 0 {
+				t.Fatalf("%s: got %+v, want nothing", step, events)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no pod change", step)
+		}
+	}
+
+	pods := clientset.CoreV1().Pods("team-a")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "team-a", UID: types.UID("uid-1"), Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	update := func(change func(pod *corev1.Pod)) {
+		t.Helper()
+		change(pod)
+		var err error
+		if pod, err = pods.Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	running := func(restarts int32, id string) func(pod *corev1.Pod) {
+		return func(pod *corev1.Pod) {
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app", RestartCount: restarts, ContainerID: id}}
+		}
+	}
+
+	// Pending pods and pods of other workloads are not tailed
+	var err error
+	if pod, err = pods.Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	quiet("pending pod")
+	if _, err := pods.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "team-a", Labels: map[string]string{"app": "db"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	added := LogStreamEvent{Type: LogStreamPodAdded, Pod: "web-1", Container: "app"}
+	restarted := LogStreamEvent{Type: LogStreamPodAdded, Pod: "web-1", Container: "app", Reason: "restarted"}
+	ended := LogStreamEvent{Type: LogStreamPodRemoved, Pod: "web-1", Reason: "ended"}
+
+	update(running(0, "containerd://a"))
+	expect("running pod", added)
+
+	// A crashed container's logs close, and it is attached to again once it restarts
+	update(func(pod *corev1.Pod) {
+		pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+	})
+	tails.end(t, "web-1")
+	expect("log stream closed", ended)
+	update(running(1, "containerd://b"))
+	expect("container restarted", restarted)
+
+	// A restart seen before the old run's logs close is attached to once they do
+	update(running(2, "containerd://c"))
+	quiet("restart while still tailing")
+	tails.end(t, "web-1")
+	expect("previous run's logs closed", ended, restarted)
+
+	if names := tracker.names(); len(names) != 1 || names[0] != "web-1" {
+		t.Fatalf("tailed pods = %v, want only web-1", names)
+	}
+
+	// Deleted pods are detached
+	if err := pods.Delete(ctx, "web-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expect("pod deleted", LogStreamEvent{Type: LogStreamPodRemoved, Pod: "web-1", Reason: "deleted"})
+	if names := tracker.names(); len(names) != 0 {
+		t.Fatalf("tailed pods after delete = %v", names)
+	}
+}
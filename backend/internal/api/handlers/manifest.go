@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/access"
+	"github.com/waiyan/bridge/internal/api/middleware"
+	"github.com/waiyan/bridge/internal/notify"
+	"sigs.k8s.io/yaml"
+)
+
+// ImportAccessResponse reports what an import did, or would do with ?dryRun=true
+type ImportAccessResponse struct {
+	DryRun    bool                  `json:"dryRun"`
+	Results   []access.ImportResult `json:"results"`
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Skipped   int                   `json:"skipped"`
+	Failed    int                   `json:"failed"`
+	Submitted int                   `json:"submitted,omitempty"` // access requests made instead, when approval is required
+}
+
+// ExportAccess handles GET /api/v1/bridge/access/export
+// Renders grants as a YAML bundle for ImportAccess; ?namespace=, ?user= and ?template= narrow it down
+func (h *AccessHandler) ExportAccess(c *gin.Context) {
+	manifest, err := h.accessManager.Export(c.Request.Context(), access.ExportFilter{
+		Namespace: c.Query("namespace"),
+		User:      c.Query("user"),
+		Template:  c.Query("template"),
+	})
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   access.CodeExport,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="bridge-access.yaml"`)
+	c.Data(http.StatusOK, "application/yaml", data)
+}
+
+// ImportAccess handles POST /api/v1/bridge/access/import
+// Creates or reconciles the grants of a YAML or JSON bundle; ?dryRun=true only reports the changes.
+// When approval is required every grant that would change becomes an access request instead.
+func (h *AccessHandler) ImportAccess(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+	var manifest access.Manifest
+	if err := yaml.UnmarshalStrict(body, &manifest); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   access.CodeInvalidManifest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dryRun := c.Query("dryRun") == "true"
+	submit := h.approval.Required && !dryRun
+	results, err := h.accessManager.Import(ctx, &manifest, dryRun || submit)
+	if err != nil {
+		respondAccessError(c, err)
+		return
+	}
+
+	actor := middleware.GetIdentity(c).String()
+	if submit {
+		h.submitImport(c, &manifest, results, actor)
+		return
+	}
+
+	resp := ImportAccessResponse{DryRun: dryRun, Results: results}
+	for _, result := range results {
+		switch result.Action {
+		case access.ImportCreate:
+			resp.Created++
+			// Only new grants are announced; reconciling an existing one is not a new grant
+			if result.Grant != nil {
+				h.notifier.Notify(ctx, notify.GrantCreated(result.Grant, actor))
+			}
+		case access.ImportUpdate:
+			resp.Updated++
+		case access.ImportUnchanged:
+			resp.Unchanged++
+		case access.ImportSkip:
+			resp.Skipped++
+		case access.ImportError:
+			resp.Failed++
+		}
+	}
+
+	if !dryRun {
+		log.Printf("[Access] Import by %s: %d created, %d updated, %d unchanged, %d skipped, %d failed",
+			actor, resp.Created, resp.Updated, resp.Unchanged, resp.Skipped, resp.Failed)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// submitImport turns the grants an import would create or update into access requests.
+// Each request carries its declared grant, so approving it applies exactly what was imported.
+func (h *AccessHandler) submitImport(c *gin.Context, manifest *access.Manifest, results []access.ImportResult, actor string) {
+	ctx := c.Request.Context()
+	resp := ImportAccessResponse{Results: results}
+	for i := range results {
+		result := &results[i]
+		switch result.Action {
+		case access.ImportCreate, access.ImportUpdate:
+		case access.ImportUnchanged:
+			resp.Unchanged++
+			continue
+		case access.ImportSkip:
+			resp.Skipped++
+			continue
+		default:
+			resp.Failed++
+			continue
+		}
+
+		grant := manifest.Grants[i]
+		justification := grant.Justification
+		if justification == "" {
+			justification = "Imported from an access manifest"
+		}
+		submitted, err := h.accessManager.SubmitRequest(ctx, access.AccessRequest{
+			Spec: access.GrantSpec{
+				UserLabel:    grant.UserLabel,
+				Namespace:    grant.Namespace,
+				Namespaces:   grant.Namespaces,
+				ClusterScope: grant.ClusterScope,
+				Template:     grant.Template,
+				Rules:        grant.Rules,
+				Subject:      grant.Subject,
+				Login:        grant.Login,
+			},
+			GrantID:       grant.ID,
+			Reconcile:     true,
+			Import:        &grant,
+			Justification: justification,
+			Requester:     actor,
+		})
+		if err != nil {
+			result.Action, result.Message = access.ImportError, err.Error()
+			resp.Failed++
+			continue
+		}
+		result.RequestID = submitted.ID
+		resp.Submitted++
+	}
+
+	log.Printf("[Access] Import by %s submitted %d access requests: %d unchanged, %d skipped, %d failed",
+		actor, resp.Submitted, resp.Unchanged, resp.Skipped, resp.Failed)
+	c.JSON(http.StatusAccepted, resp)
+}
@@ -1,13 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/waiyan/bridge/internal/k8s"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // NamespaceHandler handles namespace related HTTP requests
@@ -30,16 +28,7 @@ type ListNamespacesResponse struct {
 
 // ListNamespaces handles GET /api/v1/namespaces
 func (h *NamespaceHandler) ListNamespaces(c *gin.Context) {
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	namespaces, err := clientset.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	namespaces, err := h.k8sService.CachedNamespaces(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -48,8 +37,8 @@ func (h *NamespaceHandler) ListNamespaces(c *gin.Context) {
 		return
 	}
 
-	names := make([]string, 0, len(namespaces.Items))
-	for _, ns := range namespaces.Items {
+	names := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
 		names = append(names, ns.Name)
 	}
 
@@ -86,16 +75,7 @@ type ResourceQuotasResponse struct {
 func (h *NamespaceHandler) GetResourceQuotas(c *gin.Context) {
 	namespace := c.Param("namespace")
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	quotas, err := clientset.CoreV1().ResourceQuotas(namespace).List(context.Background(), metav1.ListOptions{})
+	quotas, err := h.k8sService.CachedResourceQuotas(c.Request.Context(), namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -105,7 +85,7 @@ func (h *NamespaceHandler) GetResourceQuotas(c *gin.Context) {
 	}
 
 	var quotaInfos []ResourceQuotaInfo
-	for _, q := range quotas.Items {
+	for _, q := range quotas {
 		info := ResourceQuotaInfo{
 			Name:      q.Name,
 			Namespace: q.Namespace,
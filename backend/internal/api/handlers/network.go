@@ -1,14 +1,14 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/waiyan/bridge/internal/k8s"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 )
 
 // NetworkHandler handles network-related HTTP requests
@@ -84,16 +84,7 @@ func (h *NetworkHandler) ListServices(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	serviceList, err := clientset.CoreV1().Services(ns).List(context.Background(), metav1.ListOptions{})
+	serviceList, err := h.k8sService.CachedServices(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -102,27 +93,9 @@ func (h *NetworkHandler) ListServices(c *gin.Context) {
 		return
 	}
 
-	result := make([]ServiceInfo, 0, len(serviceList.Items))
-	for _, svc := range serviceList.Items {
-		ports := make([]string, 0, len(svc.Spec.Ports))
-		for _, port := range svc.Spec.Ports {
-			var portStr string
-			if port.NodePort != 0 {
-				portStr = fmt.Sprintf("%d:%d/%s", port.Port, port.NodePort, port.Protocol)
-			} else {
-				portStr = fmt.Sprintf("%d/%s", port.Port, port.Protocol)
-			}
-			ports = append(ports, portStr)
-		}
-
-		result = append(result, ServiceInfo{
-			Name:      svc.Name,
-			Namespace: svc.Namespace,
-			Type:      string(svc.Spec.Type),
-			ClusterIP: svc.Spec.ClusterIP,
-			Ports:     ports,
-			Age:       formatAge(svc.CreationTimestamp.Time),
-		})
+	result := make([]ServiceInfo, 0, len(serviceList))
+	for _, svc := range serviceList {
+		result = append(result, serviceToInfo(&svc))
 	}
 
 	displayNs := namespace
@@ -148,16 +121,7 @@ func (h *NetworkHandler) ListIngresses(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	ingressList, err := clientset.NetworkingV1().Ingresses(ns).List(context.Background(), metav1.ListOptions{})
+	ingressList, err := h.k8sService.CachedIngresses(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -166,40 +130,9 @@ func (h *NetworkHandler) ListIngresses(c *gin.Context) {
 		return
 	}
 
-	result := make([]IngressInfo, 0, len(ingressList.Items))
-	for _, ing := range ingressList.Items {
-		hosts := make([]string, 0)
-		for _, rule := range ing.Spec.Rules {
-			if rule.Host != "" {
-				hosts = append(hosts, rule.Host)
-			}
-		}
-
-		// Get load balancer address
-		address := ""
-		if len(ing.Status.LoadBalancer.Ingress) > 0 {
-			lb := ing.Status.LoadBalancer.Ingress[0]
-			if lb.IP != "" {
-				address = lb.IP
-			} else if lb.Hostname != "" {
-				address = lb.Hostname
-			}
-		}
-
-		// Get ingress class
-		ingressClass := ""
-		if ing.Spec.IngressClassName != nil {
-			ingressClass = *ing.Spec.IngressClassName
-		}
-
-		result = append(result, IngressInfo{
-			Name:      ing.Name,
-			Namespace: ing.Namespace,
-			Hosts:     hosts,
-			Address:   address,
-			Class:     ingressClass,
-			Age:       formatAge(ing.CreationTimestamp.Time),
-		})
+	result := make([]IngressInfo, 0, len(ingressList))
+	for _, ing := range ingressList {
+		result = append(result, ingressToInfo(&ing))
 	}
 
 	displayNs := namespace
@@ -214,6 +147,65 @@ func (h *NetworkHandler) ListIngresses(c *gin.Context) {
 	})
 }
 
+// serviceToInfo converts a Service to the simplified ServiceInfo
+func serviceToInfo(svc *corev1.Service) ServiceInfo {
+	ports := make([]string, 0, len(svc.Spec.Ports))
+	for _, port := range svc.Spec.Ports {
+		var portStr string
+		if port.NodePort != 0 {
+			portStr = fmt.Sprintf("%d:%d/%s", port.Port, port.NodePort, port.Protocol)
+		} else {
+			portStr = fmt.Sprintf("%d/%s", port.Port, port.Protocol)
+		}
+		ports = append(ports, portStr)
+	}
+
+	return ServiceInfo{
+		Name:      svc.Name,
+		Namespace: svc.Namespace,
+		Type:      string(svc.Spec.Type),
+		ClusterIP: svc.Spec.ClusterIP,
+		Ports:     ports,
+		Age:       formatAge(svc.CreationTimestamp.Time),
+	}
+}
+
+// ingressToInfo converts an Ingress to the simplified IngressInfo
+func ingressToInfo(ing *networkingv1.Ingress) IngressInfo {
+	hosts := make([]string, 0)
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+
+	// Get load balancer address
+	address := ""
+	if len(ing.Status.LoadBalancer.Ingress) > 0 {
+		lb := ing.Status.LoadBalancer.Ingress[0]
+		if lb.IP != "" {
+			address = lb.IP
+		} else if lb.Hostname != "" {
+			address = lb.Hostname
+		}
+	}
+
+	// Get ingress class
+	ingressClass := ""
+	if ing.Spec.IngressClassName != nil {
+		ingressClass = *ing.Spec.IngressClassName
+	}
+
+	return IngressInfo{
+		Name:      ing.Name,
+		Namespace: ing.Namespace,
+		Hosts:     hosts,
+		Address:   address,
+		Class:     ingressClass,
+		Age:       formatAge(ing.CreationTimestamp.Time),
+	}
+}
+
 // ListNetworkPolicies handles GET /api/v1/networkpolicies
 func (h *NetworkHandler) ListNetworkPolicies(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
@@ -225,16 +217,7 @@ func (h *NetworkHandler) ListNetworkPolicies(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	npList, err := clientset.NetworkingV1().NetworkPolicies(ns).List(context.Background(), metav1.ListOptions{})
+	npList, err := h.k8sService.CachedNetworkPolicies(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -243,8 +226,8 @@ func (h *NetworkHandler) ListNetworkPolicies(c *gin.Context) {
 		return
 	}
 
-	result := make([]NetworkPolicyInfo, 0, len(npList.Items))
-	for _, np := range npList.Items {
+	result := make([]NetworkPolicyInfo, 0, len(npList))
+	for _, np := range npList {
 		// Format pod selector
 		podSelector := ""
 		labels := np.Spec.PodSelector.MatchLabels
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/api/middleware"
+	"github.com/waiyan/bridge/internal/k8s"
+	"github.com/waiyan/bridge/internal/notify"
+)
+
+// NotificationHandler manages outbound webhook notifications
+type NotificationHandler struct {
+	notifier *notify.Notifier
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(k8sService *k8s.Service) *NotificationHandler {
+	return &NotificationHandler{
+		notifier: notify.NewNotifier(k8sService),
+	}
+}
+
+// TestNotificationRequest represents a test delivery. Without a URL the configured webhooks are tested.
+type TestNotificationRequest struct {
+	URL    string `json:"url"`
+	Format string `json:"format"` // "json" (default) or "slack"
+}
+
+// TestNotificationResponse reports the outcome of each test delivery
+type TestNotificationResponse struct {
+	Deliveries []notify.Delivery `json:"deliveries"`
+}
+
+// respondNotifyError maps notification errors to HTTP responses
+func respondNotifyError(c *gin.Context, err error) {
+	if errors.Is(err, notify.ErrInvalidConfig) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_CONFIG",
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   "NOTIFICATIONS_FAILED",
+		Message: err.Error(),
+	})
+}
+
+// GetConfig handles GET /api/v1/notifications/config
+// Webhook URLs are masked, since they usually carry credentials
+func (h *NotificationHandler) GetConfig(c *gin.Context) {
+	config, err := h.notifier.Config(c.Request.Context())
+	if err != nil {
+		respondNotifyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config.Masked())
+}
+
+// UpdateConfig handles PUT /api/v1/notifications/config
+// Replaces the cluster's webhooks and expiry lead times; webhooks sent back with their
+// masked URL keep the stored one
+func (h *NotificationHandler) UpdateConfig(c *gin.Context) {
+	var config notify.Config
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+	if config.Webhooks == nil {
+		config.Webhooks = []notify.Webhook{}
+	}
+	if config.LeadTimes == nil {
+		config.LeadTimes = notify.DefaultLeadTimes
+	}
+
+	ctx := c.Request.Context()
+	stored, err := h.notifier.Config(ctx)
+	if err != nil {
+		respondNotifyError(c, err)
+		return
+	}
+	if err := config.KeepMaskedURLs(stored); err != nil {
+		respondNotifyError(c, err)
+		return
+	}
+	if err := h.notifier.SaveConfig(ctx, &config); err != nil {
+		respondNotifyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config.Masked())
+}
+
+// TestNotification handles POST /api/v1/notifications/test
+// Sends a test event synchronously, with retries, and reports each delivery
+func (h *NotificationHandler) TestNotification(c *gin.Context) {
+	var req TestNotificationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_REQUEST",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	var webhooks []notify.Webhook
+	if req.URL != "" {
+		if notify.IsMasked(req.URL) {
+			respondNotifyError(c, fmt.Errorf("%w: the URL is masked; send the full URL, or none to test the configured webhooks", notify.ErrInvalidConfig))
+			return
+		}
+		webhooks = []notify.Webhook{{Name: "test", URL: req.URL, Format: req.Format}}
+		if err := (&notify.Config{Webhooks: webhooks}).Validate(); err != nil {
+			respondNotifyError(c, err)
+			return
+		}
+	} else {
+		config, err := h.notifier.Config(ctx)
+		if err != nil {
+			respondNotifyError(c, err)
+			return
+		}
+		webhooks = config.Webhooks
+	}
+
+	event := notify.TestEvent(middleware.GetIdentity(c).String())
+	deliveries := make([]notify.Delivery, 0, len(webhooks))
+	for _, w := range webhooks {
+		deliveries = append(deliveries, h.notifier.Deliver(ctx, w, event))
+	}
+
+	c.JSON(http.StatusOK, TestNotificationResponse{Deliveries: deliveries})
+}
@@ -1,13 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/waiyan/bridge/internal/k8s"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // RBACHandler handles RBAC-related HTTP requests
@@ -83,16 +81,7 @@ func (h *RBACHandler) ListServiceAccounts(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	saList, err := clientset.CoreV1().ServiceAccounts(ns).List(context.Background(), metav1.ListOptions{})
+	saList, err := h.k8sService.CachedServiceAccounts(c.Request.Context(), ns, nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -101,8 +90,8 @@ func (h *RBACHandler) ListServiceAccounts(c *gin.Context) {
 		return
 	}
 
-	result := make([]ServiceAccountInfo, 0, len(saList.Items))
-	for _, sa := range saList.Items {
+	result := make([]ServiceAccountInfo, 0, len(saList))
+	for _, sa := range saList {
 		result = append(result, ServiceAccountInfo{
 			Name:         sa.Name,
 			Namespace:    sa.Namespace,
@@ -134,16 +123,7 @@ func (h *RBACHandler) ListRoles(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	roleList, err := clientset.RbacV1().Roles(ns).List(context.Background(), metav1.ListOptions{})
+	roleList, err := h.k8sService.CachedRoles(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -152,8 +132,8 @@ func (h *RBACHandler) ListRoles(c *gin.Context) {
 		return
 	}
 
-	result := make([]RoleInfo, 0, len(roleList.Items))
-	for _, role := range roleList.Items {
+	result := make([]RoleInfo, 0, len(roleList))
+	for _, role := range roleList {
 		rules := make([]PolicyRule, 0, len(role.Rules))
 		for _, r := range role.Rules {
 			rules = append(rules, PolicyRule{
@@ -185,16 +165,7 @@ func (h *RBACHandler) ListRoles(c *gin.Context) {
 
 // ListClusterRoles handles GET /api/v1/clusterroles
 func (h *RBACHandler) ListClusterRoles(c *gin.Context) {
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	crList, err := clientset.RbacV1().ClusterRoles().List(context.Background(), metav1.ListOptions{})
+	crList, err := h.k8sService.CachedClusterRoles(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -203,8 +174,8 @@ func (h *RBACHandler) ListClusterRoles(c *gin.Context) {
 		return
 	}
 
-	result := make([]ClusterRoleInfo, 0, len(crList.Items))
-	for _, cr := range crList.Items {
+	result := make([]ClusterRoleInfo, 0, len(crList))
+	for _, cr := range crList {
 		rules := make([]PolicyRule, 0, len(cr.Rules))
 		for _, r := range cr.Rules {
 			rules = append(rules, PolicyRule{
@@ -270,16 +241,7 @@ func (h *RBACHandler) ListRoleBindings(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	rbList, err := clientset.RbacV1().RoleBindings(ns).List(context.Background(), metav1.ListOptions{})
+	rbList, err := h.k8sService.CachedRoleBindings(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -288,8 +250,8 @@ func (h *RBACHandler) ListRoleBindings(c *gin.Context) {
 		return
 	}
 
-	result := make([]RoleBindingInfo, 0, len(rbList.Items))
-	for _, rb := range rbList.Items {
+	result := make([]RoleBindingInfo, 0, len(rbList))
+	for _, rb := range rbList {
 		subjects := make([]string, 0, len(rb.Subjects))
 		for _, s := range rb.Subjects {
 			subjects = append(subjects, s.Kind+":"+s.Name)
@@ -319,16 +281,7 @@ func (h *RBACHandler) ListRoleBindings(c *gin.Context) {
 
 // ListClusterRoleBindings handles GET /api/v1/clusterrolebindings
 func (h *RBACHandler) ListClusterRoleBindings(c *gin.Context) {
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	crbList, err := clientset.RbacV1().ClusterRoleBindings().List(context.Background(), metav1.ListOptions{})
+	crbList, err := h.k8sService.CachedClusterRoleBindings(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -337,8 +290,8 @@ func (h *RBACHandler) ListClusterRoleBindings(c *gin.Context) {
 		return
 	}
 
-	result := make([]ClusterRoleBindingInfo, 0, len(crbList.Items))
-	for _, crb := range crbList.Items {
+	result := make([]ClusterRoleBindingInfo, 0, len(crbList))
+	for _, crb := range crbList {
 		subjects := make([]string, 0, len(crb.Subjects))
 		for _, s := range crb.Subjects {
 			subjects = append(subjects, s.Kind+":"+s.Name)
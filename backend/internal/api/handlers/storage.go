@@ -1,13 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/waiyan/bridge/internal/k8s"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // StorageHandler handles storage-related HTTP requests
@@ -87,16 +85,7 @@ func (h *StorageHandler) ListPVCs(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	pvcList, err := clientset.CoreV1().PersistentVolumeClaims(ns).List(context.Background(), metav1.ListOptions{})
+	pvcList, err := h.k8sService.CachedPersistentVolumeClaims(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -105,8 +94,8 @@ func (h *StorageHandler) ListPVCs(c *gin.Context) {
 		return
 	}
 
-	result := make([]PVCInfo, 0, len(pvcList.Items))
-	for _, pvc := range pvcList.Items {
+	result := make([]PVCInfo, 0, len(pvcList))
+	for _, pvc := range pvcList {
 		// Get capacity
 		capacity := ""
 		if pvc.Status.Capacity != nil {
@@ -170,16 +159,7 @@ func (h *StorageHandler) ListPVCs(c *gin.Context) {
 
 // ListPVs handles GET /api/v1/pvs
 func (h *StorageHandler) ListPVs(c *gin.Context) {
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	pvList, err := clientset.CoreV1().PersistentVolumes().List(context.Background(), metav1.ListOptions{})
+	pvList, err := h.k8sService.CachedPersistentVolumes(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -188,8 +168,8 @@ func (h *StorageHandler) ListPVs(c *gin.Context) {
 		return
 	}
 
-	result := make([]PVInfo, 0, len(pvList.Items))
-	for _, pv := range pvList.Items {
+	result := make([]PVInfo, 0, len(pvList))
+	for _, pv := range pvList {
 		// Get capacity
 		capacity := ""
 		if pv.Spec.Capacity != nil {
@@ -241,16 +221,7 @@ func (h *StorageHandler) ListPVs(c *gin.Context) {
 
 // ListStorageClasses handles GET /api/v1/storageclasses
 func (h *StorageHandler) ListStorageClasses(c *gin.Context) {
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	scList, err := clientset.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	scList, err := h.k8sService.CachedStorageClasses(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -259,8 +230,8 @@ func (h *StorageHandler) ListStorageClasses(c *gin.Context) {
 		return
 	}
 
-	result := make([]StorageClassInfo, 0, len(scList.Items))
-	for _, sc := range scList.Items {
+	result := make([]StorageClassInfo, 0, len(scList))
+	for _, sc := range scList {
 		// Get reclaim policy
 		reclaimPolicy := ""
 		if sc.ReclaimPolicy != nil {
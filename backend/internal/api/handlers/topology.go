@@ -63,7 +63,7 @@ func (h *TopologyHandler) GetTopology(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
@@ -81,9 +81,9 @@ func (h *TopologyHandler) GetTopology(c *gin.Context) {
 	deploymentPods := make(map[string]string)              // podName -> deploymentName (via OwnerRef)
 
 	// Fetch Ingresses
-	ingresses, err := clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	ingresses, err := h.k8sService.CachedIngresses(ctx, namespace)
 	if err == nil {
-		for _, ing := range ingresses.Items {
+		for _, ing := range ingresses {
 			nodeID := fmt.Sprintf("ingress-%s", ing.Name)
 			nodes = append(nodes, TopologyNode{
 				ID:       nodeID,
@@ -122,9 +122,9 @@ func (h *TopologyHandler) GetTopology(c *gin.Context) {
 	}
 
 	// Fetch Services
-	services, err := clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	services, err := h.k8sService.CachedServices(ctx, namespace)
 	if err == nil {
-		for _, svc := range services.Items {
+		for _, svc := range services {
 			nodeID := fmt.Sprintf("service-%s", svc.Name)
 			nodes = append(nodes, TopologyNode{
 				ID:       nodeID,
@@ -152,9 +152,9 @@ func (h *TopologyHandler) GetTopology(c *gin.Context) {
 	}
 
 	// Fetch Deployments
-	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	deployments, err := h.k8sService.CachedDeployments(ctx, namespace)
 	if err == nil {
-		for _, dep := range deployments.Items {
+		for _, dep := range deployments {
 			nodeID := fmt.Sprintf("deployment-%s", dep.Name)
 
 			ready := int32(0)
@@ -184,9 +184,9 @@ func (h *TopologyHandler) GetTopology(c *gin.Context) {
 	}
 
 	// Fetch Pods
-	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	pods, err := h.k8sService.CachedPods(ctx, namespace, nil)
 	if err == nil {
-		for _, pod := range pods.Items {
+		for _, pod := range pods {
 			nodeID := fmt.Sprintf("pod-%s", pod.Name)
 
 			status := string(pod.Status.Phase)
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/k8s"
 	"github.com/waiyan/bridge/internal/tunnel"
 )
 
@@ -46,6 +47,7 @@ func (h *TunnelHandler) CreateTunnel(c *gin.Context) {
 	}
 
 	tunnelReq := tunnel.CreateTunnelRequest{
+		Context:      k8s.KubeContextFrom(c.Request.Context()),
 		Namespace:    req.Namespace,
 		ResourceType: req.ResourceType,
 		ResourceName: req.ResourceName,
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/waiyan/bridge/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Watch event types. ADDED/MODIFIED/DELETED mirror the Kubernetes watch API;
+// SYNCED marks the end of the initial ADDED replay for a kind.
+const (
+	WatchEventAdded    = "ADDED"
+	WatchEventModified = "MODIFIED"
+	WatchEventDeleted  = "DELETED"
+	WatchEventSynced   = "SYNCED"
+	WatchEventError    = "ERROR"
+)
+
+// watchBufferSize is how many events are buffered for the writer
+const watchBufferSize = 1024
+
+// watchSendTimeout is how long events wait on a full buffer before the client is disconnected
+const watchSendTimeout = 10 * time.Second
+
+// watchPingInterval keeps idle streams alive through proxies
+const watchPingInterval = 30 * time.Second
+
+// WatchEvent is a single message on the watch stream
+type WatchEvent struct {
+	Type    string      `json:"type"`
+	Kind    string      `json:"kind,omitempty"`
+	Object  interface{} `json:"object,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+// watchKind describes how to watch one resource kind and convert it to its list shape
+type watchKind struct {
+	informer func(informers.SharedInformerFactory) cache.SharedIndexInformer
+	convert  func(obj interface{}) (interface{}, bool)
+}
+
+var watchKinds = map[string]watchKind{
+	"pods": {
+		informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().Pods().Informer()
+		},
+		convert: func(obj interface{}) (interface{}, bool) {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return nil, false
+			}
+			return k8s.PodToPodInfo(pod), true
+		},
+	},
+	"deployments": {
+		informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Apps().V1().Deployments().Informer()
+		},
+		convert: func(obj interface{}) (interface{}, bool) {
+			d, ok := obj.(*appsv1.Deployment)
+			if !ok {
+				return nil, false
+			}
+			return deploymentToInfo(d), true
+		},
+	},
+	"statefulsets": {
+		informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Apps().V1().StatefulSets().Informer()
+		},
+		convert: func(obj interface{}) (interface{}, bool) {
+			s, ok := obj.(*appsv1.StatefulSet)
+			if !ok {
+				return nil, false
+			}
+			return statefulSetToInfo(s), true
+		},
+	},
+	"daemonsets": {
+		informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Apps().V1().DaemonSets().Informer()
+		},
+		convert: func(obj interface{}) (interface{}, bool) {
+			ds, ok := obj.(*appsv1.DaemonSet)
+			if !ok {
+				return nil, false
+			}
+			return daemonSetToInfo(ds), true
+		},
+	},
+	"cronjobs": {
+		informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Batch().V1().CronJobs().Informer()
+		},
+		convert: func(obj interface{}) (interface{}, bool) {
+			cj, ok := obj.(*batchv1.CronJob)
+			if !ok {
+				return nil, false
+			}
+			return cronJobToInfo(cj), true
+		},
+	},
+	"services": {
+		informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().Services().Informer()
+		},
+		convert: func(obj interface{}) (interface{}, bool) {
+			svc, ok := obj.(*corev1.Service)
+			if !ok {
+				return nil, false
+			}
+			return serviceToInfo(svc), true
+		},
+	},
+	"ingresses": {
+		informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Networking().V1().Ingresses().Informer()
+		},
+		convert: func(obj interface{}) (interface{}, bool) {
+			ing, ok := obj.(*networkingv1.Ingress)
+			if !ok {
+				return nil, false
+			}
+			return ingressToInfo(ing), true
+		},
+	},
+}
+
+// WatchHandler streams live resource changes from the informer cache
+type WatchHandler struct {
+	k8sService *k8s.Service
+}
+
+// NewWatchHandler creates a new WatchHandler
+func NewWatchHandler(k8sService *k8s.Service) *WatchHandler {
+	return &WatchHandler{
+		k8sService: k8sService,
+	}
+}
+
+// parseWatchList splits a comma-separated query value, dropping blanks and duplicates
+func parseWatchList(value string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+	return result
+}
+
+// Watch handles GET /api/v1/watch?kinds=pods,deployments&namespaces=default,kube-system
+// Upgrades to WebSocket when requested, otherwise streams Server-Sent Events.
+// Each kind first replays its current objects as ADDED followed by SYNCED,
+// then sends ADDED/MODIFIED/DELETED as they happen. Omitting namespaces
+// (or passing "all") watches every namespace.
+func (h *WatchHandler) Watch(c *gin.Context) {
+	kinds := parseWatchList(c.Query("kinds"))
+	if len(kinds) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "INVALID_REQUEST",
+			Message: "at least one kind is required",
+		})
+		return
+	}
+	for _, kind := range kinds {
+		if _, ok := watchKinds[kind]; !ok {
+			supported := make([]string, 0, len(watchKinds))
+			for name := range watchKinds {
+				supported = append(supported, name)
+			}
+			sort.Strings(supported)
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "INVALID_KIND",
+				Message: fmt.Sprintf("unsupported kind '%s' (supported: %s)", kind, strings.Join(supported, ", ")),
+			})
+			return
+		}
+	}
+
+	namespaces := make(map[string]bool)
+	for _, ns := range parseWatchList(c.Query("namespaces")) {
+		if ns == "all" {
+			namespaces = nil
+			break
+		}
+		namespaces[ns] = true
+	}
+	if len(namespaces) == 0 {
+		namespaces = nil
+	}
+
+	rc, err := h.k8sService.CacheFor(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "CLIENT_NOT_READY",
+			Message: err.Error(),
+		})
+		return
+	}
+	release := rc.Acquire()
+	defer release()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// The stream is set up before any informer handler is registered, so the replay
+	// always has a writer to drain it. Events are produced by informer goroutines;
+	// the stream loop below is the only writer.
+	var write func(event WatchEvent) error
+	var ping func() error
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			log.Printf("[Watch] Failed to upgrade to WebSocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// Handle connection close from client
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+
+		write = func(event WatchEvent) error {
+			return conn.WriteJSON(event)
+		}
+		ping = func() error {
+			return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+		}
+	} else {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		write = func(event WatchEvent) error {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", data); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}
+		ping = func() error {
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}
+		ping()
+	}
+
+	// Events are buffered, and when the buffer is full a handler waits up to
+	// watchSendTimeout for the writer, which is already running, to make room. A large
+	// initial replay just waits its turn; a client that stops reading for that long is
+	// disconnected (it reconnects and re-syncs).
+	events := make(chan WatchEvent, watchBufferSize)
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+	send := func(event WatchEvent) {
+		select {
+		case events <- event:
+			return
+		default:
+		}
+		timer := time.NewTimer(watchSendTimeout)
+		defer timer.Stop()
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		case <-overflow:
+		case <-timer.C:
+			overflowOnce.Do(func() { close(overflow) })
+		}
+	}
+
+	for _, kind := range kinds {
+		wk := watchKinds[kind]
+		informer, _ := rc.Informer(wk.informer)
+
+		emit := func(eventType string, obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if namespaces != nil {
+				accessor, err := meta.Accessor(obj)
+				if err != nil || !namespaces[accessor.GetNamespace()] {
+					return
+				}
+			}
+			info, ok := wk.convert(obj)
+			if !ok {
+				return
+			}
+			send(WatchEvent{Type: eventType, Kind: kind, Object: info})
+		}
+
+		registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { emit(WatchEventAdded, obj) },
+			UpdateFunc: func(_, obj interface{}) { emit(WatchEventModified, obj) },
+			DeleteFunc: func(obj interface{}) { emit(WatchEventDeleted, obj) },
+		})
+		if err != nil {
+			write(WatchEvent{Type: WatchEventError, Message: err.Error()})
+			return
+		}
+		defer informer.RemoveEventHandler(registration)
+
+		go func() {
+			if cache.WaitForCacheSync(ctx.Done(), registration.HasSynced) {
+				send(WatchEvent{Type: WatchEventSynced, Kind: kind})
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(watchPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rc.StopCh():
+			// Context switched or client rebuilt: the client should reconnect
+			write(WatchEvent{Type: WatchEventError, Message: "watch cache was reset, reconnect to resume"})
+			return
+		case <-overflow:
+			write(WatchEvent{Type: WatchEventError, Message: "client fell too far behind, reconnect to resume"})
+			return
+		case event := <-events:
+			if err := write(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := ping(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -57,7 +57,7 @@ func (h *WorkloadActionsHandler) RestartWorkload(c *gin.Context) {
 	}
 
 	ctx := context.Background()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
@@ -148,7 +148,7 @@ func (h *WorkloadActionsHandler) ScaleWorkload(c *gin.Context) {
 	}
 
 	ctx := context.Background()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
@@ -228,7 +228,7 @@ func (h *WorkloadActionsHandler) SuspendCronJob(c *gin.Context) {
 	}
 
 	ctx := context.Background()
-	clientset, err := h.k8sService.GetClientset()
+	clientset, err := h.k8sService.ClientsetFor(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "CLIENT_NOT_READY",
@@ -1,14 +1,14 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/waiyan/bridge/internal/k8s"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 )
 
 // WorkloadHandler handles workload-related HTTP requests
@@ -101,67 +101,14 @@ type ListCronJobsResponse struct {
 func (h *WorkloadHandler) ListDeployments(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
 
-	var deployments *metav1.PartialObjectMetadataList
-	var err error
-
-	listOpts := metav1.ListOptions{}
-
+	var ns string
 	if namespace == "" || namespace == "all" {
-		clientset, err := h.k8sService.GetClientset()
-		if err != nil {
-			c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-				Error:   "CLIENT_NOT_READY",
-				Message: err.Error(),
-			})
-			return
-		}
-
-		deploymentList, listErr := clientset.AppsV1().Deployments("").List(context.Background(), listOpts)
-		if listErr != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "KUBERNETES_ERROR",
-				Message: listErr.Error(),
-			})
-			return
-		}
-
-		result := make([]DeploymentInfo, 0, len(deploymentList.Items))
-		for _, d := range deploymentList.Items {
-			images := make([]string, 0)
-			for _, container := range d.Spec.Template.Spec.Containers {
-				images = append(images, container.Image)
-			}
-
-			result = append(result, DeploymentInfo{
-				Name:         d.Name,
-				Namespace:    d.Namespace,
-				Replicas:     fmt.Sprintf("%d/%d", d.Status.ReadyReplicas, *d.Spec.Replicas),
-				ReadyCount:   d.Status.ReadyReplicas,
-				DesiredCount: *d.Spec.Replicas,
-				Images:       images,
-				Age:          formatAge(d.CreationTimestamp.Time),
-				Selector:     d.Spec.Selector.MatchLabels,
-			})
-		}
-
-		c.JSON(http.StatusOK, ListDeploymentsResponse{
-			Deployments: result,
-			Namespace:   "all",
-			Count:       len(result),
-		})
-		return
-	}
-
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
+		ns = ""
+	} else {
+		ns = namespace
 	}
 
-	deploymentList, err := clientset.AppsV1().Deployments(namespace).List(context.Background(), listOpts)
+	deploymentList, err := h.k8sService.CachedDeployments(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -170,35 +117,19 @@ func (h *WorkloadHandler) ListDeployments(c *gin.Context) {
 		return
 	}
 
-	_ = deployments // unused variable fix
-
-	result := make([]DeploymentInfo, 0, len(deploymentList.Items))
-	for _, d := range deploymentList.Items {
-		images := make([]string, 0)
-		for _, container := range d.Spec.Template.Spec.Containers {
-			images = append(images, container.Image)
-		}
-
-		replicas := int32(0)
-		if d.Spec.Replicas != nil {
-			replicas = *d.Spec.Replicas
-		}
-
-		result = append(result, DeploymentInfo{
-			Name:         d.Name,
-			Namespace:    d.Namespace,
-			Replicas:     fmt.Sprintf("%d/%d", d.Status.ReadyReplicas, replicas),
-			ReadyCount:   d.Status.ReadyReplicas,
-			DesiredCount: replicas,
-			Images:       images,
-			Age:          formatAge(d.CreationTimestamp.Time),
-			Selector:     d.Spec.Selector.MatchLabels,
-		})
+	result := make([]DeploymentInfo, 0, len(deploymentList))
+	for _, d := range deploymentList {
+		result = append(result, deploymentToInfo(&d))
+	}
+
+	displayNs := namespace
+	if ns == "" {
+		displayNs = "all"
 	}
 
 	c.JSON(http.StatusOK, ListDeploymentsResponse{
 		Deployments: result,
-		Namespace:   namespace,
+		Namespace:   displayNs,
 		Count:       len(result),
 	})
 }
@@ -207,7 +138,6 @@ func (h *WorkloadHandler) ListDeployments(c *gin.Context) {
 func (h *WorkloadHandler) ListStatefulSets(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
 
-	listOpts := metav1.ListOptions{}
 	var ns string
 	if namespace == "" || namespace == "all" {
 		ns = ""
@@ -215,16 +145,7 @@ func (h *WorkloadHandler) ListStatefulSets(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	statefulSetList, err := clientset.AppsV1().StatefulSets(ns).List(context.Background(), listOpts)
+	statefulSetList, err := h.k8sService.CachedStatefulSets(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -233,27 +154,9 @@ func (h *WorkloadHandler) ListStatefulSets(c *gin.Context) {
 		return
 	}
 
-	result := make([]StatefulSetInfo, 0, len(statefulSetList.Items))
-	for _, s := range statefulSetList.Items {
-		images := make([]string, 0)
-		for _, container := range s.Spec.Template.Spec.Containers {
-			images = append(images, container.Image)
-		}
-
-		replicas := int32(0)
-		if s.Spec.Replicas != nil {
-			replicas = *s.Spec.Replicas
-		}
-
-		result = append(result, StatefulSetInfo{
-			Name:         s.Name,
-			Namespace:    s.Namespace,
-			Replicas:     fmt.Sprintf("%d/%d", s.Status.ReadyReplicas, replicas),
-			ReadyCount:   s.Status.ReadyReplicas,
-			DesiredCount: replicas,
-			Images:       images,
-			Age:          formatAge(s.CreationTimestamp.Time),
-		})
+	result := make([]StatefulSetInfo, 0, len(statefulSetList))
+	for _, s := range statefulSetList {
+		result = append(result, statefulSetToInfo(&s))
 	}
 
 	displayNs := namespace
@@ -272,7 +175,6 @@ func (h *WorkloadHandler) ListStatefulSets(c *gin.Context) {
 func (h *WorkloadHandler) ListDaemonSets(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
 
-	listOpts := metav1.ListOptions{}
 	var ns string
 	if namespace == "" || namespace == "all" {
 		ns = ""
@@ -280,16 +182,7 @@ func (h *WorkloadHandler) ListDaemonSets(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	daemonSetList, err := clientset.AppsV1().DaemonSets(ns).List(context.Background(), listOpts)
+	daemonSetList, err := h.k8sService.CachedDaemonSets(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -298,23 +191,9 @@ func (h *WorkloadHandler) ListDaemonSets(c *gin.Context) {
 		return
 	}
 
-	result := make([]DaemonSetInfo, 0, len(daemonSetList.Items))
-	for _, ds := range daemonSetList.Items {
-		images := make([]string, 0)
-		for _, container := range ds.Spec.Template.Spec.Containers {
-			images = append(images, container.Image)
-		}
-
-		result = append(result, DaemonSetInfo{
-			Name:      ds.Name,
-			Namespace: ds.Namespace,
-			Desired:   ds.Status.DesiredNumberScheduled,
-			Current:   ds.Status.CurrentNumberScheduled,
-			Ready:     ds.Status.NumberReady,
-			Available: ds.Status.NumberAvailable,
-			Images:    images,
-			Age:       formatAge(ds.CreationTimestamp.Time),
-		})
+	result := make([]DaemonSetInfo, 0, len(daemonSetList))
+	for _, ds := range daemonSetList {
+		result = append(result, daemonSetToInfo(&ds))
 	}
 
 	displayNs := namespace
@@ -333,7 +212,6 @@ func (h *WorkloadHandler) ListDaemonSets(c *gin.Context) {
 func (h *WorkloadHandler) ListCronJobs(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
 
-	listOpts := metav1.ListOptions{}
 	var ns string
 	if namespace == "" || namespace == "all" {
 		ns = ""
@@ -341,16 +219,7 @@ func (h *WorkloadHandler) ListCronJobs(c *gin.Context) {
 		ns = namespace
 	}
 
-	clientset, err := h.k8sService.GetClientset()
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "CLIENT_NOT_READY",
-			Message: err.Error(),
-		})
-		return
-	}
-
-	cronJobList, err := clientset.BatchV1().CronJobs(ns).List(context.Background(), listOpts)
+	cronJobList, err := h.k8sService.CachedCronJobs(c.Request.Context(), ns)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "KUBERNETES_ERROR",
@@ -359,27 +228,9 @@ func (h *WorkloadHandler) ListCronJobs(c *gin.Context) {
 		return
 	}
 
-	result := make([]CronJobInfo, 0, len(cronJobList.Items))
-	for _, cj := range cronJobList.Items {
-		lastSchedule := "Never"
-		if cj.Status.LastScheduleTime != nil {
-			lastSchedule = formatAge(cj.Status.LastScheduleTime.Time) + " ago"
-		}
-
-		suspend := false
-		if cj.Spec.Suspend != nil {
-			suspend = *cj.Spec.Suspend
-		}
-
-		result = append(result, CronJobInfo{
-			Name:             cj.Name,
-			Namespace:        cj.Namespace,
-			Schedule:         cj.Spec.Schedule,
-			LastScheduleTime: lastSchedule,
-			Suspend:          suspend,
-			Active:           len(cj.Status.Active),
-			Age:              formatAge(cj.CreationTimestamp.Time),
-		})
+	result := make([]CronJobInfo, 0, len(cronJobList))
+	for _, cj := range cronJobList {
+		result = append(result, cronJobToInfo(&cj))
 	}
 
 	displayNs := namespace
@@ -394,6 +245,100 @@ func (h *WorkloadHandler) ListCronJobs(c *gin.Context) {
 	})
 }
 
+// deploymentToInfo converts a Deployment to the simplified DeploymentInfo
+func deploymentToInfo(d *appsv1.Deployment) DeploymentInfo {
+	images := make([]string, 0)
+	for _, container := range d.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+
+	replicas := int32(0)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	var selector map[string]string
+	if d.Spec.Selector != nil {
+		selector = d.Spec.Selector.MatchLabels
+	}
+
+	return DeploymentInfo{
+		Name:         d.Name,
+		Namespace:    d.Namespace,
+		Replicas:     fmt.Sprintf("%d/%d", d.Status.ReadyReplicas, replicas),
+		ReadyCount:   d.Status.ReadyReplicas,
+		DesiredCount: replicas,
+		Images:       images,
+		Age:          formatAge(d.CreationTimestamp.Time),
+		Selector:     selector,
+	}
+}
+
+// statefulSetToInfo converts a StatefulSet to the simplified StatefulSetInfo
+func statefulSetToInfo(s *appsv1.StatefulSet) StatefulSetInfo {
+	images := make([]string, 0)
+	for _, container := range s.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+
+	replicas := int32(0)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+
+	return StatefulSetInfo{
+		Name:         s.Name,
+		Namespace:    s.Namespace,
+		Replicas:     fmt.Sprintf("%d/%d", s.Status.ReadyReplicas, replicas),
+		ReadyCount:   s.Status.ReadyReplicas,
+		DesiredCount: replicas,
+		Images:       images,
+		Age:          formatAge(s.CreationTimestamp.Time),
+	}
+}
+
+// daemonSetToInfo converts a DaemonSet to the simplified DaemonSetInfo
+func daemonSetToInfo(ds *appsv1.DaemonSet) DaemonSetInfo {
+	images := make([]string, 0)
+	for _, container := range ds.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+
+	return DaemonSetInfo{
+		Name:      ds.Name,
+		Namespace: ds.Namespace,
+		Desired:   ds.Status.DesiredNumberScheduled,
+		Current:   ds.Status.CurrentNumberScheduled,
+		Ready:     ds.Status.NumberReady,
+		Available: ds.Status.NumberAvailable,
+		Images:    images,
+		Age:       formatAge(ds.CreationTimestamp.Time),
+	}
+}
+
+// cronJobToInfo converts a CronJob to the simplified CronJobInfo
+func cronJobToInfo(cj *batchv1.CronJob) CronJobInfo {
+	lastSchedule := "Never"
+	if cj.Status.LastScheduleTime != nil {
+		lastSchedule = formatAge(cj.Status.LastScheduleTime.Time) + " ago"
+	}
+
+	suspend := false
+	if cj.Spec.Suspend != nil {
+		suspend = *cj.Spec.Suspend
+	}
+
+	return CronJobInfo{
+		Name:             cj.Name,
+		Namespace:        cj.Namespace,
+		Schedule:         cj.Spec.Schedule,
+		LastScheduleTime: lastSchedule,
+		Suspend:          suspend,
+		Active:           len(cj.Status.Active),
+		Age:              formatAge(cj.CreationTimestamp.Time),
+	}
+}
+
 // formatAge formats a time duration as a human-readable string
 func formatAge(t time.Time) string {
 	duration := time.Since(t)
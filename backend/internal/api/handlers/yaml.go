@@ -16,25 +16,22 @@ import (
 
 // YAMLHandler handles YAML view/edit requests
 type YAMLHandler struct {
-	k8sService    *k8s.Service
-	dynamicClient dynamic.Interface
+	k8sService *k8s.Service
 }
 
 // NewYAMLHandler creates a new YAMLHandler
-func NewYAMLHandler(k8sService *k8s.Service) (*YAMLHandler, error) {
-	config, err := k8sService.GetConfig()
-	if err != nil {
-		return nil, err
-	}
+// Clients are created lazily on each request so the request's kube context is honored
+func NewYAMLHandler(k8sService *k8s.Service) *YAMLHandler {
+	return &YAMLHandler{k8sService: k8sService}
+}
 
-	dynamicClient, err := dynamic.NewForConfig(config)
+// getDynamicClient creates a dynamic client for the request's kube context
+func (h *YAMLHandler) getDynamicClient(ctx context.Context) (dynamic.Interface, error) {
+	config, err := h.k8sService.ConfigFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &YAMLHandler{
-		k8sService:    k8sService,
-		dynamicClient: dynamicClient,
-	}, nil
+	return dynamic.NewForConfig(config)
 }
 
 // GetYAMLResponse represents the YAML response
@@ -172,13 +169,21 @@ func (h *YAMLHandler) GetYAML(c *gin.Context) {
 		namespaced = isNamespaced(resourceType)
 	}
 
+	dynamicClient, err := h.getDynamicClient(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "CLIENT_NOT_READY",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	var resource *unstructured.Unstructured
-	var err error
 
 	if namespaced && namespace != "" && namespace != "_" {
-		resource, err = h.dynamicClient.Resource(gvr).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		resource, err = dynamicClient.Resource(gvr).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
 	} else {
-		resource, err = h.dynamicClient.Resource(gvr).Get(context.Background(), name, metav1.GetOptions{})
+		resource, err = dynamicClient.Resource(gvr).Get(context.Background(), name, metav1.GetOptions{})
 	}
 
 	if err != nil {
@@ -268,14 +273,22 @@ func (h *YAMLHandler) ApplyYAML(c *gin.Context) {
 		return
 	}
 
+	dynamicClient, err := h.getDynamicClient(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "CLIENT_NOT_READY",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Get current resource to get resourceVersion
 	var currentResource *unstructured.Unstructured
-	var err error
 
 	if namespaced && namespace != "" && namespace != "_" {
-		currentResource, err = h.dynamicClient.Resource(gvr).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		currentResource, err = dynamicClient.Resource(gvr).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
 	} else {
-		currentResource, err = h.dynamicClient.Resource(gvr).Get(context.Background(), name, metav1.GetOptions{})
+		currentResource, err = dynamicClient.Resource(gvr).Get(context.Background(), name, metav1.GetOptions{})
 	}
 
 	if err != nil {
@@ -303,9 +316,9 @@ func (h *YAMLHandler) ApplyYAML(c *gin.Context) {
 	// Update the resource
 	var updatedResource *unstructured.Unstructured
 	if namespaced && namespace != "" && namespace != "_" {
-		updatedResource, err = h.dynamicClient.Resource(gvr).Namespace(namespace).Update(context.Background(), resource, metav1.UpdateOptions{})
+		updatedResource, err = dynamicClient.Resource(gvr).Namespace(namespace).Update(context.Background(), resource, metav1.UpdateOptions{})
 	} else {
-		updatedResource, err = h.dynamicClient.Resource(gvr).Update(context.Background(), resource, metav1.UpdateOptions{})
+		updatedResource, err = dynamicClient.Resource(gvr).Update(context.Background(), resource, metav1.UpdateOptions{})
 	}
 
 	if err != nil {
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/audit"
+	"github.com/waiyan/bridge/internal/k8s"
+)
+
+// auditRoute names an audited endpoint. kindParam, when set, takes the target
+// kind from that route parameter instead of kind.
+type auditRoute struct {
+	verb      string
+	kind      string
+	kindParam string
+}
+
+// auditRoutes maps "METHOD /route" to a verb. Mutating requests not listed here are
+// still audited under "METHOD /route"; read-only requests are only audited when listed.
+var auditRoutes = map[string]auditRoute{
+	"GET /api/v1/secrets/:namespace/:name/reveal": {verb: "secret.reveal", kind: "Secret"},
+	"GET /api/v1/exec": {verb: "pod.exec", kind: "Pod"},
+	"GET /api/v1/bridge/access/:namespace/:name/kubeconfig":      {verb: "access.kubeconfig", kind: "ServiceAccount"},
+	"POST /api/v1/access/generate":                               {verb: "access.generate", kind: "ServiceAccount"},
+	"POST /api/v1/bridge/access":                                 {verb: "access.create", kind: "ServiceAccount"},
+	"GET /api/v1/bridge/access/export":                           {verb: "access.export", kind: "ServiceAccount"},
+	"POST /api/v1/bridge/access/import":                          {verb: "access.import", kind: "ServiceAccount"},
+	"DELETE /api/v1/bridge/access/:namespace/:name":              {verb: "access.revoke", kind: "ServiceAccount"},
+	"POST /api/v1/bridge/access/:namespace/:name/extend":         {verb: "access.extend", kind: "ServiceAccount"},
+	"POST /api/v1/bridge/access/:namespace/:name/rotate":         {verb: "access.rotate", kind: "ServiceAccount"},
+	"POST /api/v1/bridge/break-glass":                            {verb: "access.break-glass", kind: "ServiceAccount"},
+	"POST /api/v1/bridge/requests":                               {verb: "access.request.submit", kind: "AccessRequest"},
+	"POST /api/v1/bridge/requests/:id/approve":                   {verb: "access.request.approve", kind: "AccessRequest"},
+	"POST /api/v1/bridge/requests/:id/deny":                      {verb: "access.request.deny", kind: "AccessRequest"},
+	"POST /api/v1/bridge/templates":                              {verb: "access.template.create", kind: "AccessTemplate"},
+	"PUT /api/v1/bridge/templates/:name":                         {verb: "access.template.update", kind: "AccessTemplate"},
+	"DELETE /api/v1/bridge/templates/:name":                      {verb: "access.template.delete", kind: "AccessTemplate"},
+	"POST /api/v1/janitor/run":                                   {verb: "janitor.run"},
+	"PUT /api/v1/janitor/policies":                               {verb: "janitor.policies.update", kind: "ConfigMap"},
+	"GET /api/v1/notifications/config":                           {verb: "notifications.read", kind: "Secret"},
+	"PUT /api/v1/notifications/config":                           {verb: "notifications.update", kind: "Secret"},
+	"POST /api/v1/notifications/test":                            {verb: "notifications.test"},
+	"POST /api/v1/workloads/:kind/:namespace/:name/restart":      {verb: "workload.restart", kindParam: "kind"},
+	"POST /api/v1/workloads/:kind/:namespace/:name/scale":        {verb: "workload.scale", kindParam: "kind"},
+	"POST /api/v1/cronjobs/:namespace/:name/suspend":             {verb: "cronjob.suspend", kind: "CronJob"},
+	"PUT /api/v1/yaml/:resourceType/:namespace/:name":            {verb: "yaml.apply", kindParam: "resourceType"},
+	"POST /api/v1/contexts/switch":                               {verb: "context.switch"},
+	"POST /api/v1/tunnels":                                       {verb: "tunnel.create", kind: "Pod"},
+	"DELETE /api/v1/tunnels/:id":                                 {verb: "tunnel.delete"},
+	"POST /api/v1/aws/sso/eks-token":                             {verb: "aws.eks-token"},
+	"POST /api/v1/aws/sso/bridge/context-mapping":                {verb: "aws.context-mapping.set"},
+	"DELETE /api/v1/aws/sso/bridge/context-mapping/*contextName": {verb: "aws.context-mapping.delete"},
+}
+
+// Keys handlers set to add to their request's audit record
+const (
+	auditTargetKey = "bridge.audit.target"
+	auditReasonKey = "bridge.audit.reason"
+)
+
+// SetAuditTarget names the object a request acted on, for routes that don't name it
+func SetAuditTarget(c *gin.Context, namespace, name string) {
+	c.Set(auditTargetKey, audit.Target{Namespace: namespace, Name: name})
+}
+
+// SetAuditReason records why the actor made a request, e.g. a break-glass justification
+func SetAuditReason(c *gin.Context, reason string) {
+	c.Set(auditReasonKey, reason)
+}
+
+// maxAuditBody caps how much of a request body is hashed; longer bodies still reach the handler whole
+const maxAuditBody = 10 << 20
+
+// auditResponseWriter keeps the start of error responses so the record can carry the message
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < 4096 {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Audit middleware records mutating and sensitive requests (secret reveal, exec,
+// kubeconfig download, ...) to the audit log once the handler has finished.
+// It must run after Auth and KubeContext so the actor and context are known.
+func Audit(logger *audit.Logger, manager *k8s.ClientManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		key := c.Request.Method + " " + route
+		entry, listed := auditRoutes[key]
+		if !listed {
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions || route == "" {
+				c.Next()
+				return
+			}
+			entry = auditRoute{verb: key}
+		}
+
+		start := time.Now()
+
+		var bodyHash string
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBody))
+			if err == nil && len(body) > 0 {
+				sum := sha256.Sum256(body)
+				bodyHash = hex.EncodeToString(sum[:])
+			}
+			// Put back what was read in front of the rest of the body
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		kubeContext := k8s.KubeContextFrom(c.Request.Context())
+		if kubeContext == "" {
+			kubeContext = manager.GetCurrentContext()
+		}
+
+		identity := GetIdentity(c)
+		rec := audit.Record{
+			Time:       start,
+			Actor:      identity.String(),
+			Context:    kubeContext,
+			Verb:       entry.verb,
+			Target:     auditTarget(c, entry),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			BodySHA256: bodyHash,
+			Status:     writer.Status(),
+			Result:     audit.ResultSuccess,
+			Reason:     c.GetString(auditReasonKey),
+			RemoteAddr: c.ClientIP(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if identity != nil {
+			rec.AuthMethod = identity.Method
+		}
+		if rec.Status >= http.StatusBadRequest {
+			rec.Result = audit.ResultFailure
+			rec.Error = auditErrorMessage(writer.body.Bytes())
+		}
+
+		if err := logger.Write(rec); err != nil {
+			log.Printf("[Audit] Failed to write audit record for %s: %v", rec.Verb, err)
+		}
+	}
+}
+
+// auditTarget resolves the resource a request acts on from its route and query parameters
+func auditTarget(c *gin.Context, entry auditRoute) audit.Target {
+	target := audit.Target{
+		Kind:      entry.kind,
+		Namespace: c.Param("namespace"),
+		Name:      c.Param("name"),
+	}
+	if entry.kindParam != "" {
+		target.Kind = c.Param(entry.kindParam)
+	}
+	if value, ok := c.Get(auditTargetKey); ok {
+		if set, ok := value.(audit.Target); ok {
+			target.Namespace, target.Name = set.Namespace, set.Name
+		}
+	}
+	if target.Namespace == "" {
+		target.Namespace = c.Query("namespace")
+	}
+	if target.Name == "" {
+		target.Name = c.Query("pod")
+	}
+	if target.Name == "" {
+		target.Name = strings.TrimPrefix(c.Param("id")+c.Param("contextName"), "/")
+	}
+	return target
+}
+
+// auditErrorMessage extracts the message from an ErrorResponse-style JSON body
+func auditErrorMessage(body []byte) string {
+	var resp struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ""
+	}
+	if resp.Message != "" {
+		return resp.Message
+	}
+	return resp.Error
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/auth"
+)
+
+// Auth middleware rejects requests that don't carry a valid Bridge credential.
+// It runs before the WebSocket upgrade, so log streaming, exec and other
+// upgraded connections are covered by the same check.
+// The resolved identity is stored under auth.ContextKey for downstream handlers.
+func Auth(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := authenticator.Authenticate(c.Request)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "UNAUTHORIZED",
+				"message": "Missing or invalid Bridge credentials. Open the login URL printed at startup or sign in via /auth/oidc/login.",
+			})
+			return
+		}
+
+		c.Set(auth.ContextKey, identity)
+		c.Next()
+	}
+}
+
+// GetIdentity returns the identity stored by the Auth middleware, if any
+func GetIdentity(c *gin.Context) *auth.Identity {
+	value, exists := c.Get(auth.ContextKey)
+	if !exists {
+		return nil
+	}
+	identity, _ := value.(*auth.Identity)
+	return identity
+}
@@ -50,7 +50,8 @@ func ETag() gin.HandlerFunc {
 		path := c.Request.URL.Path
 		if strings.Contains(path, "/logs") ||
 			strings.Contains(path, "/exec") ||
-			strings.Contains(path, "/stream") {
+			strings.Contains(path, "/stream") ||
+			strings.HasSuffix(path, "/watch") {
 			c.Next()
 			return
 		}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/k8s"
+)
+
+// KubeContextHeader selects the kubeconfig context for a single request
+const KubeContextHeader = "X-Bridge-Context"
+
+// KubeContext middleware lets each request target its own kubeconfig context.
+// The context is read from the X-Bridge-Context header or, for WebSockets and
+// plain links that can't set headers, the "context" query parameter.
+// Requests without either use the manager's current context.
+func KubeContext(manager *k8s.ClientManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.GetHeader(KubeContextHeader)
+		if name == "" {
+			name = c.Query("context")
+		}
+
+		if name != "" {
+			if !manager.HasContext(name) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":   "UNKNOWN_CONTEXT",
+					"message": fmt.Sprintf("context '%s' not found in kubeconfig", name),
+				})
+				return
+			}
+			c.Request = c.Request.WithContext(k8s.WithKubeContext(c.Request.Context(), name))
+		}
+
+		c.Next()
+	}
+}
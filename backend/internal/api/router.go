@@ -1,17 +1,19 @@
 package api
 
 import (
-	"log"
-
 	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/access"
 	"github.com/waiyan/bridge/internal/api/handlers"
 	"github.com/waiyan/bridge/internal/api/middleware"
+	"github.com/waiyan/bridge/internal/audit"
+	"github.com/waiyan/bridge/internal/auth"
+	"github.com/waiyan/bridge/internal/janitor"
 	"github.com/waiyan/bridge/internal/k8s"
 	"github.com/waiyan/bridge/internal/tunnel"
 )
 
 // SetupRoutes configures all API routes
-func SetupRoutes(router *gin.Engine, k8sService *k8s.Service) {
+func SetupRoutes(router *gin.Engine, k8sService *k8s.Service, authenticator *auth.Authenticator, auditLogger *audit.Logger, accessJanitor *janitor.Janitor, approval access.ApprovalPolicy, execLoginCommands []string) {
 	// Create handlers
 	podHandler := handlers.NewPodHandler(k8sService)
 	logsHandler := handlers.NewLogsHandler(k8sService)
@@ -25,31 +27,51 @@ func SetupRoutes(router *gin.Engine, k8sService *k8s.Service) {
 	rbacHandler := handlers.NewRBACHandler(k8sService)
 	clusterHandler := handlers.NewClusterHandler(k8sService)
 	helmHandler := handlers.NewHelmHandler(k8sService.GetManager())
-	accessHandler := handlers.NewAccessHandler(k8sService)
+	accessHandler := handlers.NewAccessHandler(k8sService, approval, execLoginCommands)
 	contextHandler := handlers.NewContextHandler(k8sService)
 	topologyHandler := handlers.NewTopologyHandler(k8sService)
 	workloadActionsHandler := handlers.NewWorkloadActionsHandler(k8sService)
 	dashboardHandler := handlers.NewDashboardHandler(k8sService)
+	watchHandler := handlers.NewWatchHandler(k8sService)
 
 	// Create tunnel manager and handler (uses lazy client access)
 	tunnelManager := tunnel.NewManager(k8sService)
 	tunnelHandler := handlers.NewTunnelHandler(tunnelManager)
 
-	yamlHandler, err := handlers.NewYAMLHandler(k8sService)
-	if err != nil {
-		log.Printf("Warning: Failed to create YAML handler: %v", err)
-	}
+	yamlHandler := handlers.NewYAMLHandler(k8sService)
 
 	crdHandler := handlers.NewCRDHandler(k8sService)
 
 	awsHandler := handlers.NewAWSHandler(k8sService)
 	awsSSOHandler := handlers.NewAWSSSOHandler(k8sService)
+	authHandler := handlers.NewAuthHandler(authenticator)
+	auditHandler := handlers.NewAuditHandler(auditLogger)
+	janitorHandler := handlers.NewJanitorHandler(accessJanitor)
+	notificationHandler := handlers.NewNotificationHandler(k8sService)
+
+	// Login endpoints (unauthenticated - they establish the session)
+	authGroup := router.Group("/auth")
+	{
+		authGroup.GET("/login", authHandler.TokenLogin)
+		authGroup.GET("/oidc/login", authHandler.OIDCLogin)
+		authGroup.GET("/oidc/callback", authHandler.OIDCCallback)
+		authGroup.POST("/logout", authHandler.Logout)
+	}
 
-	// API v1 group with ETag middleware for caching
+	// API v1 group with auth, kube context, audit and ETag middleware
+	// Auth runs first so WebSocket upgrades are rejected before they happen
+	// KubeContext scopes the request to the context named in X-Bridge-Context / ?context=
+	// Audit records mutating and sensitive requests with the resolved actor and context
 	// The ETag middleware automatically skips WebSocket/streaming endpoints
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.Auth(authenticator))
+	v1.Use(middleware.KubeContext(k8sService.GetManager()))
+	v1.Use(middleware.Audit(auditLogger, k8sService.GetManager()))
 	v1.Use(middleware.ETag())
 	{
+		// Identity of the current caller
+		v1.GET("/auth/whoami", authHandler.WhoAmI)
+
 		// Context endpoints (cluster switching)
 		v1.GET("/contexts", contextHandler.ListContexts)
 		v1.GET("/contexts/current", contextHandler.GetCurrentContext)
@@ -67,6 +89,9 @@ func SetupRoutes(router *gin.Engine, k8sService *k8s.Service) {
 		v1.GET("/logs/stream", logsHandler.StreamAggregatedLogs)
 		v1.GET("/exec", execHandler.Exec)
 
+		// Live resource watch (WebSocket or SSE)
+		v1.GET("/watch", watchHandler.Watch)
+
 		// Node endpoints
 		v1.GET("/nodes", nodeHandler.ListNodes)
 
@@ -124,10 +149,8 @@ func SetupRoutes(router *gin.Engine, k8sService *k8s.Service) {
 		v1.GET("/secrets/:namespace/:name/reveal", configHandler.RevealSecret)
 
 		// YAML endpoints (generic resource editing)
-		if yamlHandler != nil {
-			v1.GET("/yaml/:resourceType/:namespace/:name", yamlHandler.GetYAML)
-			v1.PUT("/yaml/:resourceType/:namespace/:name", yamlHandler.ApplyYAML)
-		}
+		v1.GET("/yaml/:resourceType/:namespace/:name", yamlHandler.GetYAML)
+		v1.PUT("/yaml/:resourceType/:namespace/:name", yamlHandler.ApplyYAML)
 
 		// Legacy access endpoint (backward compatibility)
 		v1.POST("/access/generate", accessHandler.GenerateKubeconfig)
@@ -135,15 +158,52 @@ func SetupRoutes(router *gin.Engine, k8sService *k8s.Service) {
 		// Bridge access lifecycle endpoints
 		v1.POST("/bridge/access", accessHandler.CreateAccess)
 		v1.GET("/bridge/access", accessHandler.ListAccess)
+		v1.GET("/bridge/access/export", accessHandler.ExportAccess)
+		v1.POST("/bridge/access/import", accessHandler.ImportAccess)
 		v1.GET("/bridge/access/:namespace/:name/kubeconfig", accessHandler.GetKubeconfig)
+		v1.GET("/bridge/access/:namespace/:name/permissions", accessHandler.GetPermissions)
+		v1.POST("/bridge/access/:namespace/:name/extend", accessHandler.ExtendAccess)
+		v1.POST("/bridge/access/:namespace/:name/rotate", accessHandler.RotateAccess)
 		v1.DELETE("/bridge/access/:namespace/:name", accessHandler.RevokeAccess)
 
+		// Break-glass access (emergency admin, no approver, strictly limited)
+		v1.POST("/bridge/break-glass", accessHandler.BreakGlass)
+
+		// Access requests (submit, then an approver approves or denies)
+		v1.POST("/bridge/requests", accessHandler.SubmitRequest)
+		v1.GET("/bridge/requests", accessHandler.ListRequests)
+		v1.GET("/bridge/requests/:id", accessHandler.GetRequest)
+		v1.POST("/bridge/requests/:id/approve", accessHandler.ApproveRequest)
+		v1.POST("/bridge/requests/:id/deny", accessHandler.DenyRequest)
+
+		// Access templates (shared presets stored in the cluster)
+		v1.GET("/bridge/templates", accessHandler.ListTemplates)
+		v1.GET("/bridge/templates/:name", accessHandler.GetTemplate)
+		v1.POST("/bridge/templates", accessHandler.CreateTemplate)
+		v1.PUT("/bridge/templates/:name", accessHandler.UpdateTemplate)
+		v1.DELETE("/bridge/templates/:name", accessHandler.DeleteTemplate)
+
+		// Access janitor (sweep history, lease holders, manual trigger)
+		v1.GET("/janitor/status", janitorHandler.GetStatus)
+		v1.POST("/janitor/run", janitorHandler.RunNow)
+		v1.GET("/janitor/policies", janitorHandler.GetPolicies)
+		v1.PUT("/janitor/policies", janitorHandler.UpdatePolicies)
+		v1.POST("/janitor/policies/dry-run", janitorHandler.DryRunPolicies)
+
+		// Webhook notifications (expiring, cleaned, created and revoked grants)
+		v1.GET("/notifications/config", notificationHandler.GetConfig)
+		v1.PUT("/notifications/config", notificationHandler.UpdateConfig)
+		v1.POST("/notifications/test", notificationHandler.TestNotification)
+
 		// Topology endpoint
 		v1.GET("/bridge/topology", topologyHandler.GetTopology)
 
 		// Dashboard endpoints
 		v1.GET("/dashboard/stats", dashboardHandler.GetStats)
 
+		// Audit log
+		v1.GET("/audit", auditHandler.ListAudit)
+
 		// Resource Quotas
 		v1.GET("/resourcequotas/:namespace", namespaceHandler.GetResourceQuotas)
 
@@ -0,0 +1,322 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// activeFile is the file new records are appended to
+	activeFile = "audit.jsonl"
+
+	// DefaultMaxSize is the size at which the active file is rotated
+	DefaultMaxSize = 10 * 1024 * 1024
+
+	// DefaultMaxBackups is how many rotated files are kept
+	DefaultMaxBackups = 10
+)
+
+// Result values for Record.Result
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Target identifies the resource an action was performed on
+type Target struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Record is a single audited action
+type Record struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	AuthMethod string    `json:"authMethod,omitempty"`
+	Context    string    `json:"context,omitempty"`
+	Verb       string    `json:"verb"`
+	Target     Target    `json:"target"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	BodySHA256 string    `json:"bodySha256,omitempty"`
+	Status     int       `json:"status"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+	Reason     string    `json:"reason,omitempty"` // why the actor says they did it, e.g. a break-glass justification
+	RemoteAddr string    `json:"remoteAddr,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// Filter selects records in Query. Zero values match everything.
+type Filter struct {
+	Actor     string
+	Verb      string // exact verb, or a prefix ending in "." (e.g. "access.")
+	Context   string
+	Namespace string
+	Result    string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// matches reports whether rec passes the filter
+func (f Filter) matches(rec *Record) bool {
+	if f.Actor != "" && !strings.EqualFold(rec.Actor, f.Actor) {
+		return false
+	}
+	if f.Verb != "" {
+		if strings.HasSuffix(f.Verb, ".") {
+			if !strings.HasPrefix(rec.Verb, f.Verb) {
+				return false
+			}
+		} else if rec.Verb != f.Verb {
+			return false
+		}
+	}
+	if f.Context != "" && rec.Context != f.Context {
+		return false
+	}
+	if f.Namespace != "" && rec.Target.Namespace != f.Namespace {
+		return false
+	}
+	if f.Result != "" && rec.Result != f.Result {
+		return false
+	}
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && rec.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Logger appends audit records as JSON lines and rotates the file by size
+type Logger struct {
+	dir        string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// rotateMu is held for reading while Query reads the files, so a rotation
+	// can't rename or prune them in between; plain appends don't take it
+	rotateMu sync.RWMutex
+}
+
+// DefaultDir returns ~/.bridge/audit
+func DefaultDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".bridge", "audit")
+}
+
+// NewLogger creates a logger writing to dir with the default rotation settings
+func NewLogger(dir string) (*Logger, error) {
+	return NewLoggerWithRotation(dir, DefaultMaxSize, DefaultMaxBackups)
+}
+
+// NewLoggerWithRotation creates a logger that rotates after maxSize bytes and keeps maxBackups rotated files
+func NewLoggerWithRotation(dir string, maxSize int64, maxBackups int) (*Logger, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	l := &Logger{dir: dir, maxSize: maxSize, maxBackups: maxBackups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Dir returns the directory audit files are written to
+func (l *Logger) Dir() string {
+	return l.dir
+}
+
+// open opens (or creates) the active file for appending
+func (l *Logger) open() error {
+	file, err := os.OpenFile(filepath.Join(l.dir, activeFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// Write appends a record, rotating the active file first if it is full
+func (l *Logger) Write(rec Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Time = rec.Time.UTC()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+	if l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		l.rotateMu.Lock()
+		err := l.rotate()
+		l.rotateMu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	return err
+}
+
+// rotate renames the active file with a timestamp suffix and prunes old backups
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	// Backup names sort chronologically; bump the stamp on the rare collision
+	stamp := time.Now().UTC()
+	backup := backupPath(l.dir, stamp)
+	for fileExists(backup) {
+		stamp = stamp.Add(time.Nanosecond)
+		backup = backupPath(l.dir, stamp)
+	}
+	if err := os.Rename(filepath.Join(l.dir, activeFile), backup); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	backups, err := l.backups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > l.maxBackups {
+		os.Remove(filepath.Join(l.dir, backups[0]))
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// backupPath returns the rotated file name for a rotation at t
+func backupPath(dir string, t time.Time) string {
+	return filepath.Join(dir, "audit-"+t.Format("20060102T150405.000000000")+".jsonl")
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// backups lists rotated files, oldest first
+func (l *Logger) backups() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "audit-") && strings.HasSuffix(name, ".jsonl") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Query returns records matching the filter, newest first. Writes aren't held
+// up while the files are read; only a rotation waits until the query is done,
+// so no record moves out from under the file list.
+func (l *Logger) Query(filter Filter) ([]Record, error) {
+	l.rotateMu.RLock()
+	defer l.rotateMu.RUnlock()
+
+	backups, err := l.backups()
+	if err != nil {
+		return nil, err
+	}
+	files := append(backups, activeFile)
+
+	result := make([]Record, 0)
+	for i := len(files) - 1; i >= 0; i-- {
+		records, err := readFile(filepath.Join(l.dir, files[i]))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for j := len(records) - 1; j >= 0; j-- {
+			if !filter.matches(&records[j]) {
+				continue
+			}
+			result = append(result, records[j])
+			if filter.Limit > 0 && len(result) >= filter.Limit {
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
+
+// readFile parses a JSONL audit file, skipping lines that don't decode
+func readFile(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// Close closes the active file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
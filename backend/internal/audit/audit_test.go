@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoggerRotation(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewLoggerWithRotation(dir, 300, 2)
+	if err != nil {
+		t.Fatalf("NewLoggerWithRotation() error = %v", err)
+	}
+	defer logger.Close()
+
+	for i := 0; i < 20; i++ {
+		if err := logger.Write(Record{Actor: "alice", Verb: "secret.reveal", Result: ResultSuccess}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	backups, err := logger.backups()
+	if err != nil {
+		t.Fatalf("backups() error = %v", err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("kept %d rotated files, want 2", len(backups))
+	}
+
+	info, err := os.Stat(filepath.Join(dir, activeFile))
+	if err != nil {
+		t.Fatalf("active file missing: %v", err)
+	}
+	if info.Size() > 300 {
+		t.Errorf("active file size = %d, want <= 300", info.Size())
+	}
+}
+
+func TestLoggerQuery(t *testing.T) {
+	logger, err := NewLogger(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: base, Actor: "alice", Context: "prod", Verb: "secret.reveal", Target: Target{Namespace: "default"}, Result: ResultSuccess},
+		{Time: base.Add(time.Minute), Actor: "bob", Context: "staging", Verb: "access.create", Target: Target{Namespace: "team-a"}, Result: ResultFailure},
+		{Time: base.Add(2 * time.Minute), Actor: "alice", Context: "prod", Verb: "access.revoke", Target: Target{Namespace: "team-a"}, Result: ResultSuccess},
+	}
+	for _, rec := range records {
+		if err := logger.Write(rec); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filter    Filter
+		wantVerbs []string
+	}{
+		{name: "all newest first", filter: Filter{}, wantVerbs: []string{"access.revoke", "access.create", "secret.reveal"}},
+		{name: "actor", filter: Filter{Actor: "alice"}, wantVerbs: []string{"access.revoke", "secret.reveal"}},
+		{name: "verb prefix", filter: Filter{Verb: "access."}, wantVerbs: []string{"access.revoke", "access.create"}},
+		{name: "namespace and result", filter: Filter{Namespace: "team-a", Result: ResultFailure}, wantVerbs: []string{"access.create"}},
+		{name: "since", filter: Filter{Since: base.Add(30 * time.Second)}, wantVerbs: []string{"access.revoke", "access.create"}},
+		{name: "limit", filter: Filter{Context: "prod", Limit: 1}, wantVerbs: []string{"access.revoke"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := logger.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if len(got) != len(tt.wantVerbs) {
+				t.Fatalf("Query() returned %d records, want %d", len(got), len(tt.wantVerbs))
+			}
+			for i, rec := range got {
+				if rec.Verb != tt.wantVerbs[i] {
+					t.Errorf("record %d verb = %s, want %s", i, rec.Verb, tt.wantVerbs[i])
+				}
+			}
+		})
+	}
+}
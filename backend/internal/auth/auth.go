@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SessionCookieName is the cookie holding a Bridge browser session
+	SessionCookieName = "bridge_session"
+
+	// OIDCStateCookieName is the cookie binding a pending OIDC login to the browser that started it
+	OIDCStateCookieName = "bridge_oidc_state"
+
+	// OIDCStateTTL is how long a started OIDC login may take to call back
+	OIDCStateTTL = 10 * time.Minute
+
+	// ContextKey is the gin context key under which the authenticated Identity is stored
+	ContextKey = "bridge.identity"
+
+	// defaultSessionTTL is how long a browser session stays valid
+	defaultSessionTTL = 12 * time.Hour
+)
+
+// Mode selects how Bridge authenticates its own API
+type Mode string
+
+const (
+	// ModeToken requires the local bearer token (or an OIDC session if configured)
+	ModeToken Mode = "token"
+	// ModeNone disables authentication (only safe when bound to localhost)
+	ModeNone Mode = "none"
+)
+
+// Identity describes who made a request
+type Identity struct {
+	Subject string   `json:"subject"`
+	Email   string   `json:"email,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+	Method  string   `json:"method"` // "token", "oidc" or "none"
+}
+
+// String returns the most human-friendly name for the identity
+func (i *Identity) String() string {
+	if i == nil {
+		return "anonymous"
+	}
+	if i.Email != "" {
+		return i.Email
+	}
+	return i.Subject
+}
+
+// Config holds authenticator settings
+type Config struct {
+	Mode       Mode
+	SessionTTL time.Duration
+	OIDC       *OIDCConfig // nil disables OIDC login
+}
+
+type session struct {
+	identity  Identity
+	expiresAt time.Time
+}
+
+// Authenticator validates requests against the local token, browser sessions and OIDC
+type Authenticator struct {
+	mode       Mode
+	localToken string
+	sessionTTL time.Duration
+	oidc       *oidcProvider
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// New creates an Authenticator. A fresh local token is generated on every start.
+func New(cfg Config) (*Authenticator, error) {
+	if cfg.Mode == "" {
+		cfg.Mode = ModeToken
+	}
+	if cfg.Mode != ModeToken && cfg.Mode != ModeNone {
+		return nil, fmt.Errorf("unknown auth mode: %s", cfg.Mode)
+	}
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = defaultSessionTTL
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate local token: %w", err)
+	}
+
+	a := &Authenticator{
+		mode:       cfg.Mode,
+		localToken: token,
+		sessionTTL: cfg.SessionTTL,
+		sessions:   make(map[string]*session),
+	}
+
+	if cfg.OIDC != nil && cfg.Mode != ModeNone {
+		provider, err := newOIDCProvider(*cfg.OIDC)
+		if err != nil {
+			return nil, err
+		}
+		a.oidc = provider
+	}
+
+	return a, nil
+}
+
+// Enabled reports whether requests must be authenticated
+func (a *Authenticator) Enabled() bool {
+	return a.mode != ModeNone
+}
+
+// OIDCEnabled reports whether OIDC login is configured
+func (a *Authenticator) OIDCEnabled() bool {
+	return a.oidc != nil
+}
+
+// LocalToken returns the bearer token generated at startup
+func (a *Authenticator) LocalToken() string {
+	return a.localToken
+}
+
+// Authenticate resolves the identity behind a request.
+// Accepts "Authorization: Bearer <token>" or a session cookie. WebSocket upgrades from
+// the browser carry the session cookie automatically.
+func (a *Authenticator) Authenticate(r *http.Request) (*Identity, bool) {
+	if !a.Enabled() {
+		return &Identity{Subject: "anonymous", Method: string(ModeNone)}, true
+	}
+
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		if a.ValidToken(strings.TrimPrefix(header, "Bearer ")) {
+			return &Identity{Subject: "local", Method: string(ModeToken)}, true
+		}
+		return nil, false
+	}
+
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		return a.lookupSession(cookie.Value)
+	}
+
+	return nil, false
+}
+
+// ValidToken compares a candidate against the local token in constant time
+func (a *Authenticator) ValidToken(candidate string) bool {
+	return subtle.ConstantTimeCompare([]byte(candidate), []byte(a.localToken)) == 1
+}
+
+// NewSession creates a browser session for the identity and returns its ID
+func (a *Authenticator) NewSession(identity Identity) (string, error) {
+	id, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Drop expired sessions while we hold the lock
+	now := time.Now()
+	for key, s := range a.sessions {
+		if now.After(s.expiresAt) {
+			delete(a.sessions, key)
+		}
+	}
+
+	a.sessions[id] = &session{identity: identity, expiresAt: now.Add(a.sessionTTL)}
+	return id, nil
+}
+
+// EndSession removes a browser session
+func (a *Authenticator) EndSession(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, id)
+}
+
+func (a *Authenticator) lookupSession(id string) (*Identity, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s, ok := a.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(s.expiresAt) {
+		delete(a.sessions, id)
+		return nil, false
+	}
+	identity := s.identity
+	return &identity, true
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
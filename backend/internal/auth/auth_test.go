@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAuthenticate(t *testing.T) {
+	a, err := New(Config{Mode: ModeToken})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sessionID, err := a.NewSession(Identity{Subject: "alice", Email: "alice@example.com", Method: "oidc"})
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		cookie  string
+		wantOK  bool
+		wantSub string
+	}{
+		{name: "no credentials", wantOK: false},
+		{name: "valid bearer token", header: "Bearer " + a.LocalToken(), wantOK: true, wantSub: "local"},
+		{name: "invalid bearer token", header: "Bearer nope", wantOK: false},
+		{name: "valid session cookie", cookie: sessionID, wantOK: true, wantSub: "alice"},
+		{name: "unknown session cookie", cookie: "deadbeef", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/pods", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.cookie})
+			}
+
+			identity, ok := a.Authenticate(req)
+			if ok != tt.wantOK {
+				t.Fatalf("Authenticate() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && identity.Subject != tt.wantSub {
+				t.Errorf("Authenticate() subject = %s, want %s", identity.Subject, tt.wantSub)
+			}
+		})
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	a, err := New(Config{Mode: ModeToken, SessionTTL: time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sessionID, err := a.NewSession(Identity{Subject: "bob"})
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pods", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+	if _, ok := a.Authenticate(req); ok {
+		t.Error("Authenticate() accepted an expired session")
+	}
+}
+
+func TestModeNone(t *testing.T) {
+	a, err := New(Config{Mode: ModeNone})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pods", nil)
+	if _, ok := a.Authenticate(req); !ok {
+		t.Error("Authenticate() rejected a request with auth disabled")
+	}
+}
+
+func TestOIDCAllowed(t *testing.T) {
+	if _, err := newOIDCProvider(OIDCConfig{IssuerURL: "https://idp.example.com", ClientID: "bridge", RedirectURL: "http://localhost/cb"}); err == nil {
+		t.Fatal("newOIDCProvider() without an allowlist succeeded, want an error")
+	}
+
+	p := &oidcProvider{
+		allowedEmails:  lowerSet([]string{"Bob@Partner.io"}),
+		allowedDomains: lowerSet([]string{"example.com"}),
+		allowedGroups:  lowerSet([]string{"platform"}),
+	}
+	verified, unverified := true, false
+
+	tests := []struct {
+		name string
+		info oidcUserInfo
+		want bool
+	}{
+		{name: "allowed domain", info: oidcUserInfo{Email: "alice@example.com", EmailVerified: &verified}, want: true},
+		{name: "allowed email", info: oidcUserInfo{Email: "bob@partner.io", EmailVerified: &verified}, want: true},
+		{name: "allowed group", info: oidcUserInfo{Email: "carol@elsewhere.org", Groups: []string{"Platform"}}, want: true},
+		{name: "unlisted", info: oidcUserInfo{Email: "mallory@elsewhere.org"}},
+		{name: "lookalike domain", info: oidcUserInfo{Email: "mallory@notexample.com"}},
+		{name: "unverified email", info: oidcUserInfo{Email: "alice@example.com", EmailVerified: &unverified}},
+		{name: "verification not asserted", info: oidcUserInfo{Email: "alice@example.com"}},
+		{name: "no email", info: oidcUserInfo{Subject: "123"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.allowed(tt.info); got != tt.want {
+				t.Fatalf("allowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCStateBoundToBrowser(t *testing.T) {
+	p := &oidcProvider{
+		oauth:  &oauth2.Config{ClientID: "bridge", Endpoint: oauth2.Endpoint{AuthURL: "https://idp.example.com/auth"}},
+		states: make(map[string]pendingLogin),
+	}
+
+	url, state, err := p.loginURL()
+	if err != nil {
+		t.Fatalf("loginURL() error = %v", err)
+	}
+	if !strings.Contains(url, "code_challenge_method=S256") {
+		t.Fatalf("loginURL() = %q, want a PKCE challenge", url)
+	}
+
+	if _, ok := p.consumeState("", state); ok {
+		t.Fatal("consumeState() without a browser state succeeded")
+	}
+	if _, ok := p.consumeState("other", state); ok {
+		t.Fatal("consumeState() with another browser's state succeeded")
+	}
+	verifier, ok := p.consumeState(state, state)
+	if !ok || verifier == "" {
+		t.Fatalf("consumeState() = %q, %v, want the PKCE verifier", verifier, ok)
+	}
+	if _, ok := p.consumeState(state, state); ok {
+		t.Fatal("consumeState() accepted a state twice")
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig configures optional OIDC login for the Bridge UI
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // e.g. http://bridge.internal:8080/auth/oidc/callback
+	Scopes       []string // defaults to openid, email, profile
+
+	// Only identities matching one of these may sign in; at least one must be set
+	AllowedEmails  []string
+	AllowedDomains []string // email domains, e.g. example.com
+	AllowedGroups  []string // matched against the userinfo groups claim
+}
+
+// oidcDiscovery is the subset of the provider metadata Bridge needs
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// oidcUserInfo is the subset of the userinfo response Bridge uses for identity
+type oidcUserInfo struct {
+	Subject       string   `json:"sub"`
+	Email         string   `json:"email"`
+	EmailVerified *bool    `json:"email_verified"`
+	Name          string   `json:"name"`
+	Groups        []string `json:"groups"`
+}
+
+type oidcProvider struct {
+	oauth       *oauth2.Config
+	userinfoURL string
+
+	allowedEmails  map[string]bool
+	allowedDomains map[string]bool
+	allowedGroups  map[string]bool
+
+	mu     sync.Mutex
+	states map[string]pendingLogin // pending login state -> PKCE verifier and expiry
+}
+
+// pendingLogin is a started login waiting for its callback
+type pendingLogin struct {
+	verifier string
+	expiry   time.Time
+}
+
+// newOIDCProvider discovers the provider endpoints from the issuer
+func newOIDCProvider(cfg OIDCConfig) (*oidcProvider, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC requires issuer URL, client ID and redirect URL")
+	}
+	if len(cfg.AllowedEmails) == 0 && len(cfg.AllowedDomains) == 0 && len(cfg.AllowedGroups) == 0 {
+		return nil, fmt.Errorf("OIDC requires allowed emails, domains or groups; otherwise anyone the issuer knows could sign in")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	wellKnown := strings.TrimSuffix(cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery returned %s", resp.Status)
+	}
+
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("invalid OIDC discovery document: %w", err)
+	}
+	if discovery.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("OIDC provider does not expose a userinfo endpoint")
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &oidcProvider{
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  discovery.AuthorizationEndpoint,
+				TokenURL: discovery.TokenEndpoint,
+			},
+		},
+		userinfoURL:    discovery.UserinfoEndpoint,
+		allowedEmails:  lowerSet(cfg.AllowedEmails),
+		allowedDomains: lowerSet(cfg.AllowedDomains),
+		allowedGroups:  lowerSet(cfg.AllowedGroups),
+		states:         make(map[string]pendingLogin),
+	}, nil
+}
+
+// lowerSet builds a case-insensitive set, dropping blanks
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+// allowed reports whether the user may sign in. Emails only count when the
+// provider asserts they are verified.
+func (p *oidcProvider) allowed(info oidcUserInfo) bool {
+	if email := strings.ToLower(info.Email); email != "" && info.EmailVerified != nil && *info.EmailVerified {
+		if p.allowedEmails[email] {
+			return true
+		}
+		if at := strings.LastIndex(email, "@"); at >= 0 && p.allowedDomains[email[at+1:]] {
+			return true
+		}
+	}
+	for _, group := range info.Groups {
+		if p.allowedGroups[strings.ToLower(group)] {
+			return true
+		}
+	}
+	return false
+}
+
+// loginURL registers a new login state with a PKCE verifier and returns the
+// provider URL to redirect to, plus the state the browser must present on callback
+func (p *oidcProvider) loginURL() (string, string, error) {
+	state, err := randomHex(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for s, pending := range p.states {
+		if now.After(pending.expiry) {
+			delete(p.states, s)
+		}
+	}
+	p.states[state] = pendingLogin{verifier: verifier, expiry: now.Add(OIDCStateTTL)}
+
+	return p.oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), state, nil
+}
+
+// consumeState checks and removes a pending login state. The state returned by
+// the provider must match the one stored in the browser that started the login,
+// so a callback URL cannot be completed from another browser.
+// Returns the PKCE verifier for the code exchange.
+func (p *oidcProvider) consumeState(browserState, state string) (string, bool) {
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(browserState), []byte(state)) != 1 {
+		return "", false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending, ok := p.states[state]
+	delete(p.states, state)
+	if !ok || time.Now().After(pending.expiry) {
+		return "", false
+	}
+	return pending.verifier, true
+}
+
+// exchange trades the authorization code for tokens and resolves the user via userinfo
+func (p *oidcProvider) exchange(ctx context.Context, code, verifier string) (*Identity, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userinfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	token.SetAuthHeader(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("userinfo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo returned %s", resp.Status)
+	}
+
+	var info oidcUserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("invalid userinfo response: %w", err)
+	}
+	if info.Subject == "" {
+		return nil, fmt.Errorf("userinfo response has no subject")
+	}
+	if !p.allowed(info) {
+		return nil, fmt.Errorf("%s is not allowed to sign in to Bridge", firstNonEmpty(info.Email, info.Subject))
+	}
+
+	return &Identity{
+		Subject: info.Subject,
+		Email:   info.Email,
+		Name:    info.Name,
+		Groups:  info.Groups,
+		Method:  "oidc",
+	}, nil
+}
+
+// OIDCLoginURL starts an OIDC login and returns the provider URL to redirect to.
+// The returned state must be stored in the browser (see OIDCStateCookieName)
+// and passed back to CompleteOIDCLogin.
+func (a *Authenticator) OIDCLoginURL() (string, string, error) {
+	if a.oidc == nil {
+		return "", "", fmt.Errorf("OIDC login is not configured")
+	}
+	return a.oidc.loginURL()
+}
+
+// CompleteOIDCLogin validates the callback state against the one stored in the
+// browser, resolves the user and opens a session. Returns the new session ID.
+func (a *Authenticator) CompleteOIDCLogin(ctx context.Context, browserState, state, code string) (string, *Identity, error) {
+	if a.oidc == nil {
+		return "", nil, fmt.Errorf("OIDC login is not configured")
+	}
+	verifier, ok := a.oidc.consumeState(browserState, state)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid or expired login state")
+	}
+
+	identity, err := a.oidc.exchange(ctx, code, verifier)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sessionID, err := a.NewSession(*identity)
+	if err != nil {
+		return "", nil, err
+	}
+	return sessionID, identity, nil
+}
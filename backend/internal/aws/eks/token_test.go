@@ -51,7 +51,7 @@ func TestValidateToken(t *testing.T) {
 		},
 		{
 			name:    "valid token format",
-			token:   encodeToken("https://sts.us-east-1.amazonaws.com/?Action=GetCallerIdentity&Version=2011-06-15"),
+			token:   encodeToken("https://sts.us-east-1.amazonaws.com/?Action=GetCallerIdentity&Version=2011-06-15&X-Amz-SignedHeaders=host%3Bx-k8s-aws-id"),
 			wantErr: false,
 		},
 	}
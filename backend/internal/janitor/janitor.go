@@ -2,12 +2,19 @@ package janitor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/waiyan/bridge/internal/access"
 	"github.com/waiyan/bridge/internal/k8s"
+	"github.com/waiyan/bridge/internal/notify"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -19,59 +26,517 @@ const (
 	AnnotationExpiresAt = "bridge.io/expires-at"
 )
 
-// Janitor periodically cleans up expired Bridge access resources
+// contextTimeout bounds the sweep of a single context, so one unreachable cluster can't stall the rest
+const contextTimeout = 2 * time.Minute
+
+// maxRunHistory caps how many past sweeps are kept for the status API
+const maxRunHistory = 20
+
+// What started a sweep
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+)
+
+// ErrRunning is returned by RunNow while a sweep is already in progress
+var ErrRunning = errors.New("a janitor sweep is already running")
+
+// Janitor periodically cleans up expired Bridge access resources in every
+// configured kube context. Only the instance holding a cluster's janitor lease
+// cleans it, so several Bridge processes against the same cluster don't race.
 type Janitor struct {
-	k8sService *k8s.Service
-	interval   time.Duration
-	stopCh     chan struct{}
+	k8sService    *k8s.Service
+	accessManager *access.Manager
+	notifier      *notify.Notifier
+	interval      time.Duration
+	contexts      []string      // contexts to sweep; empty means the current context
+	tokenMaxAge   time.Duration // rotate permanent tokens older than this; 0 disables scheduled rotation
+	identity      string
+	stopCh        chan struct{}
+	stopOnce      sync.Once
+
+	sweepMu sync.Mutex // held for the duration of a sweep
+
+	mu      sync.Mutex
+	running bool
+	nextRun time.Time
+	runs    []Run // newest first
 }
 
-// New creates a new Janitor instance
-func New(k8sService *k8s.Service, interval time.Duration) *Janitor {
+// ContextResult is the outcome of sweeping one kube context
+type ContextResult struct {
+	Context         string         `json:"context"`
+	Server          string         `json:"server,omitempty"`
+	LastRun         time.Time      `json:"lastRun"`
+	Holder          string         `json:"holder,omitempty"` // lease holder when the sweep ran
+	Swept           bool           `json:"swept"`            // this instance held the lease and cleaned
+	Cleaned         int            `json:"cleaned"`
+	ExpiredRequests int            `json:"expiredRequests"`
+	Rotated         int            `json:"rotated"`            // permanent tokens rotated for age
+	RetiredTokens   int            `json:"retiredTokens"`      // replaced tokens deleted after their grace period
+	Deleted         int            `json:"deleted"`            // resources deleted by policies
+	Policies        []PolicyResult `json:"policies,omitempty"` // what each policy matched
+	Skipped         string         `json:"skipped,omitempty"`  // why the context was not swept
+	Error           string         `json:"error,omitempty"`    // why the context could not be swept
+	Errors          []string       `json:"errors,omitempty"`   // grants that could not be revoked or rotated
+}
+
+// Run is one sweep over every context
+type Run struct {
+	Trigger    string          `json:"trigger"`
+	StartedAt  time.Time       `json:"startedAt"`
+	FinishedAt time.Time       `json:"finishedAt"`
+	Cleaned    int             `json:"cleaned"`
+	Deleted    int             `json:"deleted"` // resources deleted by policies
+	Errors     int             `json:"errors"`  // failed contexts, grants and policy deletions
+	Contexts   []ContextResult `json:"contexts"`
+}
+
+// ContextStatus is a context's last sweep together with its live lease
+type ContextStatus struct {
+	ContextResult
+	Lease      LeaseStatus `json:"lease"`
+	LeaseError string      `json:"leaseError,omitempty"` // why the lease could not be read
+}
+
+// Status is the janitor's state as reported over the API
+type Status struct {
+	Identity string          `json:"identity"` // this instance's lease identity
+	Interval string          `json:"interval"`
+	Running  bool            `json:"running"`
+	LastRun  *time.Time      `json:"lastRun,omitempty"` // last sweep attempted by this instance
+	NextRun  *time.Time      `json:"nextRun,omitempty"`
+	Contexts []ContextStatus `json:"contexts"` // last sweep of each context with its live lease
+	Runs     []Run           `json:"runs"`     // recent sweeps, newest first
+}
+
+// New creates a new Janitor instance. With no contexts it sweeps the current context.
+func New(k8sService *k8s.Service, interval time.Duration, contexts []string) *Janitor {
 	return &Janitor{
-		k8sService: k8sService,
-		interval:   interval,
-		stopCh:     make(chan struct{}),
+		k8sService:    k8sService,
+		accessManager: access.NewManager(k8sService),
+		notifier:      notify.NewNotifier(k8sService),
+		interval:      interval,
+		contexts:      contexts,
+		identity:      newIdentity(),
+		stopCh:        make(chan struct{}),
 	}
 }
 
+// SetTokenRotation makes each sweep rotate the tokens of permanent grants older than maxAge.
+// Call it before Start; 0 disables scheduled rotation.
+func (j *Janitor) SetTokenRotation(maxAge time.Duration) {
+	j.tokenMaxAge = maxAge
+}
+
+// leaseDuration is how long a lease lasts without renewal; two missed sweeps hand it over
+func (j *Janitor) leaseDuration() time.Duration {
+	return 2 * j.interval
+}
+
 // Start begins the cleanup loop in a goroutine
 func (j *Janitor) Start() {
 	go j.run()
-	log.Printf("[Janitor] Started with cleanup interval of %s", j.interval)
+	scope := "the current context"
+	if len(j.contexts) > 0 {
+		scope = fmt.Sprintf("contexts %v", j.contexts)
+	}
+	log.Printf("[Janitor] Started with cleanup interval of %s for %s (lease identity %s)", j.interval, scope, j.identity)
 }
 
-// Stop signals the janitor to stop
+// Stop signals the janitor to stop and releases the leases it holds. Calls after the first do nothing.
 func (j *Janitor) Stop() {
+	j.stopOnce.Do(j.stop)
+}
+
+func (j *Janitor) stop() {
 	close(j.stopCh)
+
+	var held []string
+	for _, result := range j.lastResults() {
+		if result.Swept {
+			held = append(held, result.Context)
+		}
+	}
+
+	manager := j.k8sService.GetManager()
+	for _, name := range held {
+		clientset, err := manager.GetClientsetForContext(name)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := releaseLease(ctx, clientset, j.identity); err != nil {
+			log.Printf("[Janitor] [%s] Failed to release lease: %v", name, err)
+		}
+		cancel()
+	}
 	log.Println("[Janitor] Stopped")
 }
 
+// Status reports recent sweeps, the next scheduled one, and the janitor lease of every context
+func (j *Janitor) Status(ctx context.Context) *Status {
+	j.mu.Lock()
+	status := &Status{
+		Identity: j.identity,
+		Interval: j.interval.String(),
+		Running:  j.running,
+		Contexts: []ContextStatus{},
+		Runs:     append([]Run{}, j.runs...),
+	}
+	if !j.nextRun.IsZero() {
+		nextRun := j.nextRun
+		status.NextRun = &nextRun
+	}
+	j.mu.Unlock()
+
+	if len(status.Runs) > 0 {
+		lastRun := status.Runs[0].StartedAt
+		status.LastRun = &lastRun
+	}
+	for _, result := range j.lastResults() {
+		status.Contexts = append(status.Contexts, ContextStatus{ContextResult: result})
+	}
+
+	// Read the leases concurrently; unreachable clusters would otherwise add up
+	manager := j.k8sService.GetManager()
+	var wg sync.WaitGroup
+	for i := range status.Contexts {
+		cs := &status.Contexts[i]
+		if cs.Skipped != "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clientset, err := manager.GetClientsetForContext(cs.Context)
+			if err != nil {
+				cs.LeaseError = err.Error()
+				return
+			}
+			leaseCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			lease, err := readLease(leaseCtx, clientset, j.identity)
+			if err != nil {
+				cs.LeaseError = err.Error()
+				return
+			}
+			cs.Lease = lease
+		}()
+	}
+	wg.Wait()
+	return status
+}
+
+// RunNow sweeps every context immediately and returns the result.
+// It fails with ErrRunning if a sweep is already in progress.
+func (j *Janitor) RunNow() (*Run, error) {
+	return j.sweep(TriggerManual)
+}
+
+// lastResults returns the per-context results of the latest sweep
+func (j *Janitor) lastResults() []ContextResult {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.runs) == 0 {
+		return nil
+	}
+	return j.runs[0].Contexts
+}
+
 func (j *Janitor) run() {
 	ticker := time.NewTicker(j.interval)
 	defer ticker.Stop()
+	j.setNextRun(time.Now().Add(j.interval))
 
 	// Run cleanup immediately on start
-	j.cleanup()
+	j.scheduledSweep()
 
 	for {
 		select {
-		case <-ticker.C:
-			j.cleanup()
+		case tick := <-ticker.C:
+			j.setNextRun(tick.Add(j.interval))
+			j.scheduledSweep()
 		case <-j.stopCh:
 			return
 		}
 	}
 }
 
-func (j *Janitor) cleanup() {
-	ctx := context.Background()
-	clientset, err := j.k8sService.GetClientset()
+func (j *Janitor) setNextRun(t time.Time) {
+	j.mu.Lock()
+	j.nextRun = t
+	j.mu.Unlock()
+}
+
+func (j *Janitor) scheduledSweep() {
+	if _, err := j.sweep(TriggerScheduled); errors.Is(err, ErrRunning) {
+		log.Printf("[Janitor] Previous sweep still running, skipping scheduled cleanup")
+	}
+}
+
+// sweep runs one cleanup unless another is in progress and records it in the history
+func (j *Janitor) sweep(trigger string) (*Run, error) {
+	if !j.sweepMu.TryLock() {
+		return nil, ErrRunning
+	}
+	defer j.sweepMu.Unlock()
+
+	j.mu.Lock()
+	j.running = true
+	j.mu.Unlock()
+
+	run := j.cleanup(trigger)
+
+	j.mu.Lock()
+	j.running = false
+	j.runs = append([]Run{*run}, j.runs...)
+	if len(j.runs) > maxRunHistory {
+		j.runs = j.runs[:maxRunHistory]
+	}
+	j.mu.Unlock()
+	return run, nil
+}
+
+// targetContexts returns the contexts to sweep: the configured ones, or else the current context.
+// An empty name is the current context when there is no kubeconfig (e.g. running in-cluster).
+func (j *Janitor) targetContexts() []string {
+	if len(j.contexts) > 0 {
+		return j.contexts
+	}
+	return []string{j.k8sService.GetManager().GetCurrentContext()}
+}
+
+// sweepScope is what a sweep of one cluster covers
+type sweepScope struct {
+	grants    bool     // Bridge has granted access here: clean up grants and rotate tokens
+	policies  []Policy // the cluster's janitor policies, or DefaultPolicies
+	policyErr error    // why the stored policies could not be read
+}
+
+// empty reports whether there is nothing to sweep
+func (s sweepScope) empty() bool {
+	return !s.grants && len(s.policies) == 0 && s.policyErr == nil
+}
+
+// scopeFor decides what to sweep in a cluster. Grants are only cleaned where Bridge has
+// granted access; policies run wherever there are any, including the default TTL policy
+// for throwaway namespaces in clusters Bridge never granted access to.
+func scopeFor(ctx context.Context, clientset kubernetes.Interface) (sweepScope, error) {
+	managed, err := hasManagedServiceAccounts(ctx, clientset)
+	if err != nil {
+		return sweepScope{}, fmt.Errorf("error listing Bridge ServiceAccounts: %w", err)
+	}
+	policies, err := loadPolicies(ctx, clientset)
+	return sweepScope{grants: managed, policies: policies, policyErr: err}, nil
+}
+
+// hasManagedServiceAccounts reports whether Bridge has granted access in the cluster at all
+func hasManagedServiceAccounts(ctx context.Context, clientset kubernetes.Interface) (bool, error) {
+	sas, err := clientset.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{
+		LabelSelector: LabelManagedBy + "=" + ManagedByBridge,
+		Limit:         1,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(sas.Items) > 0, nil
+}
+
+// skippedContexts picks one context per API server, so a cluster reachable through
+// several contexts is swept once. The other contexts map to the reason they are skipped.
+func skippedContexts(names []string, serverOf func(string) string) map[string]string {
+	skipped := make(map[string]string)
+	seen := make(map[string]string)
+	for _, name := range names {
+		server := serverOf(name)
+		if server != "" {
+			if first, ok := seen[server]; ok {
+				skipped[name] = fmt.Sprintf("same cluster as context %s", first)
+				continue
+			}
+			seen[server] = name
+		}
+	}
+	return skipped
+}
+
+// cleanup sweeps every target context and returns the run
+func (j *Janitor) cleanup(trigger string) *Run {
+	names := j.targetContexts()
+	manager := j.k8sService.GetManager()
+	serverOf := func(name string) string {
+		_, _, server := manager.GetClusterInfoForContext(name)
+		return server
+	}
+	skipped := skippedContexts(names, serverOf)
+
+	previous := make(map[string]string)
+	for _, result := range j.lastResults() {
+		previous[result.Context] = result.Holder
+	}
+
+	// Each context is swept on its own; a failure in one doesn't stop the others
+	run := &Run{Trigger: trigger, StartedAt: time.Now()}
+	for _, name := range names {
+		result := ContextResult{Context: name, Server: serverOf(name), LastRun: time.Now()}
+		if reason, ok := skipped[name]; ok {
+			result.Skipped = reason
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), contextTimeout)
+			j.cleanupContext(ctx, &result, previous[name])
+			cancel()
+		}
+
+		if result.Error != "" {
+			log.Printf("[Janitor] [%s] %s", name, result.Error)
+			run.Errors++
+		}
+		run.Errors += len(result.Errors)
+		for _, p := range result.Policies {
+			run.Errors += len(p.Errors)
+		}
+		run.Cleaned += result.Cleaned
+		run.Deleted += result.Deleted
+		run.Contexts = append(run.Contexts, result)
+	}
+	run.FinishedAt = time.Now()
+	return run
+}
+
+// cleanupContext sweeps one context if this instance holds its lease
+func (j *Janitor) cleanupContext(ctx context.Context, result *ContextResult, previousHolder string) {
+	name := result.Context
+	clientset, err := j.k8sService.GetManager().GetClientsetForContext(name)
+	if err != nil {
+		result.Error = fmt.Sprintf("client not ready, skipping cleanup: %v", err)
+		return
+	}
+
+	scope, err := scopeFor(ctx, clientset)
+	if err != nil {
+		result.Error = fmt.Sprintf("%v, skipping cleanup", err)
+		return
+	}
+	if scope.empty() {
+		result.Skipped = "no Bridge-managed ServiceAccounts or janitor policies"
+		return
+	}
+
+	// Without the system namespace there is nowhere to keep the lease, and nothing shared to
+	// coordinate on; the cluster is still swept, unleased
+	leased := true
+	holder, err := acquireLease(ctx, clientset, j.identity, j.leaseDuration())
+	result.Holder = holder
+	switch {
+	case errors.Is(err, errNoSystemNamespace):
+		leased = false
+	case err != nil:
+		result.Error = fmt.Sprintf("error acquiring lease, skipping cleanup: %v", err)
+		return
+	case holder != j.identity:
+		if holder != "" && holder != previousHolder {
+			log.Printf("[Janitor] [%s] Lease held by %s, leaving cleanup to it", name, holder)
+		}
+		return
+	case previousHolder != j.identity:
+		log.Printf("[Janitor] [%s] Acquired lease %s/%s", name, access.SystemNamespace, LeaseName)
+	}
+	result.Swept = true
+
+	// The access manager resolves its client from the context carried by ctx
+	ctx = k8s.WithKubeContext(ctx, name)
+	if scope.grants {
+		cleaned, failures, err := j.cleanupGrants(ctx, clientset)
+		result.Cleaned = cleaned
+		result.Errors = failures
+		if err != nil {
+			result.Error = err.Error()
+			return
+		}
+		if cleaned > 0 {
+			log.Printf("[Janitor] [%s] Cleaned up %d expired access(es)", name, cleaned)
+		}
+
+		// Rotate old permanent tokens, and delete replaced ones once their grace period is over
+		j.rotateTokens(ctx, result)
+	}
+
+	// Expire access requests nobody decided on in time
+	expired, err := j.accessManager.ExpireRequests(ctx)
+	if err != nil {
+		log.Printf("[Janitor] [%s] Error expiring access requests: %v", name, err)
+	} else if expired > 0 {
+		log.Printf("[Janitor] [%s] Expired %d pending access request(s)", name, expired)
+	}
+	result.ExpiredRequests = expired
+
+	// Delete expired and stale resources as the cluster's policies say
+	j.cleanupPolicies(ctx, result, scope)
+
+	if !leased {
+		return
+	}
+	if err := recordRun(ctx, clientset, result.LastRun, result.Cleaned); err != nil {
+		log.Printf("[Janitor] [%s] Error recording run on lease: %v", name, err)
+	}
+}
+
+// rotateTokens runs scheduled token rotation and retires replaced tokens in the context carried by ctx
+func (j *Janitor) rotateTokens(ctx context.Context, result *ContextResult) {
+	if j.tokenMaxAge > 0 {
+		rotated, failures, err := j.accessManager.RotateDue(ctx, j.tokenMaxAge, "janitor")
+		if err != nil {
+			log.Printf("[Janitor] [%s] Error rotating tokens: %v", result.Context, err)
+			failures = append(failures, err.Error())
+		} else if rotated > 0 {
+			log.Printf("[Janitor] [%s] Rotated %d token(s) older than %s", result.Context, rotated, j.tokenMaxAge)
+		}
+		result.Rotated = rotated
+		result.Errors = append(result.Errors, failures...)
+	}
+
+	retired, err := j.accessManager.RetireTokens(ctx)
+	if err != nil {
+		log.Printf("[Janitor] [%s] Error retiring replaced tokens: %v", result.Context, err)
+		result.Errors = append(result.Errors, err.Error())
+	} else if retired > 0 {
+		log.Printf("[Janitor] [%s] Deleted %d replaced token(s)", result.Context, retired)
+	}
+	result.RetiredTokens = retired
+}
+
+// cleanupPolicies applies the policies in scope to the context carried by ctx
+func (j *Janitor) cleanupPolicies(ctx context.Context, result *ContextResult, scope sweepScope) {
+	if scope.policyErr != nil {
+		log.Printf("[Janitor] [%s] Skipping policies: %v", result.Context, scope.policyErr)
+		result.Policies = []PolicyResult{{Policy: policiesConfigMap, Errors: []string{scope.policyErr.Error()}}}
+		return
+	}
+	if len(scope.policies) == 0 {
+		return
+	}
+	clients, err := j.policyClientsFor(ctx)
 	if err != nil {
-		log.Printf("[Janitor] Client not ready, skipping cleanup: %v", err)
+		log.Printf("[Janitor] [%s] Skipping policies: %v", result.Context, err)
 		return
 	}
 
+	result.Policies = applyPolicies(ctx, clients, scope.policies, false, time.Now())
+	for _, p := range result.Policies {
+		result.Deleted += p.Deleted
+		if p.DryRun && p.Matched > 0 {
+			log.Printf("[Janitor] [%s] Policy %s would delete %d resource(s) (dry run)", result.Context, p.Policy, p.Matched)
+		}
+		for _, e := range p.Errors {
+			log.Printf("[Janitor] [%s] Policy %s: %s", result.Context, p.Policy, e)
+		}
+	}
+}
+
+// cleanupGrants revokes every expired grant in the cluster. It returns how many it
+// cleaned and why the others could not be revoked.
+func (j *Janitor) cleanupGrants(ctx context.Context, clientset kubernetes.Interface) (int, []string, error) {
 	// List all ServiceAccounts with Bridge label across all namespaces
 	labelSelector := LabelManagedBy + "=" + ManagedByBridge
 
@@ -79,86 +544,83 @@ func (j *Janitor) cleanup() {
 		LabelSelector: labelSelector,
 	})
 	if err != nil {
-		log.Printf("[Janitor] Error listing ServiceAccounts: %v", err)
-		return
+		return 0, nil, fmt.Errorf("error listing ServiceAccounts: %w", err)
+	}
+
+	// Expiry notices are only tracked when someone is listening
+	var leads []time.Duration
+	if config, err := j.notifier.Config(ctx); err != nil {
+		log.Printf("[Janitor] Not sending expiry notices: %v", err)
+	} else if len(config.Webhooks) > 0 {
+		leads = config.Leads()
 	}
 
 	now := time.Now()
 	cleanedUp := 0
+	var failures []string
 
 	for _, sa := range saList.Items {
+		// Break-glass grants are held to their maximum even if their expiry was removed or pushed back
+		breakGlass := sa.Labels[access.LabelBreakGlass] == "true"
+
 		// Check for expires-at annotation
-		if sa.Annotations == nil {
-			continue
-		}
-		expiresAtStr, ok := sa.Annotations[AnnotationExpiresAt]
-		if !ok || expiresAtStr == "" {
+		expiresAtStr := sa.Annotations[AnnotationExpiresAt]
+		if expiresAtStr == "" && !breakGlass {
 			continue // No expiration, skip
 		}
 
 		// Parse expiration time
 		expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
-		if err != nil {
+		if breakGlass {
+			expiresAt = access.BreakGlassDeadline(&sa)
+			expiresAtStr = expiresAt.Format(time.RFC3339)
+		} else if err != nil {
 			log.Printf("[Janitor] Invalid expires-at annotation for %s/%s: %v", sa.Namespace, sa.Name, err)
 			continue
 		}
 
 		// Check if expired
 		if expiresAt.After(now) {
+			j.noticeExpiry(ctx, clientset, &sa, leads, expiresAt, now)
 			continue // Not expired yet
 		}
 
 		// Expired! Clean up resources
-		log.Printf("[Janitor] Cleaning up expired access for %s (expired at %s)", sa.Name, expiresAtStr)
-		j.revokeAccess(ctx, sa.Namespace, sa.Name)
+		grant := access.FromServiceAccount(&sa)
+		log.Printf("[Janitor] Cleaning up expired %s-scoped grant %s/%s of %s (expired at %s)", grant.Scope(), grant.Namespace, grant.Name, grant.Username, expiresAtStr)
+		if err := j.revokeAccess(ctx, grant); err != nil {
+			failures = append(failures, fmt.Sprintf("%s/%s: %v", grant.Namespace, grant.Name, err))
+			continue
+		}
+		j.notifier.Notify(ctx, notify.GrantCleaned(grant))
 		cleanedUp++
 	}
 
-	if cleanedUp > 0 {
-		log.Printf("[Janitor] Cleaned up %d expired access(es)", cleanedUp)
-	}
+	return cleanedUp, failures, nil
 }
 
-func (j *Janitor) revokeAccess(ctx context.Context, namespace, saName string) {
-	clientset, err := j.k8sService.GetClientset()
-	if err != nil {
-		log.Printf("[Janitor] Client not ready, cannot revoke access: %v", err)
+// noticeExpiry sends the expiry notice due for a grant, if any. The notice is recorded
+// on the ServiceAccount first, so each lead time fires once across sweeps and instances.
+func (j *Janitor) noticeExpiry(ctx context.Context, clientset kubernetes.Interface, sa *corev1.ServiceAccount, leads []time.Duration, expiresAt, now time.Time) {
+	lead, record, ok := notify.DueNotice(leads, expiresAt, now, sa.Annotations[notify.AnnotationExpiryNotices])
+	if !ok {
 		return
 	}
-
-	// Derive resource names from SA name
-	baseName := strings.TrimSuffix(saName, "-sa")
-	roleName := baseName + "-role"
-	bindingName := baseName + "-binding"
-	secretName := baseName + "-token"
-
-	// Delete Secret (ignore not found errors)
-	if err := clientset.CoreV1().Secrets(namespace).Delete(ctx, secretName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			log.Printf("[Janitor] Error deleting Secret %s/%s: %v", namespace, secretName, err)
-		}
-	}
-
-	// Delete RoleBinding
-	if err := clientset.RbacV1().RoleBindings(namespace).Delete(ctx, bindingName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			log.Printf("[Janitor] Error deleting RoleBinding %s/%s: %v", namespace, bindingName, err)
-		}
-	}
-
-	// Delete Role
-	if err := clientset.RbacV1().Roles(namespace).Delete(ctx, roleName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			log.Printf("[Janitor] Error deleting Role %s/%s: %v", namespace, roleName, err)
-		}
+	patch := annotationPatch(map[string]string{notify.AnnotationExpiryNotices: record})
+	if _, err := clientset.CoreV1().ServiceAccounts(sa.Namespace).Patch(ctx, sa.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		log.Printf("[Janitor] Failed to record expiry notice for %s/%s: %v", sa.Namespace, sa.Name, err)
+		return
 	}
+	j.notifier.Notify(ctx, notify.GrantExpiring(access.FromServiceAccount(sa), lead))
+}
 
-	// Delete ServiceAccount
-	if err := clientset.CoreV1().ServiceAccounts(namespace).Delete(ctx, saName, metav1.DeleteOptions{}); err != nil {
-		if !strings.Contains(err.Error(), "not found") {
-			log.Printf("[Janitor] Error deleting ServiceAccount %s/%s: %v", namespace, saName, err)
-		}
+// revokeAccess deletes an expired grant, by ID, in every namespace it covers
+func (j *Janitor) revokeAccess(ctx context.Context, grant *access.Grant) error {
+	if err := j.accessManager.Revoke(ctx, grant.Namespace, grant.Name); err != nil {
+		log.Printf("[Janitor] Error cleaning up grant %s/%s: %v", grant.Namespace, grant.Name, err)
+		return err
 	}
 
-	log.Printf("[Janitor] Successfully cleaned up resources for %s/%s", namespace, saName)
+	log.Printf("[Janitor] Successfully cleaned up resources of grant %s/%s", grant.Namespace, grant.Name)
+	return nil
 }
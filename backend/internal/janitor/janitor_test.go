@@ -0,0 +1,132 @@
+package janitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/waiyan/bridge/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	fakemetadata "k8s.io/client-go/metadata/fake"
+)
+
+func TestSkippedContexts(t *testing.T) {
+	servers := map[string]string{
+		"prod":       "https://prod.example.com",
+		"prod-admin": "https://prod.example.com",
+		"staging":    "https://staging.example.com",
+	}
+	serverOf := func(name string) string { return servers[name] }
+
+	tests := []struct {
+		name     string
+		contexts []string
+		want     map[string]string // skipped context -> context swept instead
+	}{
+		{name: "distinct clusters", contexts: []string{"prod", "staging"}, want: map[string]string{}},
+		{name: "same cluster swept once", contexts: []string{"prod", "prod-admin", "staging"}, want: map[string]string{"prod-admin": "prod"}},
+		{name: "first context wins", contexts: []string{"prod-admin", "prod"}, want: map[string]string{"prod": "prod-admin"}},
+		{name: "unknown server is still swept", contexts: []string{"missing", "other-missing"}, want: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skipped := skippedContexts(tt.contexts, serverOf)
+			if len(skipped) != len(tt.want) {
+				t.Fatalf("skipped = %v, want %v", skipped, tt.want)
+			}
+			for name, first := range tt.want {
+				if want := "same cluster as context " + first; skipped[name] != want {
+					t.Errorf("skipped[%s] = %q, want %q", name, skipped[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunNowWhileRunning(t *testing.T) {
+	j := &Janitor{}
+	j.sweepMu.Lock()
+	defer j.sweepMu.Unlock()
+
+	if _, err := j.RunNow(); err != ErrRunning {
+		t.Fatalf("RunNow during a sweep: err = %v, want ErrRunning", err)
+	}
+}
+
+func TestStopTwice(t *testing.T) {
+	j := &Janitor{k8sService: k8s.NewService(nil), stopCh: make(chan struct{})}
+	j.Stop()
+	j.Stop() // must not close stopCh again
+	select {
+	case <-j.stopCh:
+	default:
+		t.Fatal("stopCh is still open after Stop")
+	}
+}
+
+func TestHasManagedServiceAccounts(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewClientset(&corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "team-a"},
+	})
+
+	if managed, err := hasManagedServiceAccounts(ctx, clientset); err != nil || managed {
+		t.Fatalf("cluster without Bridge grants: managed = %v, err = %v", managed, err)
+	}
+
+	if _, err := clientset.CoreV1().ServiceAccounts("team-a").Create(ctx, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alice-dev-sa",
+			Namespace: "team-a",
+			Labels:    map[string]string{LabelManagedBy: ManagedByBridge},
+		},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if managed, err := hasManagedServiceAccounts(ctx, clientset); err != nil || !managed {
+		t.Fatalf("cluster with a Bridge grant: managed = %v, err = %v", managed, err)
+	}
+}
+
+func TestSweepWithoutGrants(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	past := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	// A cluster Bridge never granted access to, used only for throwaway preview namespaces
+	clientset := fake.NewClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = testResources
+	scheme := fakemetadata.NewTestScheme()
+	metav1.AddMetaToScheme(scheme)
+	metadataClient := fakemetadata.NewSimpleMetadataClient(scheme, partial("v1", "Namespace", "", "preview-1", past))
+
+	scope, err := scopeFor(ctx, clientset)
+	if err != nil {
+		t.Fatalf("scopeFor() error = %v", err)
+	}
+	if scope.grants || scope.empty() {
+		t.Fatalf("scopeFor() = %+v, want policies but no grants", scope)
+	}
+
+	clients := policyClients{clientset: clientset, discovery: clientset.Discovery(), metadata: metadataClient}
+	results := applyPolicies(ctx, clients, scope.policies, false, now)
+	if len(results) != 1 || results[0].Deleted != 1 {
+		t.Fatalf("applyPolicies() = %+v, want preview-1 deleted", results)
+	}
+	if _, err := metadataClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Get(ctx, "preview-1", metav1.GetOptions{}); err == nil {
+		t.Fatal("preview-1 was not deleted")
+	}
+
+	// With no policies stored either, there is nothing to sweep
+	if err := savePolicies(ctx, clientset, []Policy{}); err != nil {
+		t.Fatal(err)
+	}
+	if scope, err := scopeFor(ctx, clientset); err != nil || !scope.empty() {
+		t.Fatalf("scopeFor() = %+v, %v, want nothing to sweep", scope, err)
+	}
+}
@@ -0,0 +1,203 @@
+package janitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/waiyan/bridge/internal/access"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LeaseName is the coordination.k8s.io Lease the janitors of a cluster compete for
+const LeaseName = "bridge-janitor"
+
+// Annotations the lease holder records after each sweep, so every instance can report it
+const (
+	AnnotationLastRun = "bridge.io/janitor-last-run"
+	AnnotationCleaned = "bridge.io/janitor-cleaned"
+)
+
+// errNoSystemNamespace is returned by acquireLease when the cluster has no namespace to keep
+// the lease in. The janitor never creates it; that is left to the people using Bridge.
+var errNoSystemNamespace = errors.New("namespace " + access.SystemNamespace + " does not exist")
+
+// LeaseStatus is the janitor lease of a cluster
+type LeaseStatus struct {
+	Holder    string     `json:"holder,omitempty"`
+	Held      bool       `json:"held"` // this instance holds the lease
+	RenewedAt *time.Time `json:"renewedAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	LastRun   *time.Time `json:"lastRun,omitempty"` // last sweep by whichever instance held the lease
+	Cleaned   int        `json:"cleaned"`           // grants cleaned by that sweep
+}
+
+// newIdentity returns a lease identity unique to this process
+func newIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "bridge"
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return hostname + "-" + strconv.Itoa(os.Getpid())
+	}
+	return hostname + "-" + hex.EncodeToString(b)
+}
+
+// acquireLease takes or renews the janitor lease for identity and returns the holder.
+// The lease is only taken over from another instance once it has expired, and only
+// created when the system namespace already exists.
+func acquireLease(ctx context.Context, clientset kubernetes.Interface, identity string, duration time.Duration) (string, error) {
+	leases := clientset.CoordinationV1().Leases(access.SystemNamespace)
+	now := metav1.NewMicroTime(time.Now())
+	seconds := int32(duration.Seconds())
+
+	lease, err := leases.Get(ctx, LeaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := clientset.CoreV1().Namespaces().Get(ctx, access.SystemNamespace, metav1.GetOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", errNoSystemNamespace
+			}
+			return "", err
+		}
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      LeaseName,
+				Namespace: access.SystemNamespace,
+				Labels:    map[string]string{access.LabelManagedBy: access.ManagedByBridge},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// Another instance created it first; try again next sweep
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return identity, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	holder := ""
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	if holder != "" && holder != identity && !leaseExpired(lease, now.Time) {
+		return holder, nil
+	}
+
+	if holder != identity {
+		transitions := int32(1)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions + 1
+		}
+		lease.Spec.AcquireTime = &now
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.HolderIdentity = &identity
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &now
+
+	// The update carries the resourceVersion we read, so of two instances taking over only one wins
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsConflict(err) {
+			return holder, nil
+		}
+		return "", err
+	}
+	return identity, nil
+}
+
+// leaseExpired reports whether the holder failed to renew the lease in time
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return now.After(lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+// recordRun stores the time and result of a sweep on the lease
+func recordRun(ctx context.Context, clientset kubernetes.Interface, at time.Time, cleaned int) error {
+	patch := annotationPatch(map[string]string{
+		AnnotationLastRun: at.UTC().Format(time.RFC3339),
+		AnnotationCleaned: strconv.Itoa(cleaned),
+	})
+	_, err := clientset.CoordinationV1().Leases(access.SystemNamespace).Patch(ctx, LeaseName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// annotationPatch builds a merge patch setting annotations
+func annotationPatch(annotations map[string]string) []byte {
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	return patch
+}
+
+// releaseLease gives up the lease if identity holds it, so another instance can take over right away
+func releaseLease(ctx context.Context, clientset kubernetes.Interface, identity string) error {
+	leases := clientset.CoordinationV1().Leases(access.SystemNamespace)
+	lease, err := leases.Get(ctx, LeaseName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity {
+		return nil
+	}
+	lease.Spec.HolderIdentity = nil
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// readLease returns the janitor lease of a cluster as seen by identity
+func readLease(ctx context.Context, clientset kubernetes.Interface, identity string) (LeaseStatus, error) {
+	var status LeaseStatus
+	lease, err := clientset.CoordinationV1().Leases(access.SystemNamespace).Get(ctx, LeaseName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return status, nil
+		}
+		return status, err
+	}
+
+	if lease.Spec.HolderIdentity != nil && !leaseExpired(lease, time.Now()) {
+		status.Holder = *lease.Spec.HolderIdentity
+		status.Held = status.Holder == identity
+	}
+	if lease.Spec.RenewTime != nil {
+		renewed := lease.Spec.RenewTime.Time
+		status.RenewedAt = &renewed
+		if lease.Spec.LeaseDurationSeconds != nil {
+			expires := renewed.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+			status.ExpiresAt = &expires
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, lease.Annotations[AnnotationLastRun]); err == nil {
+		status.LastRun = &t
+	}
+	status.Cleaned, _ = strconv.Atoi(lease.Annotations[AnnotationCleaned])
+	return status, nil
+}
@@ -0,0 +1,83 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/waiyan/bridge/internal/access"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewClientset()
+
+	// The janitor never creates the system namespace for its lease
+	if _, err := acquireLease(ctx, clientset, "a", 10*time.Minute); !errors.Is(err, errNoSystemNamespace) {
+		t.Fatalf("without the system namespace: error = %v, want %v", err, errNoSystemNamespace)
+	}
+	if namespaces, _ := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); len(namespaces.Items) != 0 {
+		t.Fatalf("acquireLease created %d namespaces", len(namespaces.Items))
+	}
+	if _, err := clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: access.SystemNamespace}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name     string
+		identity string
+		expire   bool // backdate the lease past its duration first
+		want     string
+	}{
+		{name: "first instance creates the lease", identity: "a", want: "a"},
+		{name: "holder renews", identity: "a", want: "a"},
+		{name: "other instance waits", identity: "b", want: "a"},
+		{name: "expired lease is taken over", identity: "b", expire: true, want: "b"},
+		{name: "previous holder now waits", identity: "a", want: "b"},
+	}
+
+	for _, step := range steps {
+		if step.expire {
+			leases := clientset.CoordinationV1().Leases(access.SystemNamespace)
+			lease, err := leases.Get(ctx, LeaseName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			past := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+			lease.Spec.RenewTime = &past
+			if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		holder, err := acquireLease(ctx, clientset, step.identity, 10*time.Minute)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if holder != step.want {
+			t.Fatalf("%s: holder = %q, want %q", step.name, holder, step.want)
+		}
+	}
+
+	if err := recordRun(ctx, clientset, time.Now(), 3); err != nil {
+		t.Fatal(err)
+	}
+	status, err := readLease(ctx, clientset, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Held || status.Holder != "b" || status.Cleaned != 3 || status.LastRun == nil {
+		t.Fatalf("unexpected lease status: %+v", status)
+	}
+
+	// Releasing hands the lease to the next instance right away
+	if err := releaseLease(ctx, clientset, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if holder, err := acquireLease(ctx, clientset, "a", 10*time.Minute); err != nil || holder != "a" {
+		t.Fatalf("after release: holder = %q, err = %v", holder, err)
+	}
+}
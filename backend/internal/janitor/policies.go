@@ -0,0 +1,544 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/waiyan/bridge/internal/access"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"sigs.k8s.io/yaml"
+)
+
+// Policy types
+const (
+	// PolicyTTL deletes resources whose bridge.io/expires-at has passed
+	PolicyTTL = "ttl"
+	// PolicyCompletedJobs deletes Jobs that completed more than maxAge ago
+	PolicyCompletedJobs = "completed-jobs"
+	// PolicyEvictedPods deletes Evicted pods older than maxAge
+	PolicyEvictedPods = "evicted-pods"
+)
+
+// KindPolicies marks the ConfigMap holding a cluster's janitor policies
+const KindPolicies = "janitor-policies"
+
+const (
+	// policiesConfigMap is the ConfigMap in bridge-system holding the policies
+	policiesConfigMap = "bridge-janitor-policies"
+	// policiesKey is the ConfigMap data key holding the policies' YAML
+	policiesKey = "policies.yaml"
+	// maxReportTargets caps how many matched resources a policy result lists
+	maxReportTargets = 100
+)
+
+// ErrInvalidPolicy is wrapped by policy validation errors
+var ErrInvalidPolicy = errors.New("invalid janitor policy")
+
+// protectedNamespaces are never deleted by a TTL policy
+var protectedNamespaces = map[string]bool{
+	"default":              true,
+	"kube-system":          true,
+	"kube-public":          true,
+	"kube-node-lease":      true,
+	access.SystemNamespace: true,
+}
+
+// Policy tells the janitor which resources to delete besides expired access grants
+type Policy struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`                 // ttl, completed-jobs or evicted-pods
+	Resources  []string `json:"resources,omitempty"`  // ttl: resources to scan, e.g. "namespaces", "deployments.apps", "Widget"
+	Namespaces []string `json:"namespaces,omitempty"` // only scan these namespaces; empty means all
+	MaxAge     string   `json:"maxAge,omitempty"`     // completed-jobs, evicted-pods: e.g. "7d"
+	DryRun     bool     `json:"dryRun,omitempty"`     // only report what would be deleted
+}
+
+// DefaultPolicies apply to clusters without stored policies: throwaway namespaces
+// annotated with bridge.io/expires-at are deleted once they expire
+var DefaultPolicies = []Policy{
+	{Name: "expired-namespaces", Type: PolicyTTL, Resources: []string{"namespaces"}},
+}
+
+// Target is a resource matched by a policy
+type Target struct {
+	Resource  string `json:"resource"` // e.g. "deployments.apps"
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+// PolicyResult reports what a policy matched and deleted
+type PolicyResult struct {
+	Policy  string   `json:"policy"`
+	DryRun  bool     `json:"dryRun"`
+	Matched int      `json:"matched"`
+	Deleted int      `json:"deleted"`
+	Targets []Target `json:"targets,omitempty"` // the first 100 matches
+	Errors  []string `json:"errors,omitempty"`
+}
+
+func (r *PolicyResult) match(t Target) {
+	r.Matched++
+	if len(r.Targets) < maxReportTargets {
+		r.Targets = append(r.Targets, t)
+	}
+}
+
+func (r *PolicyResult) fail(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// policyClients are the clients a policy sweep needs for one cluster
+type policyClients struct {
+	clientset kubernetes.Interface
+	discovery discovery.DiscoveryInterface
+	metadata  metadata.Interface
+}
+
+// scanResource is a resource a TTL policy lists, resolved through discovery
+type scanResource struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+func (r scanResource) String() string {
+	if r.gvr.Group == "" {
+		return r.gvr.Resource
+	}
+	return r.gvr.Resource + "." + r.gvr.Group
+}
+
+// resolveResource finds a resource by plural, singular, kind or short name, optionally
+// qualified by group ("deployments.apps"). It must support list and delete.
+func resolveResource(lists []*metav1.APIResourceList, name string) (scanResource, error) {
+	resource, group, qualified := strings.Cut(name, ".")
+
+	var matches []scanResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		if qualified && gv.Group != group {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !resourceNamed(r, resource) {
+				continue
+			}
+			if !hasVerb(r.Verbs, "list") || !hasVerb(r.Verbs, "delete") {
+				return scanResource{}, fmt.Errorf("%w: %s cannot be listed and deleted", ErrInvalidPolicy, name)
+			}
+			matches = append(matches, scanResource{gvr: gv.WithResource(r.Name), namespaced: r.Namespaced})
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return scanResource{}, fmt.Errorf("%w: resource %q is not served by the cluster", ErrInvalidPolicy, name)
+	case len(matches) == 1:
+		return matches[0], nil
+	}
+	// An unqualified name served by several groups means the core one if there is one
+	for _, m := range matches {
+		if m.gvr.Group == "" {
+			return m, nil
+		}
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, m.String())
+	}
+	return scanResource{}, fmt.Errorf("%w: %q is ambiguous, use one of %s", ErrInvalidPolicy, name, strings.Join(names, ", "))
+}
+
+func resourceNamed(r metav1.APIResource, name string) bool {
+	if strings.EqualFold(r.Name, name) || strings.EqualFold(r.SingularName, name) || strings.EqualFold(r.Kind, name) {
+		return true
+	}
+	for _, short := range r.ShortNames {
+		if strings.EqualFold(short, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVerb(verbs []string, verb string) bool {
+	for _, v := range verbs {
+		if v == verb || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// preferredResources returns the cluster's preferred resources, tolerating groups that fail discovery
+func preferredResources(client discovery.DiscoveryInterface) ([]*metav1.APIResourceList, error) {
+	lists, err := discovery.ServerPreferredResources(client)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover API resources: %w", err)
+	}
+	return lists, nil
+}
+
+// ValidatePolicies checks policy names, types and ages, and resolves TTL resources
+// through discovery when a client is given
+func ValidatePolicies(client discovery.DiscoveryInterface, policies []Policy) error {
+	var lists []*metav1.APIResourceList
+	if client != nil {
+		var err error
+		if lists, err = preferredResources(client); err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range policies {
+		if p.Name == "" {
+			return fmt.Errorf("%w: every policy needs a name", ErrInvalidPolicy)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("%w: duplicate policy name %q", ErrInvalidPolicy, p.Name)
+		}
+		seen[p.Name] = true
+
+		if p.MaxAge != "" {
+			if _, err := access.ParseDuration(p.MaxAge); err != nil {
+				return fmt.Errorf("%w: %s: maxAge: %v", ErrInvalidPolicy, p.Name, err)
+			}
+		}
+
+		switch p.Type {
+		case PolicyTTL:
+			if len(p.Resources) == 0 {
+				return fmt.Errorf("%w: %s: ttl policies need resources to scan", ErrInvalidPolicy, p.Name)
+			}
+			if client == nil {
+				continue
+			}
+			for _, name := range p.Resources {
+				if _, err := resolveResource(lists, name); err != nil {
+					return fmt.Errorf("%s: %w", p.Name, err)
+				}
+			}
+		case PolicyCompletedJobs:
+			if p.MaxAge == "" {
+				return fmt.Errorf("%w: %s: completed-jobs policies need a maxAge", ErrInvalidPolicy, p.Name)
+			}
+		case PolicyEvictedPods:
+		default:
+			return fmt.Errorf("%w: %s: unknown type %q (ttl, completed-jobs or evicted-pods)", ErrInvalidPolicy, p.Name, p.Type)
+		}
+	}
+	return nil
+}
+
+// loadPolicies reads a cluster's policies, falling back to DefaultPolicies
+func loadPolicies(ctx context.Context, clientset kubernetes.Interface) ([]Policy, error) {
+	cm, err := clientset.CoreV1().ConfigMaps(access.SystemNamespace).Get(ctx, policiesConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return DefaultPolicies, nil
+		}
+		return nil, err
+	}
+	var policies []Policy
+	if err := yaml.Unmarshal([]byte(cm.Data[policiesKey]), &policies); err != nil {
+		return nil, fmt.Errorf("janitor policies in %s/%s are malformed: %w", access.SystemNamespace, policiesConfigMap, err)
+	}
+	if policies == nil {
+		policies = []Policy{}
+	}
+	return policies, nil
+}
+
+// savePolicies replaces a cluster's policies
+func savePolicies(ctx context.Context, clientset kubernetes.Interface, policies []Policy) error {
+	data, err := yaml.Marshal(policies)
+	if err != nil {
+		return err
+	}
+	if err := access.EnsureSystemNamespace(ctx, clientset); err != nil {
+		return err
+	}
+
+	configMaps := clientset.CoreV1().ConfigMaps(access.SystemNamespace)
+	cm, err := configMaps.Get(ctx, policiesConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      policiesConfigMap,
+				Namespace: access.SystemNamespace,
+				Labels: map[string]string{
+					access.LabelManagedBy: access.ManagedByBridge,
+					access.LabelKind:      KindPolicies,
+				},
+			},
+			Data: map[string]string{policiesKey: string(data)},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[policiesKey] = string(data)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// applyPolicies runs every policy against one cluster. With dryRun nothing is deleted,
+// whatever the policies say.
+func applyPolicies(ctx context.Context, clients policyClients, policies []Policy, dryRun bool, now time.Time) []PolicyResult {
+	results := make([]PolicyResult, 0, len(policies))
+	var lists []*metav1.APIResourceList
+	var discoveryErr error
+	discovered := false
+
+	for _, p := range policies {
+		result := PolicyResult{Policy: p.Name, DryRun: dryRun || p.DryRun}
+		var maxAge time.Duration
+		if p.MaxAge != "" {
+			var err error
+			if maxAge, err = access.ParseDuration(p.MaxAge); err != nil {
+				result.fail("invalid maxAge: %v", err)
+				results = append(results, result)
+				continue
+			}
+		}
+
+		switch p.Type {
+		case PolicyTTL:
+			if !discovered {
+				lists, discoveryErr = preferredResources(clients.discovery)
+				discovered = true
+			}
+			if discoveryErr != nil {
+				result.fail("%v", discoveryErr)
+				break
+			}
+			for _, name := range p.Resources {
+				resource, err := resolveResource(lists, name)
+				if err != nil {
+					result.fail("%v", err)
+					continue
+				}
+				applyTTL(ctx, clients.metadata, resource, p.Namespaces, now, &result)
+			}
+		case PolicyCompletedJobs:
+			applyCompletedJobs(ctx, clients.clientset, p.Namespaces, now.Add(-maxAge), &result)
+		case PolicyEvictedPods:
+			applyEvictedPods(ctx, clients.clientset, p.Namespaces, now.Add(-maxAge), &result)
+		default:
+			result.fail("unknown policy type %q", p.Type)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// scanNamespaces returns the namespaces to list: the policy's, or "" for all of them
+func scanNamespaces(namespaces []string) []string {
+	if len(namespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	return namespaces
+}
+
+// applyTTL deletes the resources whose bridge.io/expires-at has passed. Access grants
+// are left to the grant cleanup, and protected namespaces are never deleted.
+func applyTTL(ctx context.Context, client metadata.Interface, resource scanResource, namespaces []string, now time.Time, result *PolicyResult) {
+	if !resource.namespaced {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	propagation := metav1.DeletePropagationBackground
+
+	for _, ns := range scanNamespaces(namespaces) {
+		list, err := metadataClient(client, resource, ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			result.fail("failed to list %s: %v", resource, err)
+			continue
+		}
+
+		for _, item := range list.Items {
+			value := item.Annotations[AnnotationExpiresAt]
+			if value == "" || item.DeletionTimestamp != nil || item.Labels[LabelManagedBy] == ManagedByBridge {
+				continue
+			}
+			if resource.gvr.Group == "" && resource.gvr.Resource == "namespaces" && protectedNamespaces[item.Name] {
+				continue
+			}
+			expiresAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				result.fail("%s %s: invalid %s annotation %q", resource, qualifiedName(item.Namespace, item.Name), AnnotationExpiresAt, value)
+				continue
+			}
+			if expiresAt.After(now) {
+				continue
+			}
+
+			result.match(Target{Resource: resource.String(), Namespace: item.Namespace, Name: item.Name, Reason: "expired at " + value})
+			if result.DryRun {
+				continue
+			}
+			// Background propagation lets the garbage collector remove dependents
+			err = metadataClient(client, resource, item.Namespace).Delete(ctx, item.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+			if err != nil && !apierrors.IsNotFound(err) {
+				result.fail("failed to delete %s %s: %v", resource, qualifiedName(item.Namespace, item.Name), err)
+				continue
+			}
+			result.Deleted++
+			log.Printf("[Janitor] Deleted expired %s %s (expired at %s)", resource, qualifiedName(item.Namespace, item.Name), value)
+		}
+	}
+}
+
+// metadataClient returns the client for a resource, scoped to namespace if it is namespaced
+func metadataClient(client metadata.Interface, resource scanResource, namespace string) metadata.ResourceInterface {
+	if resource.namespaced {
+		return client.Resource(resource.gvr).Namespace(namespace)
+	}
+	return client.Resource(resource.gvr)
+}
+
+// applyCompletedJobs deletes Jobs that completed before cutoff, along with their pods
+func applyCompletedJobs(ctx context.Context, clientset kubernetes.Interface, namespaces []string, cutoff time.Time, result *PolicyResult) {
+	policy := metav1.DeletePropagationBackground
+	for _, ns := range scanNamespaces(namespaces) {
+		jobs, err := clientset.BatchV1().Jobs(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			result.fail("failed to list jobs: %v", err)
+			continue
+		}
+		for _, job := range jobs.Items {
+			if job.DeletionTimestamp != nil || !jobComplete(&job) || job.Status.CompletionTime == nil || job.Status.CompletionTime.After(cutoff) {
+				continue
+			}
+
+			result.match(Target{Resource: "jobs.batch", Namespace: job.Namespace, Name: job.Name, Reason: "completed at " + job.Status.CompletionTime.UTC().Format(time.RFC3339)})
+			if result.DryRun {
+				continue
+			}
+			err := clientset.BatchV1().Jobs(job.Namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &policy})
+			if err != nil && !apierrors.IsNotFound(err) {
+				result.fail("failed to delete job %s/%s: %v", job.Namespace, job.Name, err)
+				continue
+			}
+			result.Deleted++
+		}
+	}
+}
+
+func jobComplete(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobComplete && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// applyEvictedPods deletes Evicted pods created before cutoff
+func applyEvictedPods(ctx context.Context, clientset kubernetes.Interface, namespaces []string, cutoff time.Time, result *PolicyResult) {
+	for _, ns := range scanNamespaces(namespaces) {
+		pods, err := clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+			FieldSelector: "status.phase=" + string(corev1.PodFailed),
+		})
+		if err != nil {
+			result.fail("failed to list pods: %v", err)
+			continue
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Reason != "Evicted" || pod.DeletionTimestamp != nil || pod.CreationTimestamp.After(cutoff) {
+				continue
+			}
+
+			result.match(Target{Resource: "pods", Namespace: pod.Namespace, Name: pod.Name, Reason: "evicted: " + pod.Status.Message})
+			if result.DryRun {
+				continue
+			}
+			err := clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				result.fail("failed to delete pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				continue
+			}
+			result.Deleted++
+		}
+	}
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// policyClientsFor builds the clients for the kube context carried by ctx
+func (j *Janitor) policyClientsFor(ctx context.Context) (policyClients, error) {
+	clientset, err := j.k8sService.ClientsetFor(ctx)
+	if err != nil {
+		return policyClients{}, err
+	}
+	config, err := j.k8sService.ConfigFor(ctx)
+	if err != nil {
+		return policyClients{}, err
+	}
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return policyClients{}, err
+	}
+	return policyClients{clientset: clientset, discovery: clientset.Discovery(), metadata: metadataClient}, nil
+}
+
+// Policies returns the janitor policies of the kube context carried by ctx
+func (j *Janitor) Policies(ctx context.Context) ([]Policy, error) {
+	clientset, err := j.k8sService.ClientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return loadPolicies(ctx, clientset)
+}
+
+// SavePolicies validates and replaces the janitor policies of the kube context carried by ctx
+func (j *Janitor) SavePolicies(ctx context.Context, policies []Policy) error {
+	clientset, err := j.k8sService.ClientsetFor(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePolicies(clientset.Discovery(), policies); err != nil {
+		return err
+	}
+	return savePolicies(ctx, clientset, policies)
+}
+
+// DryRun reports what the policies would delete in the kube context carried by ctx,
+// without deleting anything. Nil policies means the stored ones.
+func (j *Janitor) DryRun(ctx context.Context, policies []Policy) ([]PolicyResult, error) {
+	clients, err := j.policyClientsFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policies == nil {
+		if policies, err = loadPolicies(ctx, clients.clientset); err != nil {
+			return nil, err
+		}
+	} else if err := ValidatePolicies(clients.discovery, policies); err != nil {
+		return nil, err
+	}
+	return applyPolicies(ctx, clients, policies, true, time.Now()), nil
+}
@@ -0,0 +1,192 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	fakemetadata "k8s.io/client-go/metadata/fake"
+)
+
+var listDelete = []string{"get", "list", "delete"}
+
+// testResources is the API surface the fake clientset's discovery reports
+var testResources = []*metav1.APIResourceList{
+	{GroupVersion: "v1", APIResources: []metav1.APIResource{
+		{Name: "namespaces", SingularName: "namespace", Kind: "Namespace", ShortNames: []string{"ns"}, Verbs: listDelete},
+		{Name: "pods", SingularName: "pod", Namespaced: true, Kind: "Pod", Verbs: listDelete},
+		{Name: "componentstatuses", SingularName: "componentstatus", Kind: "ComponentStatus", Verbs: []string{"get", "list"}},
+	}},
+	{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+		{Name: "deployments", SingularName: "deployment", Namespaced: true, Kind: "Deployment", Verbs: listDelete},
+	}},
+	{GroupVersion: "batch/v1", APIResources: []metav1.APIResource{
+		{Name: "jobs", SingularName: "job", Namespaced: true, Kind: "Job", Verbs: listDelete},
+	}},
+	{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+		{Name: "widgets", SingularName: "widget", Namespaced: true, Kind: "Widget", Verbs: listDelete},
+	}},
+}
+
+func partial(apiVersion, kind, namespace, name, expiresAt string) *metav1.PartialObjectMetadata {
+	obj := &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiVersion, Kind: kind},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+	}
+	if expiresAt != "" {
+		obj.Annotations = map[string]string{AnnotationExpiresAt: expiresAt}
+	}
+	return obj
+}
+
+func TestValidatePolicies(t *testing.T) {
+	clientset := fake.NewClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = testResources
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{name: "ttl by plural", policy: Policy{Name: "p", Type: PolicyTTL, Resources: []string{"namespaces"}}},
+		{name: "ttl by kind and group", policy: Policy{Name: "p", Type: PolicyTTL, Resources: []string{"Deployment", "widgets.example.com", "ns"}}},
+		{name: "ttl without resources", policy: Policy{Name: "p", Type: PolicyTTL}, wantErr: true},
+		{name: "unknown resource", policy: Policy{Name: "p", Type: PolicyTTL, Resources: []string{"gadgets"}}, wantErr: true},
+		{name: "wrong group", policy: Policy{Name: "p", Type: PolicyTTL, Resources: []string{"deployments.batch"}}, wantErr: true},
+		{name: "not deletable", policy: Policy{Name: "p", Type: PolicyTTL, Resources: []string{"componentstatuses"}}, wantErr: true},
+		{name: "completed jobs", policy: Policy{Name: "p", Type: PolicyCompletedJobs, MaxAge: "7d"}},
+		{name: "completed jobs without max age", policy: Policy{Name: "p", Type: PolicyCompletedJobs}, wantErr: true},
+		{name: "bad max age", policy: Policy{Name: "p", Type: PolicyEvictedPods, MaxAge: "soon"}, wantErr: true},
+		{name: "unknown type", policy: Policy{Name: "p", Type: "orphans"}, wantErr: true},
+		{name: "missing name", policy: Policy{Type: PolicyEvictedPods}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePolicies(clientset.Discovery(), []Policy{tt.policy})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPolicy) {
+				t.Fatalf("err = %v, want it to wrap ErrInvalidPolicy", err)
+			}
+		})
+	}
+}
+
+func TestApplyPolicies(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	past := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := now.Add(time.Hour).UTC().Format(time.RFC3339)
+
+	grant := partial("apps/v1", "Deployment", "team", "bridge-owned", past)
+	grant.Labels = map[string]string{LabelManagedBy: ManagedByBridge}
+
+	scheme := fakemetadata.NewTestScheme()
+	metav1.AddMetaToScheme(scheme)
+	metadataClient := fakemetadata.NewSimpleMetadataClient(scheme,
+		partial("v1", "Namespace", "", "preview-1", past),
+		partial("v1", "Namespace", "", "preview-2", future),
+		partial("v1", "Namespace", "", "kube-system", past),
+		partial("v1", "Namespace", "", "team", ""),
+		partial("apps/v1", "Deployment", "team", "demo", past),
+		grant,
+		partial("example.com/v1", "Widget", "team", "gizmo", past),
+	)
+
+	completed := func(name string, at time.Time) *batchv1.Job {
+		completion := metav1.NewTime(at)
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: name},
+			Status: batchv1.JobStatus{
+				CompletionTime: &completion,
+				Conditions:     []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	clientset := fake.NewClientset(
+		completed("old-job", now.Add(-10*24*time.Hour)),
+		completed("recent-job", now.Add(-time.Hour)),
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "running-job"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "evicted", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "crashed"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Error"},
+		},
+	)
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = testResources
+	clients := policyClients{clientset: clientset, discovery: clientset.Discovery(), metadata: metadataClient}
+
+	policies := []Policy{
+		{Name: "ttl", Type: PolicyTTL, Resources: []string{"namespaces", "deployments", "Widget"}},
+		{Name: "jobs", Type: PolicyCompletedJobs, MaxAge: "7d"},
+		{Name: "evicted", Type: PolicyEvictedPods},
+	}
+	want := map[string][]string{
+		"ttl":     {"preview-1", "team/demo", "team/gizmo"},
+		"jobs":    {"team/old-job"},
+		"evicted": {"team/evicted"},
+	}
+
+	check := func(results []PolicyResult, dryRun bool) {
+		t.Helper()
+		for _, r := range results {
+			if len(r.Errors) > 0 {
+				t.Fatalf("%s: unexpected errors %v", r.Policy, r.Errors)
+			}
+			var got []string
+			for _, target := range r.Targets {
+				got = append(got, qualifiedName(target.Namespace, target.Name))
+			}
+			if len(got) != len(want[r.Policy]) || r.Matched != len(got) {
+				t.Fatalf("%s: matched %v, want %v", r.Policy, got, want[r.Policy])
+			}
+			for i := range got {
+				if got[i] != want[r.Policy][i] {
+					t.Fatalf("%s: matched %v, want %v", r.Policy, got, want[r.Policy])
+				}
+			}
+			wantDeleted := len(got)
+			if dryRun {
+				wantDeleted = 0
+			}
+			if r.Deleted != wantDeleted || r.DryRun != dryRun {
+				t.Fatalf("%s: deleted %d (dry run %v), want %d", r.Policy, r.Deleted, r.DryRun, wantDeleted)
+			}
+		}
+	}
+
+	// A dry run reports without deleting
+	check(applyPolicies(ctx, clients, policies, true, now), true)
+	if _, err := metadataClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Get(ctx, "preview-1", metav1.GetOptions{}); err != nil {
+		t.Fatalf("dry run deleted preview-1: %v", err)
+	}
+
+	check(applyPolicies(ctx, clients, policies, false, now), false)
+	if _, err := metadataClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Get(ctx, "preview-1", metav1.GetOptions{}); err == nil {
+		t.Fatal("preview-1 was not deleted")
+	}
+	if _, err := clientset.BatchV1().Jobs("team").Get(ctx, "recent-job", metav1.GetOptions{}); err != nil {
+		t.Fatalf("recent-job was deleted: %v", err)
+	}
+
+	// Policies round-trip through the cluster
+	if err := savePolicies(ctx, clientset, policies); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := loadPolicies(ctx, clientset)
+	if err != nil || len(stored) != len(policies) || stored[1].MaxAge != "7d" {
+		t.Fatalf("loadPolicies = %+v, %v", stored, err)
+	}
+}
@@ -0,0 +1,535 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// cacheIdleTimeout is how long an unused context keeps its informers running
+const cacheIdleTimeout = 15 * time.Minute
+
+// ResourceCache is a SharedInformerFactory-backed cache for a single kube context.
+// Informers are started lazily the first time a kind is read, so a context only
+// watches the kinds the UI actually looks at.
+type ResourceCache struct {
+	contextName string
+	clientset   *kubernetes.Clientset
+	factory     informers.SharedInformerFactory
+	stopCh      chan struct{}
+
+	mu       sync.Mutex
+	lastUsed time.Time
+	watchers int
+	stopped  bool
+}
+
+// newResourceCache creates a cache for the given context's clientset
+func newResourceCache(contextName string, clientset *kubernetes.Clientset) *ResourceCache {
+	return &ResourceCache{
+		contextName: contextName,
+		clientset:   clientset,
+		factory:     informers.NewSharedInformerFactory(clientset, 0),
+		stopCh:      make(chan struct{}),
+		lastUsed:    time.Now(),
+	}
+}
+
+// Factory returns the underlying informer factory (e.g. to attach event handlers)
+func (rc *ResourceCache) Factory() informers.SharedInformerFactory {
+	return rc.factory
+}
+
+// Informer registers and starts the informer returned by get, and reports whether it has synced.
+// Until it has, callers should fall back to a direct API list.
+func (rc *ResourceCache) Informer(get func(informers.SharedInformerFactory) cache.SharedIndexInformer) (cache.SharedIndexInformer, bool) {
+	informer := get(rc.factory)
+
+	rc.mu.Lock()
+	rc.lastUsed = time.Now()
+	if !rc.stopped {
+		// Start is idempotent: only informers registered since the last call are started
+		rc.factory.Start(rc.stopCh)
+	}
+	rc.mu.Unlock()
+
+	return informer, informer.HasSynced()
+}
+
+// StopCh returns a channel that is closed when the cache is torn down
+func (rc *ResourceCache) StopCh() <-chan struct{} {
+	return rc.stopCh
+}
+
+// Stop tears down all informers of this cache
+func (rc *ResourceCache) Stop() {
+	rc.mu.Lock()
+	if rc.stopped {
+		rc.mu.Unlock()
+		return
+	}
+	rc.stopped = true
+	rc.mu.Unlock()
+
+	close(rc.stopCh)
+	rc.factory.Shutdown()
+	log.Printf("[Cache] Stopped informers for context: %s", rc.contextName)
+}
+
+// Acquire keeps the cache from being evicted as idle while a long-lived
+// consumer (e.g. a watch stream) uses it. Call the returned func when done.
+func (rc *ResourceCache) Acquire() func() {
+	rc.mu.Lock()
+	rc.watchers++
+	rc.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			rc.mu.Lock()
+			rc.watchers--
+			rc.lastUsed = time.Now()
+			rc.mu.Unlock()
+		})
+	}
+}
+
+func (rc *ResourceCache) idle(now time.Time) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.watchers == 0 && now.Sub(rc.lastUsed) > cacheIdleTimeout
+}
+
+// GetCache returns the informer cache for a context, creating it on first use.
+// An empty name selects the current context. The cache is rebuilt whenever the
+// context's client is replaced (context switch, re-authentication).
+func (cm *ClientManager) GetCache(contextName string) (*ResourceCache, error) {
+	if contextName == "" {
+		contextName = cm.GetCurrentContext()
+	}
+
+	clientset, err := cm.GetClientsetForContext(contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	cm.poolMu.Lock()
+	defer cm.poolMu.Unlock()
+
+	// Tear down caches nobody has read from in a while
+	now := time.Now()
+	for name, rc := range cm.caches {
+		if name != contextName && rc.idle(now) {
+			go rc.Stop()
+			delete(cm.caches, name)
+		}
+	}
+
+	rc, ok := cm.caches[contextName]
+	if ok && rc.clientset == clientset {
+		return rc, nil
+	}
+	if ok {
+		go rc.Stop()
+	}
+
+	rc = newResourceCache(contextName, clientset)
+	cm.caches[contextName] = rc
+	log.Printf("[Cache] Started informer cache for context: %s", contextName)
+	return rc, nil
+}
+
+// stopCache tears down the informer cache for a context, if any
+func (cm *ClientManager) stopCache(contextName string) {
+	cm.poolMu.Lock()
+	rc, ok := cm.caches[contextName]
+	delete(cm.caches, contextName)
+	cm.poolMu.Unlock()
+
+	if ok {
+		rc.Stop()
+	}
+}
+
+// CacheFor returns the informer cache for the kube context selected by ctx
+func (s *Service) CacheFor(ctx context.Context) (*ResourceCache, error) {
+	return s.manager.GetCache(KubeContextFrom(ctx))
+}
+
+// cachedList reads objects of one kind from the request context's informer cache,
+// falling back to fallback (a direct API list) while the informer is still syncing.
+// Results are sorted by namespace/name like an API list so responses stay stable.
+func cachedList[T any](
+	s *Service,
+	ctx context.Context,
+	namespace string,
+	selector labels.Selector,
+	get func(informers.SharedInformerFactory) cache.SharedIndexInformer,
+	fallback func(clientset *kubernetes.Clientset, opts metav1.ListOptions) ([]T, error),
+) ([]T, error) {
+	if selector == nil {
+		selector = labels.Everything()
+	}
+
+	rc, err := s.CacheFor(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("client not ready: %w", err)
+	}
+
+	informer, synced := rc.Informer(get)
+	if !synced {
+		return fallback(rc.clientset, metav1.ListOptions{LabelSelector: selector.String()})
+	}
+
+	var result []T
+	err = cache.ListAllByNamespace(informer.GetIndexer(), namespace, selector, func(obj interface{}) {
+		if item, ok := obj.(*T); ok {
+			result = append(result, *item)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := any(&result[i]).(metav1.Object)
+		b, _ := any(&result[j]).(metav1.Object)
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	})
+
+	return result, nil
+}
+
+// CachedPods lists pods from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedPods(ctx context.Context, namespace string, selector labels.Selector) ([]corev1.Pod, error) {
+	return cachedList(s, ctx, namespace, selector,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().Pods().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.Pod, error) {
+			list, err := cs.CoreV1().Pods(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedNodes lists nodes from the informer cache
+func (s *Service) CachedNodes(ctx context.Context) ([]corev1.Node, error) {
+	return cachedList(s, ctx, metav1.NamespaceAll, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().Nodes().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.Node, error) {
+			list, err := cs.CoreV1().Nodes().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedNamespaces lists namespaces from the informer cache
+func (s *Service) CachedNamespaces(ctx context.Context) ([]corev1.Namespace, error) {
+	return cachedList(s, ctx, metav1.NamespaceAll, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().Namespaces().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.Namespace, error) {
+			list, err := cs.CoreV1().Namespaces().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedServices lists services from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedServices(ctx context.Context, namespace string) ([]corev1.Service, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().Services().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.Service, error) {
+			list, err := cs.CoreV1().Services(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedServiceAccounts lists service accounts from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedServiceAccounts(ctx context.Context, namespace string, selector labels.Selector) ([]corev1.ServiceAccount, error) {
+	return cachedList(s, ctx, namespace, selector,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().ServiceAccounts().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.ServiceAccount, error) {
+			list, err := cs.CoreV1().ServiceAccounts(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedDeployments lists deployments from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedDeployments(ctx context.Context, namespace string) ([]appsv1.Deployment, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Apps().V1().Deployments().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]appsv1.Deployment, error) {
+			list, err := cs.AppsV1().Deployments(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedStatefulSets lists statefulsets from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedStatefulSets(ctx context.Context, namespace string) ([]appsv1.StatefulSet, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Apps().V1().StatefulSets().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]appsv1.StatefulSet, error) {
+			list, err := cs.AppsV1().StatefulSets(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedDaemonSets lists daemonsets from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedDaemonSets(ctx context.Context, namespace string) ([]appsv1.DaemonSet, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Apps().V1().DaemonSets().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]appsv1.DaemonSet, error) {
+			list, err := cs.AppsV1().DaemonSets(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedCronJobs lists cronjobs from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedCronJobs(ctx context.Context, namespace string) ([]batchv1.CronJob, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Batch().V1().CronJobs().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]batchv1.CronJob, error) {
+			list, err := cs.BatchV1().CronJobs(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedIngresses lists ingresses from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedIngresses(ctx context.Context, namespace string) ([]networkingv1.Ingress, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Networking().V1().Ingresses().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]networkingv1.Ingress, error) {
+			list, err := cs.NetworkingV1().Ingresses(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedConfigMaps lists configmaps from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedConfigMaps(ctx context.Context, namespace string) ([]corev1.ConfigMap, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().ConfigMaps().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.ConfigMap, error) {
+			list, err := cs.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedPersistentVolumeClaims lists persistent volume claims from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedPersistentVolumeClaims(ctx context.Context, namespace string) ([]corev1.PersistentVolumeClaim, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().PersistentVolumeClaims().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.PersistentVolumeClaim, error) {
+			list, err := cs.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedPersistentVolumes lists persistent volumes from the informer cache
+func (s *Service) CachedPersistentVolumes(ctx context.Context) ([]corev1.PersistentVolume, error) {
+	return cachedList(s, ctx, metav1.NamespaceAll, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().PersistentVolumes().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.PersistentVolume, error) {
+			list, err := cs.CoreV1().PersistentVolumes().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedResourceQuotas lists resource quotas from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedResourceQuotas(ctx context.Context, namespace string) ([]corev1.ResourceQuota, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().ResourceQuotas().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]corev1.ResourceQuota, error) {
+			list, err := cs.CoreV1().ResourceQuotas(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedNetworkPolicies lists network policies from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedNetworkPolicies(ctx context.Context, namespace string) ([]networkingv1.NetworkPolicy, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Networking().V1().NetworkPolicies().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]networkingv1.NetworkPolicy, error) {
+			list, err := cs.NetworkingV1().NetworkPolicies(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedStorageClasses lists storage classes from the informer cache
+func (s *Service) CachedStorageClasses(ctx context.Context) ([]storagev1.StorageClass, error) {
+	return cachedList(s, ctx, metav1.NamespaceAll, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Storage().V1().StorageClasses().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]storagev1.StorageClass, error) {
+			list, err := cs.StorageV1().StorageClasses().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedRoles lists roles from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedRoles(ctx context.Context, namespace string) ([]rbacv1.Role, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Rbac().V1().Roles().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]rbacv1.Role, error) {
+			list, err := cs.RbacV1().Roles(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedClusterRoles lists cluster roles from the informer cache
+func (s *Service) CachedClusterRoles(ctx context.Context) ([]rbacv1.ClusterRole, error) {
+	return cachedList(s, ctx, metav1.NamespaceAll, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Rbac().V1().ClusterRoles().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]rbacv1.ClusterRole, error) {
+			list, err := cs.RbacV1().ClusterRoles().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedRoleBindings lists role bindings from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedRoleBindings(ctx context.Context, namespace string) ([]rbacv1.RoleBinding, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Rbac().V1().RoleBindings().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]rbacv1.RoleBinding, error) {
+			list, err := cs.RbacV1().RoleBindings(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedClusterRoleBindings lists cluster role bindings from the informer cache
+func (s *Service) CachedClusterRoleBindings(ctx context.Context) ([]rbacv1.ClusterRoleBinding, error) {
+	return cachedList(s, ctx, metav1.NamespaceAll, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Rbac().V1().ClusterRoleBindings().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]rbacv1.ClusterRoleBinding, error) {
+			list, err := cs.RbacV1().ClusterRoleBindings().List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
+
+// CachedHorizontalPodAutoscalers lists horizontal pod autoscalers from the informer cache. An empty namespace lists all namespaces.
+func (s *Service) CachedHorizontalPodAutoscalers(ctx context.Context, namespace string) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+	return cachedList(s, ctx, namespace, nil,
+		func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+			return f.Autoscaling().V2().HorizontalPodAutoscalers().Informer()
+		},
+		func(cs *kubernetes.Clientset, opts metav1.ListOptions) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+			list, err := cs.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		})
+}
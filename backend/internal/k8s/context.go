@@ -0,0 +1,24 @@
+package k8s
+
+import "context"
+
+// kubeContextKey is the context.Context key for the kubeconfig context selected by a request
+type kubeContextKey struct{}
+
+// WithKubeContext returns a copy of ctx that targets the given kubeconfig context.
+// An empty name leaves ctx unchanged, so the manager's current context is used.
+func WithKubeContext(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, kubeContextKey{}, name)
+}
+
+// KubeContextFrom returns the kubeconfig context selected for ctx, or "" for the current context
+func KubeContextFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	name, _ := ctx.Value(kubeContextKey{}).(string)
+	return name
+}
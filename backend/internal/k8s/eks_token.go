@@ -0,0 +1,87 @@
+package k8s
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// eksTokenRefreshWindow is how long before expiry a native EKS token is regenerated
+const eksTokenRefreshWindow = 2 * time.Minute
+
+// eksTokenTimeout bounds a single SSO + STS round trip when generating a token
+const eksTokenTimeout = 30 * time.Second
+
+// eksTokenSource generates native EKS tokens for one context on demand.
+// It sits behind client-go's caching token source, which reuses a token until
+// shortly before it expires and drops it as soon as the API server answers 401,
+// so the clientset (and any informers or streams built on it) never has to be rebuilt.
+type eksTokenSource struct {
+	contextName string
+	generate    func(ctx context.Context) (string, time.Time, error)
+
+	mu     sync.Mutex
+	expiry time.Time
+}
+
+// Token generates a fresh EKS token. The reported expiry is moved forward by
+// eksTokenRefreshWindow so the caching layer refreshes it before EKS rejects it.
+func (ts *eksTokenSource) Token() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), eksTokenTimeout)
+	defer cancel()
+
+	token, expiry, err := ts.generate(ctx)
+
+	ts.mu.Lock()
+	refreshed := !ts.expiry.IsZero()
+	if err == nil {
+		ts.expiry = expiry
+	}
+	ts.mu.Unlock()
+
+	if err != nil {
+		// Initial failures are reported by buildClient
+		if refreshed {
+			log.Printf("❌ [Auth] Failed to refresh EKS token for '%s': %v", ts.contextName, err)
+		}
+		return nil, err
+	}
+	if refreshed {
+		log.Printf("✅ [Auth] Native EKS token refreshed for '%s' (expires: %s)", ts.contextName, expiry.Format(time.RFC3339))
+	}
+
+	return &oauth2.Token{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		Expiry:      expiry.Add(-eksTokenRefreshWindow),
+	}, nil
+}
+
+// Expiry returns when the most recently generated token expires
+func (ts *eksTokenSource) Expiry() time.Time {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.expiry
+}
+
+// install wires the token source into config's transport and generates the first
+// token eagerly so SSO problems surface when the client is built, not on first use.
+func (ts *eksTokenSource) install(config *rest.Config) error {
+	cached := transport.NewCachedTokenSource(ts)
+	if _, err := cached.Token(); err != nil {
+		return err
+	}
+
+	// Static credentials would take precedence over the token source
+	config.BearerToken = ""
+	config.BearerTokenFile = ""
+	config.ExecProvider = nil
+	config.AuthProvider = nil
+	config.Wrap(transport.ResettableTokenSourceWrapTransport(cached))
+	return nil
+}
@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// fakeEKSGenerator hands out numbered tokens with a fixed lifetime
+type fakeEKSGenerator struct {
+	mu       sync.Mutex
+	calls    int
+	lifetime time.Duration
+}
+
+func (g *fakeEKSGenerator) generate(ctx context.Context) (string, time.Time, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	return fmt.Sprintf("token-%d", g.calls), time.Now().Add(g.lifetime), nil
+}
+
+func (g *fakeEKSGenerator) count() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+func TestEKSTokenSourceTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifetime  time.Duration
+		rejected  string // token the server answers 401 for
+		wantCalls int    // token generations after two requests
+		wantToken string // token seen by the second request
+	}{
+		{name: "reuses valid token", lifetime: 15 * time.Minute, wantCalls: 1, wantToken: "token-1"},
+		{name: "refreshes before expiry", lifetime: time.Minute, wantCalls: 3, wantToken: "token-3"},
+		{name: "refreshes after 401", lifetime: 15 * time.Minute, rejected: "token-1", wantCalls: 2, wantToken: "token-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var seen []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth := r.Header.Get("Authorization")
+				mu.Lock()
+				seen = append(seen, auth)
+				mu.Unlock()
+				if auth == "Bearer "+tt.rejected {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			gen := &fakeEKSGenerator{lifetime: tt.lifetime}
+			source := &eksTokenSource{contextName: "test", generate: gen.generate}
+			config := &rest.Config{Host: server.URL, BearerToken: "static"}
+			if err := source.install(config); err != nil {
+				t.Fatalf("install() error = %v", err)
+			}
+
+			client, err := rest.HTTPClientFor(config)
+			if err != nil {
+				t.Fatalf("HTTPClientFor() error = %v", err)
+			}
+			for i := 0; i < 2; i++ {
+				resp, err := client.Get(server.URL)
+				if err != nil {
+					t.Fatalf("request %d error = %v", i, err)
+				}
+				resp.Body.Close()
+			}
+
+			if got := gen.count(); got != tt.wantCalls {
+				t.Errorf("token generations = %d, want %d", got, tt.wantCalls)
+			}
+			if got := seen[len(seen)-1]; got != "Bearer "+tt.wantToken {
+				t.Errorf("last Authorization = %q, want %q", got, "Bearer "+tt.wantToken)
+			}
+		})
+	}
+}
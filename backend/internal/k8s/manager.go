@@ -40,9 +40,15 @@ type ClientManager struct {
 	ssoClient  *aws.SSOClient
 	ssoStorage *aws.Storage
 
-	// Cached EKS token info for current context
-	cachedToken       string
-	cachedTokenExpiry time.Time
+	// Native EKS token source for the current context, nil when kubeconfig auth is used
+	tokenSource *eksTokenSource
+
+	// Lazily built clients and informer caches for every context used by a request,
+	// keyed by context name
+	poolMu   sync.Mutex
+	clients  map[string]*contextClient
+	building map[string]*clientBuild // first builds in flight, shared by concurrent requests
+	caches   map[string]*ResourceCache
 
 	// Callback for notifying when context changes (for WebSocket cleanup)
 	onContextChange func()
@@ -56,6 +62,9 @@ func NewClientManager() (*ClientManager, error) {
 		// Initialize AWS SSO client for native EKS authentication
 		ssoClient:  aws.NewSSOClient(),
 		ssoStorage: aws.NewStorage(),
+		clients:    make(map[string]*contextClient),
+		building:   make(map[string]*clientBuild),
+		caches:     make(map[string]*ResourceCache),
 	}
 
 	// Find kubeconfig path
@@ -109,15 +118,63 @@ func (cm *ClientManager) loadRawConfigOnly() error {
 	return nil
 }
 
+// contextClient is a ready-to-use client for a single kubeconfig context
+type contextClient struct {
+	clientset   *kubernetes.Clientset
+	config      *rest.Config
+	tokenSource *eksTokenSource // native EKS auth, nil when kubeconfig auth is used
+}
+
+// clientBuild is a client being built for a context; done is closed once client or err is set
+type clientBuild struct {
+	done   chan struct{}
+	client *contextClient
+	err    error
+}
+
 // loadConfig loads/reloads the kubeconfig using the specified context
 // If contextName is empty, uses the current-context from kubeconfig
 func (cm *ClientManager) loadConfig(contextName string) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
+	client, rawConfig, err := cm.buildClient(contextName)
+	if rawConfig != nil {
+		cm.rawConfig = rawConfig
+		// Determine current context
+		if contextName != "" {
+			cm.currentContext = contextName
+		} else {
+			cm.currentContext = rawConfig.CurrentContext
+		}
+	}
+
+	// Reset native auth state
+	cm.tokenSource = nil
+
+	if err != nil {
+		return err
+	}
+
+	cm.config = client.config
+	cm.clientset = client.clientset
+	cm.tokenSource = client.tokenSource
+
+	// The current context shares the pool so per-request lookups reuse this client
+	cm.poolMu.Lock()
+	cm.clients[cm.currentContext] = client
+	cm.poolMu.Unlock()
+
+	return nil
+}
+
+// buildClient loads the kubeconfig and creates a client for the given context
+// (the kubeconfig's current-context if empty). The raw kubeconfig is returned even
+// when building the client fails so callers can still list contexts.
+func (cm *ClientManager) buildClient(contextName string) (*contextClient, *api.Config, error) {
 	// Check if kubeconfig file exists
 	if _, err := os.Stat(cm.kubeconfigPath); os.IsNotExist(err) {
-		return fmt.Errorf("kubeconfig not found at %s", cm.kubeconfigPath)
+		return nil, nil, fmt.Errorf("kubeconfig not found at %s", cm.kubeconfigPath)
 	}
 
 	// Load raw kubeconfig
@@ -133,26 +190,20 @@ func (cm *ClientManager) loadConfig(contextName string) error {
 	// Get raw config for context listing
 	rawConfig, err := kubeConfig.RawConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load raw kubeconfig: %w", err)
+		return nil, nil, fmt.Errorf("failed to load raw kubeconfig: %w", err)
 	}
-	cm.rawConfig = &rawConfig
 
-	// Determine current context
-	if contextName != "" {
-		cm.currentContext = contextName
-	} else {
-		cm.currentContext = rawConfig.CurrentContext
+	if contextName == "" {
+		contextName = rawConfig.CurrentContext
 	}
 
 	// Build REST config
 	config, err := kubeConfig.ClientConfig()
 	if err != nil {
-		return fmt.Errorf("failed to build config for context '%s': %w", cm.currentContext, err)
+		return nil, &rawConfig, fmt.Errorf("failed to build config for context '%s': %w", contextName, err)
 	}
 
-	// Reset cached token state
-	cm.cachedToken = ""
-	cm.cachedTokenExpiry = time.Time{}
+	client := &contextClient{config: config}
 
 	// ⚡️ CHECK FOR SSO MAPPING - Native EKS Authentication
 	// If this context is mapped to an AWS SSO role, generate a native EKS token
@@ -161,42 +212,42 @@ func (cm *ClientManager) loadConfig(contextName string) error {
 
 	if cm.ssoStorage != nil {
 		var mappingErr error
-		mapping, mappingErr = cm.ssoStorage.GetContextMapping(cm.currentContext)
+		mapping, mappingErr = cm.ssoStorage.GetContextMapping(contextName)
 		hasBridgeMapping = (mappingErr == nil && mapping != nil)
 	}
 
 	if hasBridgeMapping {
 		// ✅ [Happy Path] Bridge handles Auth
 		log.Printf("✅ [Auth] Bridge Identity used for context: %s -> %s/%s",
-			cm.currentContext, mapping.AccountId, mapping.RoleName)
+			contextName, mapping.AccountId, mapping.RoleName)
 
 		// Extract cluster name from the context/cluster ARN
-		clusterName := cm.extractClusterName(cm.currentContext, &rawConfig)
+		clusterName := cm.extractClusterName(contextName, &rawConfig)
 
 		if clusterName != "" {
-			// Try to generate native EKS token
-			token, expiry, tokenErr := cm.generateNativeEKSToken(context.Background(), mapping, clusterName)
-			if tokenErr != nil {
+			// Native EKS token source: tokens are regenerated from the SSO mapping
+			// shortly before they expire and whenever the API server answers 401
+			source := &eksTokenSource{
+				contextName: contextName,
+				generate: func(ctx context.Context) (string, time.Time, error) {
+					return cm.generateNativeEKSToken(ctx, mapping, clusterName)
+				},
+			}
+			if tokenErr := source.install(config); tokenErr != nil {
 				// Wrap error clearly for better debugging
-				log.Printf("❌ [Auth] Bridge SSO Error for '%s': %v", cm.currentContext, tokenErr)
+				log.Printf("❌ [Auth] Bridge SSO Error for '%s': %v", contextName, tokenErr)
 				// Block the CLI fallback to prevent ugly errors
 				config.ExecProvider = nil
-				return fmt.Errorf("Bridge SSO Error: Failed to generate token for '%s'. Please check your session expiry. Error: %w", cm.currentContext, tokenErr)
+				return nil, &rawConfig, fmt.Errorf("Bridge SSO Error: Failed to generate token for '%s'. Please check your session expiry. Error: %w", contextName, tokenErr)
 			}
 
-			log.Printf("✅ [Auth] Native EKS token generated (expires: %s)", expiry.Format(time.RFC3339))
-
-			// ⚡️ OVERRIDE: Remove the 'Exec' provider (stop it from calling 'aws-iam-authenticator')
-			config.ExecProvider = nil
-
-			// ⚡️ INJECT: Set the Bearer Token directly
-			config.BearerToken = token
+			log.Printf("✅ [Auth] Native EKS token generated (expires: %s)", source.Expiry().Format(time.RFC3339))
 
-			// Cache the token
-			cm.cachedToken = token
-			cm.cachedTokenExpiry = expiry
+			// ⚡️ install() removed the 'Exec' provider (no 'aws-iam-authenticator') and
+			// injects the bearer token through the transport instead
+			client.tokenSource = source
 		} else {
-			log.Printf("⚠️ [Auth] Could not extract cluster name for '%s'. Bridge auth disabled.", cm.currentContext)
+			log.Printf("⚠️ [Auth] Could not extract cluster name for '%s'. Bridge auth disabled.", contextName)
 			// Still block AWS CLI even if we can't extract cluster name
 			config.ExecProvider = nil
 		}
@@ -204,21 +255,19 @@ func (cm *ClientManager) loadConfig(contextName string) error {
 		// ℹ️ [Passthrough Mode] No Bridge mapping exists
 		// Let client-go handle authentication naturally.
 		// This supports Minikube, Docker Desktop, and users with their own ~/.aws/credentials.
-		log.Printf("ℹ️ [Auth] No Bridge Identity for '%s'. Using standard kubeconfig auth.", cm.currentContext)
+		log.Printf("ℹ️ [Auth] No Bridge Identity for '%s'. Using standard kubeconfig auth.", contextName)
 	}
 
-	cm.config = config
-
 	// Create clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return fmt.Errorf("failed to create clientset for context '%s': %w", cm.currentContext, err)
+		return nil, &rawConfig, fmt.Errorf("failed to create clientset for context '%s': %w", contextName, err)
 	}
-	cm.clientset = clientset
+	client.clientset = clientset
 
-	log.Printf("✅ [Context] Loaded: %s (cluster: %s)", cm.currentContext, config.Host)
+	log.Printf("✅ [Context] Loaded: %s (cluster: %s)", contextName, config.Host)
 
-	return nil
+	return client, &rawConfig, nil
 }
 
 // generateNativeEKSToken generates an EKS bearer token using native AWS SDK
@@ -294,61 +343,21 @@ func (cm *ClientManager) extractClusterName(contextName string, rawConfig *api.C
 	return ""
 }
 
-// RefreshTokenIfNeeded checks if the cached token is about to expire and refreshes it
-func (cm *ClientManager) RefreshTokenIfNeeded() error {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
-
-	// Check if we have a cached token and if it's about to expire (within 2 minutes)
-	if cm.cachedToken != "" && time.Now().Add(2*time.Minute).After(cm.cachedTokenExpiry) {
-		log.Printf("[ClientManager] Token expiring soon, refreshing...")
-
-		// Get the mapping for current context
-		mapping, err := cm.ssoStorage.GetContextMapping(cm.currentContext)
-		if err != nil {
-			return fmt.Errorf("no mapping found for context refresh: %w", err)
-		}
-
-		clusterName := cm.extractClusterName(cm.currentContext, cm.rawConfig)
-		if clusterName == "" {
-			return fmt.Errorf("could not determine cluster name for token refresh")
-		}
-
-		token, expiry, err := cm.generateNativeEKSToken(context.Background(), mapping, clusterName)
-		if err != nil {
-			return fmt.Errorf("failed to refresh token: %w", err)
-		}
-
-		// Update the config and cache
-		cm.config.BearerToken = token
-		cm.cachedToken = token
-		cm.cachedTokenExpiry = expiry
-
-		// Recreate clientset with new token
-		clientset, err := kubernetes.NewForConfig(cm.config)
-		if err != nil {
-			return fmt.Errorf("failed to recreate clientset: %w", err)
-		}
-		cm.clientset = clientset
-
-		log.Printf("[ClientManager] Token refreshed successfully (expires: %s)", expiry.Format(time.RFC3339))
-	}
-
-	return nil
-}
-
 // IsUsingNativeAuth returns true if the current context is using native EKS authentication
 func (cm *ClientManager) IsUsingNativeAuth() bool {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
-	return cm.cachedToken != ""
+	return cm.tokenSource != nil
 }
 
 // GetTokenExpiry returns when the current native token expires (if using native auth)
 func (cm *ClientManager) GetTokenExpiry() time.Time {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
-	return cm.cachedTokenExpiry
+	if cm.tokenSource == nil {
+		return time.Time{}
+	}
+	return cm.tokenSource.Expiry()
 }
 
 // GetClientset returns the current Kubernetes clientset
@@ -408,6 +417,113 @@ func (cm *ClientManager) GetConfig() (*rest.Config, error) {
 	return config, nil
 }
 
+// clientFor returns the cached client for a context, building it on first use.
+// An empty name selects the current context.
+func (cm *ClientManager) clientFor(contextName string) (*contextClient, error) {
+	if contextName == "" {
+		clientset, err := cm.GetClientset()
+		if err != nil {
+			return nil, err
+		}
+		config, err := cm.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+		return &contextClient{clientset: clientset, config: config}, nil
+	}
+
+	if !cm.HasContext(contextName) {
+		return nil, fmt.Errorf("context '%s' not found in kubeconfig", contextName)
+	}
+
+	cm.poolMu.Lock()
+	if client, ok := cm.clients[contextName]; ok {
+		cm.poolMu.Unlock()
+		return client, nil
+	}
+	// Concurrent first requests wait for a single build
+	build, inFlight := cm.building[contextName]
+	if !inFlight {
+		build = &clientBuild{done: make(chan struct{})}
+		if cm.building == nil {
+			cm.building = make(map[string]*clientBuild)
+		}
+		cm.building[contextName] = build
+	}
+	cm.poolMu.Unlock()
+
+	if inFlight {
+		<-build.done
+		return build.client, build.err
+	}
+
+	// Build outside the lock: native EKS auth calls out to AWS
+	client, _, err := cm.buildClient(contextName)
+	if err != nil {
+		err = fmt.Errorf("kubernetes client for context '%s' not initialized (please check SSO status): %w", contextName, err)
+	}
+
+	cm.poolMu.Lock()
+	// Not cached if the context was invalidated meanwhile; the next request rebuilds it
+	if err == nil && cm.building[contextName] == build {
+		cm.clients[contextName] = client
+		log.Printf("[ClientManager] Cached client for context: %s", contextName)
+	}
+	if cm.building[contextName] == build {
+		delete(cm.building, contextName)
+	}
+	cm.poolMu.Unlock()
+
+	build.client, build.err = client, err
+	close(build.done)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// GetClientsetForContext returns the clientset for a specific context without
+// changing the current context. An empty name selects the current context.
+func (cm *ClientManager) GetClientsetForContext(contextName string) (*kubernetes.Clientset, error) {
+	client, err := cm.clientFor(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return client.clientset, nil
+}
+
+// GetConfigForContext returns the REST config for a specific context without
+// changing the current context. An empty name selects the current context.
+func (cm *ClientManager) GetConfigForContext(contextName string) (*rest.Config, error) {
+	client, err := cm.clientFor(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return client.config, nil
+}
+
+// InvalidateContext drops the cached client for a context so the next request
+// rebuilds it (e.g. after its SSO mapping changed)
+func (cm *ClientManager) InvalidateContext(contextName string) {
+	cm.poolMu.Lock()
+	delete(cm.clients, contextName)
+	delete(cm.building, contextName)
+	cm.poolMu.Unlock()
+
+	cm.stopCache(contextName)
+}
+
+// HasContext reports whether the kubeconfig defines the given context
+func (cm *ClientManager) HasContext(contextName string) bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if cm.rawConfig == nil {
+		return false
+	}
+	_, exists := cm.rawConfig.Contexts[contextName]
+	return exists
+}
+
 // GetCurrentContext returns the name of the current context
 func (cm *ClientManager) GetCurrentContext() string {
 	cm.mu.RLock()
@@ -457,11 +573,19 @@ func (cm *ClientManager) SwitchContext(contextName string) error {
 		cm.onContextChange()
 	}
 
+	previousContext := cm.GetCurrentContext()
+
 	// Reload config with new context
 	if err := cm.loadConfig(contextName); err != nil {
 		return err
 	}
 
+	// Tear down informers of the context we switched away from; the new
+	// context's cache is rebuilt on next use since its client was replaced
+	if previousContext != contextName {
+		cm.stopCache(previousContext)
+	}
+
 	log.Printf("[ClientManager] Switched to context: %s", contextName)
 	return nil
 }
@@ -501,3 +625,52 @@ func (cm *ClientManager) GetClusterInfo() (string, string, string) {
 
 	return cm.currentContext, clusterName, serverURL
 }
+
+// GetClusterInfoForContext returns information about the cluster behind any context.
+// An empty name selects the current context.
+// Returns: contextName, clusterName, serverURL
+func (cm *ClientManager) GetClusterInfoForContext(contextName string) (string, string, string) {
+	if contextName == "" {
+		return cm.GetClusterInfo()
+	}
+
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	serverURL := ""
+	clusterName := ""
+
+	if cm.rawConfig != nil {
+		if ctx, exists := cm.rawConfig.Contexts[contextName]; exists {
+			clusterName = ctx.Cluster
+			if cluster, exists := cm.rawConfig.Clusters[ctx.Cluster]; exists {
+				serverURL = cluster.Server
+			}
+		}
+	}
+
+	return contextName, clusterName, serverURL
+}
+
+// GetClusterForContext returns the name and a copy of the kubeconfig cluster entry behind a
+// context, or nil if it has none (e.g. in-cluster config). An empty name selects the current context.
+func (cm *ClientManager) GetClusterForContext(contextName string) (string, *api.Cluster) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if contextName == "" {
+		contextName = cm.currentContext
+	}
+	if cm.rawConfig == nil {
+		return "", nil
+	}
+	ctx, exists := cm.rawConfig.Contexts[contextName]
+	if !exists {
+		return "", nil
+	}
+	cluster, exists := cm.rawConfig.Clusters[ctx.Cluster]
+	if !exists {
+		return ctx.Cluster, nil
+	}
+	return ctx.Cluster, cluster.DeepCopy()
+}
@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: staging
+  cluster:
+    server: https://staging.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: dev
+- name: prod
+  context:
+    cluster: prod
+    user: dev
+users:
+- name: dev
+  user:
+    token: test-token
+`
+
+func newTestManager(t *testing.T) *ClientManager {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	t.Setenv("HOME", dir)
+
+	cm, err := NewClientManager()
+	if err != nil {
+		t.Fatalf("NewClientManager() error = %v", err)
+	}
+	return cm
+}
+
+func TestGetConfigForContext(t *testing.T) {
+	cm := newTestManager(t)
+
+	tests := []struct {
+		name     string
+		context  string
+		wantHost string
+		wantErr  bool
+	}{
+		{name: "current context", context: "", wantHost: "https://staging.example.com"},
+		{name: "other context", context: "prod", wantHost: "https://prod.example.com"},
+		{name: "unknown context", context: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := cm.GetConfigForContext(tt.context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetConfigForContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && config.Host != tt.wantHost {
+				t.Errorf("GetConfigForContext() host = %s, want %s", config.Host, tt.wantHost)
+			}
+		})
+	}
+
+	if got := cm.GetCurrentContext(); got != "staging" {
+		t.Errorf("current context changed to %s", got)
+	}
+}
+
+func TestClientPoolCachesPerContext(t *testing.T) {
+	cm := newTestManager(t)
+	svc := NewService(cm)
+	ctx := WithKubeContext(context.Background(), "prod")
+
+	first, err := svc.ClientsetFor(ctx)
+	if err != nil {
+		t.Fatalf("ClientsetFor() error = %v", err)
+	}
+	second, err := svc.ClientsetFor(ctx)
+	if err != nil {
+		t.Fatalf("ClientsetFor() error = %v", err)
+	}
+	if first != second {
+		t.Error("ClientsetFor() built a new client instead of reusing the cached one")
+	}
+
+	cm.InvalidateContext("prod")
+	third, err := svc.ClientsetFor(ctx)
+	if err != nil {
+		t.Fatalf("ClientsetFor() error = %v", err)
+	}
+	if third == first {
+		t.Error("InvalidateContext() did not drop the cached client")
+	}
+}
+
+func TestClientPoolBuildsOncePerContext(t *testing.T) {
+	cm := newTestManager(t)
+
+	const requests = 8
+	clientsets := make([]*kubernetes.Clientset, requests)
+	var wg sync.WaitGroup
+	for i := range clientsets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clientset, err := cm.GetClientsetForContext("prod")
+			if err != nil {
+				t.Errorf("GetClientsetForContext() error = %v", err)
+				return
+			}
+			clientsets[i] = clientset
+		}(i)
+	}
+	wg.Wait()
+
+	for i, clientset := range clientsets {
+		if clientset == nil || clientset != clientsets[0] {
+			t.Fatalf("request %d got a different client; concurrent first requests should share one build", i)
+		}
+	}
+}
@@ -196,6 +196,8 @@ func NewServiceLegacy(clientset *kubernetes.Clientset, config *rest.Config) *Ser
 			clientset:      clientset,
 			config:         config,
 			currentContext: "default",
+			clients:        make(map[string]*contextClient),
+			caches:         make(map[string]*ResourceCache),
 		},
 	}
 }
@@ -212,6 +214,18 @@ func (s *Service) GetConfig() (*rest.Config, error) {
 	return s.manager.GetConfig()
 }
 
+// ClientsetFor returns the clientset for the kubeconfig context carried by ctx
+// (see WithKubeContext), falling back to the current context
+func (s *Service) ClientsetFor(ctx context.Context) (*kubernetes.Clientset, error) {
+	return s.manager.GetClientsetForContext(KubeContextFrom(ctx))
+}
+
+// ConfigFor returns the REST config for the kubeconfig context carried by ctx
+// (see WithKubeContext), falling back to the current context
+func (s *Service) ConfigFor(ctx context.Context) (*rest.Config, error) {
+	return s.manager.GetConfigForContext(KubeContextFrom(ctx))
+}
+
 // GetManager returns the underlying ClientManager
 func (s *Service) GetManager() *ClientManager {
 	return s.manager
@@ -220,12 +234,7 @@ func (s *Service) GetManager() *ClientManager {
 // ListPods lists all pods in the specified namespace
 // If namespace is empty, lists pods across all namespaces
 func (s *Service) ListPods(ctx context.Context, namespace string) ([]PodInfo, error) {
-	clientset, err := s.GetClientset()
-	if err != nil {
-		return nil, fmt.Errorf("client not ready: %w", err)
-	}
-
-	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	pods, err := s.CachedPods(ctx, namespace, nil)
 	if err != nil {
 		if namespace == "" {
 			return nil, fmt.Errorf("failed to list pods in all namespaces: %w", err)
@@ -233,9 +242,9 @@ func (s *Service) ListPods(ctx context.Context, namespace string) ([]PodInfo, er
 		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
 	}
 
-	result := make([]PodInfo, 0, len(pods.Items))
-	for _, pod := range pods.Items {
-		result = append(result, podToPodInfo(&pod))
+	result := make([]PodInfo, 0, len(pods))
+	for _, pod := range pods {
+		result = append(result, PodToPodInfo(&pod))
 	}
 
 	return result, nil
@@ -243,7 +252,7 @@ func (s *Service) ListPods(ctx context.Context, namespace string) ([]PodInfo, er
 
 // GetPod retrieves a single pod by name and namespace
 func (s *Service) GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
-	clientset, err := s.GetClientset()
+	clientset, err := s.ClientsetFor(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("client not ready: %w", err)
 	}
@@ -318,7 +327,7 @@ func (s *Service) GetPodDetail(ctx context.Context, namespace, name string) (*Po
 
 // GetPodLogs returns a stream of logs for a pod
 func (s *Service) GetPodLogs(ctx context.Context, namespace, name string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
-	clientset, err := s.GetClientset()
+	clientset, err := s.ClientsetFor(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("client not ready: %w", err)
 	}
@@ -333,32 +342,27 @@ func (s *Service) GetPodLogs(ctx context.Context, namespace, name string, opts *
 
 // ListNodes lists all nodes with resource metrics
 func (s *Service) ListNodes(ctx context.Context) ([]NodeInfo, error) {
-	clientset, err := s.GetClientset()
-	if err != nil {
-		return nil, fmt.Errorf("client not ready: %w", err)
-	}
-
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := s.CachedNodes(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list nodes: %w", err)
 	}
 
 	// Get pod counts per node
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	pods, err := s.CachedPods(ctx, "", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
 
 	// Count pods per node
 	podCountByNode := make(map[string]int)
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		if pod.Spec.NodeName != "" {
 			podCountByNode[pod.Spec.NodeName]++
 		}
 	}
 
-	result := make([]NodeInfo, 0, len(nodes.Items))
-	for _, node := range nodes.Items {
+	result := make([]NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
 		result = append(result, nodeToNodeInfo(&node, podCountByNode[node.Name]))
 	}
 
@@ -493,18 +497,13 @@ func (s *Service) ListConfigMaps(ctx context.Context, namespace string) ([]Confi
 		namespace = "default"
 	}
 
-	clientset, err := s.GetClientset()
-	if err != nil {
-		return nil, fmt.Errorf("client not ready: %w", err)
-	}
-
-	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	configMaps, err := s.CachedConfigMaps(ctx, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list configmaps in namespace %s: %w", namespace, err)
 	}
 
-	result := make([]ConfigMapInfo, 0, len(configMaps.Items))
-	for _, cm := range configMaps.Items {
+	result := make([]ConfigMapInfo, 0, len(configMaps))
+	for _, cm := range configMaps {
 		keys := make([]string, 0, len(cm.Data))
 		for key := range cm.Data {
 			keys = append(keys, key)
@@ -522,7 +521,7 @@ func (s *Service) ListConfigMaps(ctx context.Context, namespace string) ([]Confi
 
 // GetConfigMap retrieves a ConfigMap with its data
 func (s *Service) GetConfigMap(ctx context.Context, namespace, name string) (*ConfigMapInfo, error) {
-	clientset, err := s.GetClientset()
+	clientset, err := s.ClientsetFor(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("client not ready: %w", err)
 	}
@@ -552,7 +551,7 @@ func (s *Service) ListSecrets(ctx context.Context, namespace string) ([]SecretIn
 		namespace = "default"
 	}
 
-	clientset, err := s.GetClientset()
+	clientset, err := s.ClientsetFor(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("client not ready: %w", err)
 	}
@@ -582,7 +581,7 @@ func (s *Service) ListSecrets(ctx context.Context, namespace string) ([]SecretIn
 
 // GetSecret retrieves a Secret (raw bytes - caller should decode)
 func (s *Service) GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
-	clientset, err := s.GetClientset()
+	clientset, err := s.ClientsetFor(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("client not ready: %w", err)
 	}
@@ -608,8 +607,8 @@ func getContainerState(cs *corev1.ContainerStatus) string {
 	return "Unknown"
 }
 
-// podToPodInfo converts a Kubernetes Pod to our simplified PodInfo struct
-func podToPodInfo(pod *corev1.Pod) PodInfo {
+// PodToPodInfo converts a Kubernetes Pod to our simplified PodInfo struct
+func PodToPodInfo(pod *corev1.Pod) PodInfo {
 	// Calculate total restarts across all containers
 	var totalRestarts int32
 	for _, cs := range pod.Status.ContainerStatuses {
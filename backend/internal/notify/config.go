@@ -0,0 +1,272 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/waiyan/bridge/internal/access"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// KindNotifications marks the Secret holding a cluster's notification settings
+const KindNotifications = "notifications"
+
+// AnnotationExpiryNotices records which expiry notices were sent for a grant's current expiry
+const AnnotationExpiryNotices = "bridge.io/expiry-notices"
+
+const (
+	// configSecret is the Secret in bridge-system holding the settings; webhook URLs carry credentials
+	configSecret = "bridge-notifications"
+	// configKey is the Secret data key holding the settings' YAML
+	configKey = "config.yaml"
+)
+
+// maskedPath replaces the path and query of webhook URLs when they are shown
+const maskedPath = "/***"
+
+// DefaultLeadTimes are when expiry notices are sent unless configured otherwise
+var DefaultLeadTimes = []string{"24h", "1h"}
+
+// ErrInvalidConfig is wrapped by notification config validation errors
+var ErrInvalidConfig = errors.New("invalid notification config")
+
+// Webhook is an outbound notification endpoint
+type Webhook struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Format string   `json:"format,omitempty"` // "json" (default) or "slack"
+	Events []string `json:"events,omitempty"` // event types to send; empty means all
+}
+
+// wants reports whether the webhook subscribes to an event type. Test and break-glass events always go through.
+func (w Webhook) wants(eventType string) bool {
+	if len(w.Events) == 0 || eventType == EventTest || eventType == EventBreakGlass {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is a cluster's notification settings
+type Config struct {
+	Webhooks  []Webhook `json:"webhooks"`
+	LeadTimes []string  `json:"leadTimes"` // how long before expiry to warn, e.g. ["24h", "1h"]
+}
+
+// Leads returns the parsed lead times, longest first; invalid entries are skipped
+func (c *Config) Leads() []time.Duration {
+	var leads []time.Duration
+	for _, value := range c.LeadTimes {
+		if d, err := access.ParseDuration(value); err == nil && d > 0 {
+			leads = append(leads, d)
+		}
+	}
+	sort.Slice(leads, func(i, j int) bool { return leads[i] > leads[j] })
+	return leads
+}
+
+// Validate checks webhook URLs, formats and events, and lead times
+func (c *Config) Validate() error {
+	events := map[string]bool{EventGrantCreated: true, EventGrantRevoked: true, EventGrantExpiring: true, EventGrantCleaned: true, EventBreakGlass: true}
+	seen := make(map[string]bool)
+	for _, w := range c.Webhooks {
+		if w.Name == "" {
+			return fmt.Errorf("%w: every webhook needs a name", ErrInvalidConfig)
+		}
+		if seen[w.Name] {
+			return fmt.Errorf("%w: duplicate webhook name %q", ErrInvalidConfig, w.Name)
+		}
+		seen[w.Name] = true
+		if err := validateURL(w.URL); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, w.Name, err)
+		}
+		if w.Format != "" && w.Format != FormatJSON && w.Format != FormatSlack {
+			return fmt.Errorf("%w: %s: format must be json or slack", ErrInvalidConfig, w.Name)
+		}
+		for _, e := range w.Events {
+			if !events[e] {
+				return fmt.Errorf("%w: %s: unknown event %q", ErrInvalidConfig, w.Name, e)
+			}
+		}
+	}
+	for _, value := range c.LeadTimes {
+		if d, err := access.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("%w: lead time %q must be a positive duration like 1h or 1d", ErrInvalidConfig, value)
+		}
+	}
+	return nil
+}
+
+// MaskURL hides everything after the host of a webhook URL, where its credentials usually are
+func MaskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return maskedPath
+	}
+	return u.Scheme + "://" + u.Host + maskedPath
+}
+
+// IsMasked reports whether a URL is a masked webhook URL rather than a real one
+func IsMasked(raw string) bool {
+	return strings.HasSuffix(raw, maskedPath)
+}
+
+// Masked returns a copy of the config with every webhook URL masked
+func (c *Config) Masked() *Config {
+	masked := &Config{Webhooks: make([]Webhook, len(c.Webhooks)), LeadTimes: c.LeadTimes}
+	for i, w := range c.Webhooks {
+		w.URL = MaskURL(w.URL)
+		masked.Webhooks[i] = w
+	}
+	return masked
+}
+
+// KeepMaskedURLs puts back the stored URL of every webhook that was sent back masked, matched
+// by name, so a config read with its URLs masked can be saved again unchanged
+func (c *Config) KeepMaskedURLs(stored *Config) error {
+	urls := make(map[string]string, len(stored.Webhooks))
+	for _, w := range stored.Webhooks {
+		urls[w.Name] = w.URL
+	}
+	for i := range c.Webhooks {
+		w := &c.Webhooks[i]
+		if !IsMasked(w.URL) {
+			continue
+		}
+		full, ok := urls[w.Name]
+		if !ok || MaskURL(full) != w.URL {
+			return fmt.Errorf("%w: %s: the URL is masked; send the full URL", ErrInvalidConfig, w.Name)
+		}
+		w.URL = full
+	}
+	return nil
+}
+
+// validateURL accepts absolute http and https URLs
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// Config returns the notification settings of the kube context carried by ctx
+func (n *Notifier) Config(ctx context.Context) (*Config, error) {
+	clientset, err := n.clientsetFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{Webhooks: []Webhook{}, LeadTimes: DefaultLeadTimes}
+	secret, err := clientset.CoreV1().Secrets(access.SystemNamespace).Get(ctx, configSecret, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(secret.Data[configKey], config); err != nil {
+		return nil, fmt.Errorf("notification settings in %s/%s are malformed: %w", access.SystemNamespace, configSecret, err)
+	}
+	if config.Webhooks == nil {
+		config.Webhooks = []Webhook{}
+	}
+	return config, nil
+}
+
+// SaveConfig validates and replaces the notification settings of the kube context carried by ctx
+func (n *Notifier) SaveConfig(ctx context.Context, config *Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := n.clientsetFor(ctx)
+	if err != nil {
+		return err
+	}
+	if err := access.EnsureSystemNamespace(ctx, clientset); err != nil {
+		return err
+	}
+
+	secrets := clientset.CoreV1().Secrets(access.SystemNamespace)
+	secret, err := secrets.Get(ctx, configSecret, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configSecret,
+				Namespace: access.SystemNamespace,
+				Labels: map[string]string{
+					access.LabelManagedBy: access.ManagedByBridge,
+					access.LabelKind:      KindNotifications,
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{configKey: data},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[configKey] = data
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+// DueNotice decides whether an expiry notice is due for a grant expiring at expiresAt.
+// recorded is the grant's AnnotationExpiryNotices value. It returns the lead time to
+// notify for and the new annotation value; ok is false if nothing is due. When several
+// lead times have passed (e.g. Bridge was not running), only the shortest is sent.
+func DueNotice(leads []time.Duration, expiresAt, now time.Time, recorded string) (lead time.Duration, record string, ok bool) {
+	remaining := expiresAt.Sub(now)
+	if remaining <= 0 || len(leads) == 0 {
+		return 0, "", false
+	}
+
+	expiry := expiresAt.UTC().Format(time.RFC3339)
+	sent := make(map[string]bool)
+	if recordedExpiry, list, found := strings.Cut(recorded, "/"); found && recordedExpiry == expiry {
+		// Notices recorded for an earlier expiry (before an extension) don't count
+		for _, s := range strings.Split(list, ",") {
+			sent[s] = true
+		}
+	}
+
+	var passed []string
+	for _, l := range leads {
+		if remaining > l {
+			continue
+		}
+		passed = append(passed, l.String())
+		if !sent[l.String()] && (lead == 0 || l < lead) {
+			lead, ok = l, true
+		}
+	}
+	if !ok {
+		return 0, "", false
+	}
+	return lead, expiry + "/" + strings.Join(passed, ","), true
+}
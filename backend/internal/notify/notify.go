@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/waiyan/bridge/internal/access"
+	"github.com/waiyan/bridge/internal/k8s"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Event types
+const (
+	EventGrantCreated  = "grant.created"
+	EventGrantRevoked  = "grant.revoked"
+	EventGrantExpiring = "grant.expiring"
+	EventGrantCleaned  = "grant.cleaned"
+	EventBreakGlass    = "grant.break-glass"
+	EventTest          = "test"
+)
+
+// Webhook payload formats
+const (
+	FormatJSON  = "json"  // the Event itself
+	FormatSlack = "slack" // {"text": "..."}, accepted by Slack and compatible incoming webhooks
+)
+
+const (
+	// deliveryAttempts is how many times a webhook is tried before giving up
+	deliveryAttempts = 5
+	// initialBackoff is the wait before the first retry; it doubles after each attempt
+	initialBackoff = time.Second
+	// deliveryTimeout bounds a single webhook request
+	deliveryTimeout = 10 * time.Second
+)
+
+// Grant describes the grant an event is about
+type Grant struct {
+	Name       string     `json:"name"`
+	Namespace  string     `json:"namespace"`
+	User       string     `json:"user,omitempty"`
+	Subject    string     `json:"subject,omitempty"` // "User:<name>" or "Group:<name>" for identity grants
+	Scope      string     `json:"scope,omitempty"`
+	Namespaces []string   `json:"namespaces,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Event is a notification sent to webhooks
+type Event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Context  string    `json:"context,omitempty"` // kube context of the grant
+	Actor    string    `json:"actor,omitempty"`
+	Grant    *Grant    `json:"grant,omitempty"`
+	LeadTime string    `json:"leadTime,omitempty"` // grant.expiring: the lead time that fired it
+	Reason   string    `json:"reason,omitempty"`   // grant.break-glass: the justification
+	Message  string    `json:"message"`
+}
+
+// grantFrom describes an access grant
+func grantFrom(g *access.Grant) *Grant {
+	grant := &Grant{
+		Name:       g.Name,
+		Namespace:  g.Namespace,
+		User:       g.Username,
+		Scope:      g.Scope(),
+		Namespaces: g.Namespaces,
+	}
+	if g.Subject != nil {
+		grant.Subject = g.Subject.String()
+	}
+	if !g.ExpiresAt.IsZero() {
+		expiresAt := g.ExpiresAt
+		grant.ExpiresAt = &expiresAt
+	}
+	return grant
+}
+
+// GrantCreated is sent when a grant is created
+func GrantCreated(g *access.Grant, actor string) Event {
+	message := fmt.Sprintf("Access '%s' was granted in %s", g.Name, describeGrant(g))
+	if !g.ExpiresAt.IsZero() {
+		message += fmt.Sprintf(" until %s", g.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	if actor != "" {
+		message += " by " + actor
+	}
+	return Event{Type: EventGrantCreated, Time: time.Now().UTC(), Actor: actor, Grant: grantFrom(g), Message: message}
+}
+
+// BreakGlass is sent instead of GrantCreated when someone takes break-glass access
+func BreakGlass(g *access.Grant, actor string) Event {
+	message := fmt.Sprintf("BREAK-GLASS: %s took emergency access '%s' in %s until %s. Justification: %s",
+		actor, g.Name, describeGrant(g), g.ExpiresAt.UTC().Format(time.RFC3339), g.Justification)
+	return Event{Type: EventBreakGlass, Time: time.Now().UTC(), Actor: actor, Grant: grantFrom(g), Reason: g.Justification, Message: message}
+}
+
+// GrantRevoked is sent when a grant is revoked over the API
+func GrantRevoked(namespace, name, actor string) Event {
+	message := fmt.Sprintf("Access '%s' in namespace '%s' was revoked", name, namespace)
+	if actor != "" {
+		message += " by " + actor
+	}
+	return Event{Type: EventGrantRevoked, Time: time.Now().UTC(), Actor: actor, Grant: &Grant{Name: name, Namespace: namespace}, Message: message}
+}
+
+// GrantExpiring is sent once per lead time before a grant expires
+func GrantExpiring(g *access.Grant, lead time.Duration) Event {
+	return Event{
+		Type:     EventGrantExpiring,
+		Time:     time.Now().UTC(),
+		Grant:    grantFrom(g),
+		LeadTime: lead.String(),
+		Message: fmt.Sprintf("Access '%s' in %s expires in %s (at %s)", g.Name, describeGrant(g),
+			time.Until(g.ExpiresAt).Round(time.Minute), g.ExpiresAt.UTC().Format(time.RFC3339)),
+	}
+}
+
+// GrantCleaned is sent when the janitor removes an expired grant
+func GrantCleaned(g *access.Grant) Event {
+	return Event{
+		Type:    EventGrantCleaned,
+		Time:    time.Now().UTC(),
+		Actor:   "janitor",
+		Grant:   grantFrom(g),
+		Message: fmt.Sprintf("Expired access '%s' in %s was cleaned up by the janitor", g.Name, describeGrant(g)),
+	}
+}
+
+// TestEvent is sent by the test endpoint
+func TestEvent(actor string) Event {
+	return Event{Type: EventTest, Time: time.Now().UTC(), Actor: actor, Message: "Test notification from Bridge"}
+}
+
+func describeGrant(g *access.Grant) string {
+	if g.ClusterScope {
+		return "the whole cluster"
+	}
+	if len(g.Namespaces) > 1 {
+		return fmt.Sprintf("namespaces '%s'", strings.Join(g.Namespaces, "', '"))
+	}
+	return fmt.Sprintf("namespace '%s'", g.Namespace)
+}
+
+// Delivery is the outcome of sending an event to one webhook
+type Delivery struct {
+	Webhook    string `json:"webhook"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Attempts   int    `json:"attempts"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Notifier sends events to the webhooks configured in each cluster
+type Notifier struct {
+	clientsetFor   func(ctx context.Context) (kubernetes.Interface, error)
+	currentContext func() string
+	client         *http.Client
+	backoff        time.Duration
+}
+
+// NewNotifier creates a new Notifier
+func NewNotifier(k8sService *k8s.Service) *Notifier {
+	return &Notifier{
+		clientsetFor: func(ctx context.Context) (kubernetes.Interface, error) {
+			return k8sService.ClientsetFor(ctx)
+		},
+		currentContext: k8sService.GetManager().GetCurrentContext,
+		client:         &http.Client{Timeout: deliveryTimeout},
+		backoff:        initialBackoff,
+	}
+}
+
+// Notify sends an event to the webhooks of the kube context carried by ctx that
+// subscribe to it. Delivery, including retries, happens in the background.
+func (n *Notifier) Notify(ctx context.Context, e Event) {
+	contextName := k8s.KubeContextFrom(ctx)
+	if e.Context == "" {
+		e.Context = contextName
+		if e.Context == "" && n.currentContext != nil {
+			e.Context = n.currentContext()
+		}
+	}
+
+	go func() {
+		ctx := k8s.WithKubeContext(context.Background(), contextName)
+		config, err := n.Config(ctx)
+		if err != nil {
+			log.Printf("[Notify] Not sending %s: %v", e.Type, err)
+			return
+		}
+		for _, w := range config.Webhooks {
+			if !w.wants(e.Type) {
+				continue
+			}
+			if d := n.Deliver(ctx, w, e); d.Error != "" {
+				log.Printf("[Notify] Failed to send %s to %s after %d attempt(s): %s", e.Type, w.Name, d.Attempts, d.Error)
+			}
+		}
+	}()
+}
+
+// payload renders an event in a webhook's format
+func payload(format string, e Event) ([]byte, error) {
+	if format == FormatSlack {
+		text := e.Message
+		if e.Context != "" {
+			text = fmt.Sprintf("[%s] %s", e.Context, text)
+		}
+		return json.Marshal(map[string]string{"text": text})
+	}
+	return json.Marshal(e)
+}
+
+// Deliver sends an event to one webhook, retrying network errors, 429s and 5xx
+// responses with exponential backoff
+func (n *Notifier) Deliver(ctx context.Context, w Webhook, e Event) Delivery {
+	d := Delivery{Webhook: w.Name}
+	body, err := payload(w.Format, e)
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+
+	backoff := n.backoff
+	for d.Attempts < deliveryAttempts {
+		d.Attempts++
+		status, err := n.post(ctx, w.URL, body)
+		d.StatusCode = status
+		switch {
+		case err != nil:
+			d.Error = err.Error()
+		case status >= 200 && status < 300:
+			d.Error = ""
+			return d
+		default:
+			d.Error = fmt.Sprintf("webhook returned %d", status)
+			if status != http.StatusTooManyRequests && status < 500 {
+				return d
+			}
+		}
+
+		if d.Attempts == deliveryAttempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			d.Error = ctx.Err().Error()
+			return d
+		}
+	}
+	return d
+}
+
+func (n *Notifier) post(ctx context.Context, url string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bridge-notifier")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestNotifier(clientset kubernetes.Interface) *Notifier {
+	return &Notifier{
+		clientsetFor: func(ctx context.Context) (kubernetes.Interface, error) { return clientset, nil },
+		client:       &http.Client{Timeout: time.Second},
+		backoff:      time.Millisecond,
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int // responses with failStatus before succeeding
+		failStatus   int
+		format       string
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "first try", format: FormatJSON, wantAttempts: 1},
+		{name: "retries server errors", failures: 2, failStatus: http.StatusServiceUnavailable, wantAttempts: 3},
+		{name: "retries rate limits", failures: 1, failStatus: http.StatusTooManyRequests, format: FormatSlack, wantAttempts: 2},
+		{name: "gives up after max attempts", failures: 10, failStatus: http.StatusBadGateway, wantAttempts: deliveryAttempts, wantErr: true},
+		{name: "client errors are not retried", failures: 10, failStatus: http.StatusNotFound, wantAttempts: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			var body map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(calls.Add(1)) <= tt.failures {
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+			}))
+			defer server.Close()
+
+			d := newTestNotifier(nil).Deliver(context.Background(), Webhook{Name: "hook", URL: server.URL, Format: tt.format}, TestEvent("alice"))
+			if d.Attempts != tt.wantAttempts || (d.Error != "") != tt.wantErr {
+				t.Fatalf("delivery = %+v, want %d attempt(s), error %v", d, tt.wantAttempts, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.format == FormatSlack {
+				if body["text"] != "Test notification from Bridge" {
+					t.Fatalf("slack payload = %v", body)
+				}
+			} else if body["type"] != EventTest || body["actor"] != "alice" {
+				t.Fatalf("json payload = %v", body)
+			}
+		})
+	}
+}
+
+func TestDueNotice(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	leads := []time.Duration{24 * time.Hour, time.Hour}
+	expiry := func(d time.Duration) time.Time { return now.Add(d) }
+	record := func(at time.Time, sent string) string { return at.Format(time.RFC3339) + "/" + sent }
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		recorded  string
+		wantLead  time.Duration
+		wantOK    bool
+	}{
+		{name: "not yet due", expiresAt: expiry(48 * time.Hour)},
+		{name: "first lead time", expiresAt: expiry(20 * time.Hour), wantLead: 24 * time.Hour, wantOK: true},
+		{name: "already sent", expiresAt: expiry(20 * time.Hour), recorded: record(expiry(20*time.Hour), "24h0m0s")},
+		{name: "second lead time", expiresAt: expiry(30 * time.Minute), recorded: record(expiry(30*time.Minute), "24h0m0s"), wantLead: time.Hour, wantOK: true},
+		{name: "missed lead times send only the shortest", expiresAt: expiry(30 * time.Minute), wantLead: time.Hour, wantOK: true},
+		{name: "extension resets notices", expiresAt: expiry(20 * time.Hour), recorded: record(expiry(-time.Hour), "24h0m0s,1h0m0s"), wantLead: 24 * time.Hour, wantOK: true},
+		{name: "already expired", expiresAt: expiry(-time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lead, rec, ok := DueNotice(leads, tt.expiresAt, now, tt.recorded)
+			if ok != tt.wantOK || lead != tt.wantLead {
+				t.Fatalf("DueNotice = %s, %v; want %s, %v", lead, ok, tt.wantLead, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			// Recording the notice makes it not due again
+			if _, _, again := DueNotice(leads, tt.expiresAt, now, rec); again {
+				t.Fatalf("notice still due after recording %q", rec)
+			}
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	ctx := context.Background()
+	n := newTestNotifier(fake.NewClientset())
+
+	config, err := n.Config(ctx)
+	if err != nil || len(config.Webhooks) != 0 || len(config.LeadTimes) != len(DefaultLeadTimes) {
+		t.Fatalf("default config = %+v, %v", config, err)
+	}
+
+	invalid := []Config{
+		{Webhooks: []Webhook{{Name: "a", URL: "not a url"}}},
+		{Webhooks: []Webhook{{Name: "a", URL: "https://example.com", Format: "xml"}}},
+		{Webhooks: []Webhook{{Name: "a", URL: "https://example.com", Events: []string{"grant.updated"}}}},
+		{LeadTimes: []string{"-1h"}},
+	}
+	for _, c := range invalid {
+		if err := n.SaveConfig(ctx, &c); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("SaveConfig(%+v) = %v, want ErrInvalidConfig", c, err)
+		}
+	}
+
+	want := &Config{
+		Webhooks:  []Webhook{{Name: "slack", URL: "https://hooks.example.com/x", Format: FormatSlack, Events: []string{EventGrantExpiring}}},
+		LeadTimes: []string{"1h", "2d"},
+	}
+	if err := n.SaveConfig(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := n.Config(ctx)
+	if err != nil || len(got.Webhooks) != 1 || got.Webhooks[0].URL != want.Webhooks[0].URL {
+		t.Fatalf("Config = %+v, %v", got, err)
+	}
+	if leads := got.Leads(); len(leads) != 2 || leads[0] != 48*time.Hour {
+		t.Fatalf("Leads = %v, want longest first", leads)
+	}
+	if got.Webhooks[0].wants(EventGrantCleaned) || !got.Webhooks[0].wants(EventGrantExpiring) {
+		t.Fatal("webhook event filter not applied")
+	}
+}
+
+func TestMaskedConfig(t *testing.T) {
+	stored := &Config{Webhooks: []Webhook{
+		{Name: "slack", URL: "https://hooks.slack.com/services/T000/B000/secret"},
+		{Name: "ops", URL: "https://ops.example.com/hook?token=secret"},
+	}}
+
+	masked := stored.Masked()
+	if got := masked.Webhooks[0].URL; got != "https://hooks.slack.com/***" {
+		t.Fatalf("masked URL = %q", got)
+	}
+	if stored.Webhooks[0].URL != "https://hooks.slack.com/services/T000/B000/secret" {
+		t.Fatal("Masked changed the stored config")
+	}
+
+	// Masked URLs sent back keep the stored ones; changed URLs are taken as sent
+	update := masked.Masked()
+	update.Webhooks[1].URL = "https://ops.example.com/new"
+	if err := update.KeepMaskedURLs(stored); err != nil {
+		t.Fatal(err)
+	}
+	if update.Webhooks[0].URL != stored.Webhooks[0].URL || update.Webhooks[1].URL != "https://ops.example.com/new" {
+		t.Fatalf("webhooks after KeepMaskedURLs = %+v", update.Webhooks)
+	}
+
+	// A masked URL can't be moved to another webhook, or point at another host
+	renamed := &Config{Webhooks: []Webhook{{Name: "new", URL: "https://hooks.slack.com/***"}}}
+	if err := renamed.KeepMaskedURLs(stored); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("masked URL of an unknown webhook: err = %v, want ErrInvalidConfig", err)
+	}
+	moved := &Config{Webhooks: []Webhook{{Name: "slack", URL: "https://evil.example.com/***"}}}
+	if err := moved.KeepMaskedURLs(stored); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("masked URL of another host: err = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/waiyan/bridge/internal/k8s"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -18,9 +19,10 @@ import (
 )
 
 // K8sServiceGetter interface for lazy client access
+// The kubeconfig context is taken from ctx (see k8s.WithKubeContext)
 type K8sServiceGetter interface {
-	GetClientset() (*kubernetes.Clientset, error)
-	GetConfig() (*rest.Config, error)
+	ClientsetFor(ctx context.Context) (*kubernetes.Clientset, error)
+	ConfigFor(ctx context.Context) (*rest.Config, error)
 }
 
 // TunnelStatus represents the status of a tunnel
@@ -34,6 +36,7 @@ const (
 // Tunnel represents an active port forward
 type Tunnel struct {
 	ID           string       `json:"id"`
+	Context      string       `json:"context,omitempty"` // kubeconfig context, empty for the current one
 	Namespace    string       `json:"namespace"`
 	ResourceType string       `json:"resourceType"` // "pod" or "service"
 	ResourceName string       `json:"resourceName"`
@@ -67,6 +70,7 @@ func NewManager(k8sService K8sServiceGetter) *Manager {
 
 // CreateTunnelRequest represents a request to create a tunnel
 type CreateTunnelRequest struct {
+	Context      string `json:"context,omitempty"` // kubeconfig context, empty for the current one
 	Namespace    string `json:"namespace"`
 	ResourceType string `json:"resourceType"` // "pod" or "service"
 	ResourceName string `json:"resourceName"`
@@ -77,6 +81,7 @@ type CreateTunnelRequest struct {
 // TunnelInfo represents tunnel info for API responses
 type TunnelInfo struct {
 	ID           string       `json:"id"`
+	Context      string       `json:"context,omitempty"`
 	Namespace    string       `json:"namespace"`
 	ResourceType string       `json:"resourceType"`
 	ResourceName string       `json:"resourceName"`
@@ -106,7 +111,7 @@ func (m *Manager) Create(req CreateTunnelRequest) (*TunnelInfo, error) {
 	// For services, we need to find a backing pod
 	podName := req.ResourceName
 	if strings.ToLower(req.ResourceType) == "service" {
-		clientset, err := m.k8sService.GetClientset()
+		clientset, err := m.k8sService.ClientsetFor(k8s.WithKubeContext(context.Background(), req.Context))
 		if err != nil {
 			return nil, fmt.Errorf("client not ready: %w", err)
 		}
@@ -119,6 +124,7 @@ func (m *Manager) Create(req CreateTunnelRequest) (*TunnelInfo, error) {
 
 	tunnel := &Tunnel{
 		ID:           id,
+		Context:      req.Context,
 		Namespace:    req.Namespace,
 		ResourceType: req.ResourceType,
 		ResourceName: req.ResourceName,
@@ -231,6 +237,7 @@ func (m *Manager) Get(id string) (*TunnelInfo, error) {
 func (t *Tunnel) toInfo() *TunnelInfo {
 	return &TunnelInfo{
 		ID:           t.ID,
+		Context:      t.Context,
 		Namespace:    t.Namespace,
 		ResourceType: t.ResourceType,
 		ResourceName: t.ResourceName,
@@ -273,7 +280,7 @@ func (m *Manager) startPortForward(tunnel *Tunnel) {
 	}()
 
 	// Get REST config from k8sService (lazy)
-	restConfig, err := m.k8sService.GetConfig()
+	restConfig, err := m.k8sService.ConfigFor(k8s.WithKubeContext(context.Background(), tunnel.Context))
 	if err != nil {
 		m.setTunnelError(tunnel, err)
 		return
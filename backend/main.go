@@ -3,16 +3,21 @@ package main
 import (
 	"embed"
 	"flag"
+	"fmt"
 	"io"
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/waiyan/bridge/internal/access"
 	"github.com/waiyan/bridge/internal/api"
+	"github.com/waiyan/bridge/internal/audit"
+	"github.com/waiyan/bridge/internal/auth"
 	"github.com/waiyan/bridge/internal/janitor"
 	"github.com/waiyan/bridge/internal/k8s"
 )
@@ -23,6 +28,21 @@ var frontendFS embed.FS
 func main() {
 	// Parse command-line flags
 	portFlag := flag.String("port", "", "HTTP server port (overrides PORT env var, default: 8080)")
+	bindFlag := flag.String("bind", "", "Address to listen on (overrides BRIDGE_BIND env var, default: 127.0.0.1)")
+	authFlag := flag.String("auth", "token", "API authentication mode: token or none")
+	oidcIssuerFlag := flag.String("oidc-issuer", os.Getenv("BRIDGE_OIDC_ISSUER"), "OIDC issuer URL (enables OIDC login)")
+	oidcClientIDFlag := flag.String("oidc-client-id", os.Getenv("BRIDGE_OIDC_CLIENT_ID"), "OIDC client ID")
+	oidcRedirectFlag := flag.String("oidc-redirect-url", os.Getenv("BRIDGE_OIDC_REDIRECT_URL"), "OIDC redirect URL (e.g. http://host:8080/auth/oidc/callback)")
+	oidcAllowedEmailsFlag := flag.String("oidc-allowed-emails", os.Getenv("BRIDGE_OIDC_ALLOWED_EMAILS"), "Comma-separated emails allowed to sign in with OIDC")
+	oidcAllowedDomainsFlag := flag.String("oidc-allowed-domains", os.Getenv("BRIDGE_OIDC_ALLOWED_DOMAINS"), "Comma-separated email domains allowed to sign in with OIDC")
+	oidcAllowedGroupsFlag := flag.String("oidc-allowed-groups", os.Getenv("BRIDGE_OIDC_ALLOWED_GROUPS"), "Comma-separated groups (userinfo groups claim) allowed to sign in with OIDC")
+	requireApprovalFlag := flag.Bool("require-approval", os.Getenv("BRIDGE_REQUIRE_APPROVAL") == "true", "Turn direct grants and imports into access requests that an approver must approve")
+	approversFlag := flag.String("approvers", os.Getenv("BRIDGE_APPROVERS"), "Comma-separated emails or subjects that may approve access requests (default: anyone but the requester)")
+	approverGroupsFlag := flag.String("approver-groups", os.Getenv("BRIDGE_APPROVER_GROUPS"), "Comma-separated OIDC groups whose members may approve access requests")
+	execLoginCommandsFlag := flag.String("exec-login-commands", os.Getenv("BRIDGE_EXEC_LOGIN_COMMANDS"), "Comma-separated credential plugins identity grants may use as exec login, e.g. tsh (default: none, exec logins are refused)")
+	janitorIntervalFlag := flag.Duration("janitor-interval", envDuration("BRIDGE_JANITOR_INTERVAL", 10*time.Minute), "How often the janitor cleans up expired access (overrides BRIDGE_JANITOR_INTERVAL)")
+	janitorContextsFlag := flag.String("janitor-contexts", os.Getenv("BRIDGE_JANITOR_CONTEXTS"), "Comma-separated kube contexts the janitor cleans (default: the current context)")
+	tokenRotationFlag := flag.Duration("token-rotation", envDuration("BRIDGE_TOKEN_ROTATION", 0), "Rotate permanent access tokens older than this, e.g. 720h (overrides BRIDGE_TOKEN_ROTATION, default: off)")
 	flag.Parse()
 
 	// Initialize Kubernetes ClientManager (supports dynamic context switching)
@@ -46,15 +66,61 @@ func main() {
 	// Create K8s service wrapper
 	k8sService := k8s.NewService(clientManager)
 
-	// Start the Janitor (cleanup worker) - runs every 10 minutes
-	accessJanitor := janitor.New(k8sService, 10*time.Minute)
+	// Start the Janitor (cleanup worker) - runs every --janitor-interval (10 minutes by default)
+	if *janitorIntervalFlag <= 0 {
+		log.Fatalf("--janitor-interval must be positive, got %s", *janitorIntervalFlag)
+	}
+	accessJanitor := janitor.New(k8sService, *janitorIntervalFlag, splitList(*janitorContextsFlag))
+	accessJanitor.SetTokenRotation(*tokenRotationFlag)
 	accessJanitor.Start()
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Initialize authentication for Bridge's own API
+	// The client secret is only read from the environment to keep it out of process listings
+	authConfig := auth.Config{Mode: auth.Mode(*authFlag)}
+	if *oidcIssuerFlag != "" {
+		authConfig.OIDC = &auth.OIDCConfig{
+			IssuerURL:    *oidcIssuerFlag,
+			ClientID:     *oidcClientIDFlag,
+			ClientSecret: os.Getenv("BRIDGE_OIDC_CLIENT_SECRET"),
+			RedirectURL:  *oidcRedirectFlag,
+
+			AllowedEmails:  splitList(*oidcAllowedEmailsFlag),
+			AllowedDomains: splitList(*oidcAllowedDomainsFlag),
+			AllowedGroups:  splitList(*oidcAllowedGroupsFlag),
+		}
+	}
+	authenticator, err := auth.New(authConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize authentication: %v", err)
+	}
+
+	// Approvals only mean something when approvers are named and can be told apart
+	approval := access.ApprovalPolicy{
+		Required:       *requireApprovalFlag,
+		Approvers:      splitList(*approversFlag),
+		ApproverGroups: splitList(*approverGroupsFlag),
+	}
+	if approval.Required && !approval.Restricted() {
+		log.Fatalf("--require-approval needs --approvers or --approver-groups")
+	}
+	if approval.Required && authConfig.Mode == auth.ModeNone {
+		log.Fatalf("--require-approval cannot be used with --auth=none")
+	}
+
+	// Open the audit log (~/.bridge/audit)
+	auditLogger, err := audit.NewLogger(audit.DefaultDir())
+	if err != nil {
+		log.Fatalf("Failed to initialize audit log: %v", err)
+	}
+	defer auditLogger.Close()
+	log.Printf("Audit log: %s", auditLogger.Dir())
+
+	// Initialize Gin router; the access log redacts the login token and OIDC code
+	router := gin.New()
+	router.Use(gin.LoggerWithFormatter(accessLogFormatter), gin.Recovery())
 
 	// Setup API routes
-	api.SetupRoutes(router, k8sService)
+	api.SetupRoutes(router, k8sService, authenticator, auditLogger, accessJanitor, approval, splitList(*execLoginCommandsFlag))
 
 	// Serve embedded frontend (SPA)
 	setupFrontend(router)
@@ -68,12 +134,90 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Starting Bridge on http://localhost:%s", port)
-	if err := router.Run(":" + port); err != nil {
+	// Get bind address: CLI flag > environment variable > loopback only
+	bind := *bindFlag
+	if bind == "" {
+		bind = os.Getenv("BRIDGE_BIND")
+	}
+	if bind == "" {
+		bind = "127.0.0.1"
+	}
+
+	if authenticator.Enabled() {
+		// The token is regenerated on every start and only shown here
+		log.Printf("🔑 Open this URL to log in: http://localhost:%s/auth/login?token=%s", port, authenticator.LocalToken())
+		log.Printf("🔑 API clients can send: Authorization: Bearer %s", authenticator.LocalToken())
+		if authenticator.OIDCEnabled() {
+			log.Printf("🔑 OIDC login enabled at /auth/oidc/login")
+		}
+	} else if bind != "127.0.0.1" && bind != "localhost" {
+		log.Printf("⚠️ Authentication is disabled and Bridge is listening on %s - anyone who can reach this port has your cluster credentials", bind)
+	}
+
+	log.Printf("Starting Bridge on http://%s:%s", bind, port)
+	if err := router.Run(bind + ":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// envDuration reads a duration from the environment, falling back to def if unset or invalid
+func envDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid %s=%q: %v", key, value, err)
+		return def
+	}
+	return d
+}
+
+// splitList splits a comma-separated flag value, dropping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// redactedParams are query parameters that carry credentials
+var redactedParams = []string{"token", "code", "state"}
+
+// accessLogFormatter is gin's default access log line, with credentials in the query redacted
+func accessLogFormatter(param gin.LogFormatterParams) string {
+	if u, err := url.Parse(param.Path); err == nil && u.RawQuery != "" {
+		query := u.Query()
+		for _, key := range redactedParams {
+			if query.Has(key) {
+				query.Set(key, "REDACTED")
+			}
+		}
+		u.RawQuery = query.Encode()
+		param.Path = u.String()
+	}
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor, methodColor, resetColor = param.StatusCodeColor(), param.MethodColor(), param.ResetColor()
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		param.Path,
+		param.ErrorMessage,
+	)
+}
+
 // setupFrontend configures the router to serve the embedded frontend SPA
 func setupFrontend(router *gin.Engine) {
 	// Get the dist subdirectory from embedded filesystem